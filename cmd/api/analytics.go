@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+type StudyStreak struct {
+	CurrentDays   int        `json:"current_days"`
+	LongestDays   int        `json:"longest_days"`
+	LastStudyDate *time.Time `json:"last_study_date,omitempty"`
+}
+
+type StudentAnalyticsResponse struct {
+	From        *time.Time            `json:"from,omitempty"`
+	To          *time.Time            `json:"to,omitempty"`
+	Totals      store.ProgressStats   `json:"totals"`
+	ReviewRatio *float64              `json:"review_ratio"`
+	Streak      StudyStreak           `json:"streak"`
+	Books       []*store.BookProgress `json:"books"`
+	Weeks       []*store.WeekProgress `json:"weeks"`
+}
+
+// readDateRange parses the optional "from" and "to" query parameters.
+func (app *application) readDateRange(r *http.Request) (store.AnalyticsFilter, error) {
+	var filter store.AnalyticsFilter
+	qs := r.URL.Query()
+
+	if s := qs.Get("from"); s != "" {
+		from, err := app.parseDate(r, s)
+		if err != nil {
+			return filter, errors.New("invalid date format for from, please use YYYY-MM-DD")
+		}
+		filter.From = from
+	}
+
+	if s := qs.Get("to"); s != "" {
+		to, err := app.parseDate(r, s)
+		if err != nil {
+			return filter, errors.New("invalid date format for to, please use YYYY-MM-DD")
+		}
+		filter.To = to
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return filter, errors.New("to cannot be before from")
+	}
+
+	return filter, nil
+}
+
+func (app *application) getStudentAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	filter, err := app.readDateRange(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	books, err := app.store.Analytics.GetBookProgress(r.Context(), student.ID, filter)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	weeks, err := app.store.Analytics.GetWeeklyProgress(r.Context(), student.ID, filter)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	studyDates, err := app.store.Analytics.GetStudyDates(r.Context(), student.ID, filter)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	response := StudentAnalyticsResponse{
+		Totals: sumProgress(books),
+		Streak: calculateStudyStreak(studyDates, time.Now().In(student.Location())),
+		Books:  books,
+		Weeks:  weeks,
+	}
+	if !filter.From.IsZero() {
+		response.From = &filter.From
+	}
+	if !filter.To.IsZero() {
+		response.To = &filter.To
+	}
+	if studied := response.Totals.ReviewSessions + response.Totals.NewSessions; studied > 0 {
+		ratio := float64(response.Totals.ReviewSessions) / float64(studied)
+		response.ReviewRatio = &ratio
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"analytics": response}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func sumProgress(books []*store.BookProgress) store.ProgressStats {
+	var totals store.ProgressStats
+	for _, bp := range books {
+		totals.PlannedSessions += bp.PlannedSessions
+		totals.CompletedSessions += bp.CompletedSessions
+		totals.StudyMinutes += bp.StudyMinutes
+		totals.NumTests += bp.NumTests
+		totals.NumWrongTests += bp.NumWrongTests
+		totals.ReviewSessions += bp.ReviewSessions
+		totals.NewSessions += bp.NewSessions
+	}
+	totals.Finalize()
+	return totals
+}
+
+// calculateStudyStreak counts runs of consecutive study days in the sorted
+// dates. Fridays are rest days, so skipping one does not break a streak.
+func calculateStudyStreak(dates []time.Time, today time.Time) StudyStreak {
+	var streak StudyStreak
+	if len(dates) == 0 {
+		return streak
+	}
+
+	follows := func(prev, next time.Time) bool {
+		gap := dayNumber(next) - dayNumber(prev)
+		return gap == 1 || (gap == 2 && prev.AddDate(0, 0, 1).Weekday() == time.Friday)
+	}
+
+	run := 0
+	for i, date := range dates {
+		if i > 0 && follows(dates[i-1], date) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > streak.LongestDays {
+			streak.LongestDays = run
+		}
+	}
+
+	last := dates[len(dates)-1]
+	streak.LastStudyDate = &last
+
+	todayDate := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if dayNumber(last) == dayNumber(todayDate) || follows(last, todayDate) {
+		streak.CurrentDays = run
+	}
+
+	return streak
+}
+
+func dayNumber(t time.Time) int64 {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
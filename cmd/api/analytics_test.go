@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateStudyStreak(t *testing.T) {
+	// October 2026 from Saturday the 17th to Saturday the 24th
+	day := func(d int) time.Time {
+		return time.Date(2026, time.October, d, 0, 0, 0, 0, time.UTC)
+	}
+	days := func(ds ...int) []time.Time {
+		var dates []time.Time
+		for _, d := range ds {
+			dates = append(dates, day(d))
+		}
+		return dates
+	}
+
+	tests := []struct {
+		name    string
+		dates   []time.Time
+		today   time.Time
+		current int
+		longest int
+	}{
+		{"no study days", nil, day(22), 0, 0},
+		{"ends today", days(19, 20, 21), day(21), 3, 3},
+		{"ends yesterday", days(19, 20, 21), day(22), 3, 3},
+		{"ended two days ago", days(19, 20, 21), day(23), 0, 3},
+		{"Friday does not break the streak", days(21, 22, 24), day(24), 3, 3},
+		{"a Thursday streak is current on Saturday", days(21, 22), day(24), 2, 2},
+		{"a gap on a study day breaks the streak", days(19, 20, 22), day(22), 1, 2},
+		{"the longest streak is an earlier one", days(17, 18, 19, 21, 22), day(22), 2, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the time of day of today does not matter
+			streak := calculateStudyStreak(tt.dates, tt.today.Add(21*time.Hour))
+			if streak.CurrentDays != tt.current || streak.LongestDays != tt.longest {
+				t.Errorf("current %d, longest %d; want %d and %d", streak.CurrentDays, streak.LongestDays, tt.current, tt.longest)
+			}
+			switch {
+			case len(tt.dates) == 0 && streak.LastStudyDate != nil:
+				t.Errorf("last study date %s, want none", streak.LastStudyDate)
+			case len(tt.dates) > 0 && (streak.LastStudyDate == nil || !streak.LastStudyDate.Equal(tt.dates[len(tt.dates)-1])):
+				t.Errorf("last study date %v, want %s", streak.LastStudyDate, tt.dates[len(tt.dates)-1])
+			}
+		})
+	}
+}
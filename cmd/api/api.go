@@ -32,7 +32,10 @@ func (app *application) mount() http.Handler {
 	r.Use(middleware.Timeout(60 * time.Second))
 
 	r.Route("/v1", func(r chi.Router) {
+		r.Use(app.calendarMiddleware)
+
 		r.Get("/healthcheck", app.healthcheckHandler)
+		r.Get("/openapi.json", app.openAPIHandler)
 
 		r.Get("/grades", app.listGradesHandler)
 		r.Get("/majors", app.listMajorsHandler)
@@ -40,13 +43,18 @@ func (app *application) mount() http.Handler {
 		r.Get("/books/{bookID}/lessons", app.listLessonsForBookHandler)
 
 		r.Post("/exam-schedules", app.createExamScheduleHandler)
+		r.Post("/exam-schedules/import", app.importExamSeasonHandler)
+		r.Get("/exam-series/{seriesID}", app.getExamSeriesHandler)
 		r.Route("/exam-schedules/{examID}", func(r chi.Router) {
 			r.Use(app.examScheduleContextMiddleware)
 			r.Get("/", app.getExamScheduleHandler)
 			r.Get("/scope", app.listExamScopeItemsHandler)
 			r.Post("/scope", app.createExamScopeItemHandler)
+			r.Get("/scope/diff", app.diffExamScopeHandler)
 		})
 
+		r.Get("/calendar-feeds/{token}", app.calendarFeedHandler)
+
 		r.Post("/students", app.createStudentHandler)
 		r.Route("/students/{studentID}", func(r chi.Router) {
 			r.Use(app.studentContextMiddleware)
@@ -54,13 +62,39 @@ func (app *application) mount() http.Handler {
 			r.Patch("/", app.updateStudentHandler)
 			r.Delete("/", app.deleteStudentHandler)
 
+			r.Get("/analytics", app.getStudentAnalyticsHandler)
+			r.Get("/weak-topics", app.listWeakTopicsHandler)
+			r.Get("/exam-results", app.getExamResultHistoryHandler)
+
+			r.Post("/calendar-feed", app.createCalendarFeedHandler)
+			r.Delete("/calendar-feed", app.deleteCalendarFeedHandler)
+
 			r.Get("/exam-schedules", app.listExamSchedulesHandler)
+			r.Get("/available-exam-schedules", app.listAvailableExamSchedulesHandler)
+			r.Route("/exam-schedules/{examID}", func(r chi.Router) {
+				r.Use(app.studentExamContextMiddleware)
+				r.Get("/enrollment", app.getExamEnrollmentHandler)
+				r.Post("/enrollment", app.enrollInExamHandler)
+				r.Delete("/enrollment", app.unenrollFromExamHandler)
+
+				r.Group(func(r chi.Router) {
+					r.Use(app.requireExamEnrollmentMiddleware)
+					r.Get("/readiness", app.getExamReadinessHandler)
+					r.Post("/roadmap", app.createExamRoadmapHandler)
+
+					r.Get("/results", app.listExamResultsHandler)
+					r.Post("/results", app.recordExamResultsHandler)
+					r.Delete("/results", app.deleteExamResultsHandler)
+				})
+			})
 
 			r.Get("/unavailable-times", app.listUnavailableTimesHandler)
 			r.Post("/unavailable-times", app.createUnavailableTimeHandler)
+			r.Post("/unavailable-times/import", app.importUnavailableTimesHandler)
 
 			r.Get("/weekly-plans", app.listWeeklyPlansHandler)
 			r.Post("/weekly-plans", app.createWeeklyPlanHandler)
+			r.Post("/weekly-plans/week-of", app.getOrCreateWeekPlanHandler)
 
 			r.Route("/weekly-plans/{planID}", func(r chi.Router) {
 				r.Use(app.weeklyPlanContextMiddleware)
@@ -70,6 +104,10 @@ func (app *application) mount() http.Handler {
 
 				r.Post("/generate", app.generateWeeklyScheduleHandler)
 				r.Get("/calendar", app.getFullWeeklyCalendarHandler)
+				r.Get("/calendar.ics", app.exportWeeklyPlanICSHandler)
+				r.Get("/planner.html", app.exportWeeklyPlannerHTMLHandler)
+				r.Get("/planner.pdf", app.exportWeeklyPlannerPDFHandler)
+				r.Get("/quality", app.getWeeklyPlanQualityHandler)
 
 				r.Post("/generate", app.generateWeeklyScheduleHandler)
 				r.Get("/calendar", app.getFullWeeklyCalendarHandler)
@@ -19,13 +19,19 @@ func (app *application) listBooksForCurriculumHandler(w http.ResponseWriter, r *
 		return
 	}
 
-	books, err := app.store.Books.GetAllForCurriculum(r.Context(), gradeID, majorID)
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	books, metadata, err := app.store.Books.GetAllForCurriculum(r.Context(), gradeID, majorID, opts)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"books": books}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"books": books, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/calendar"
+)
+
+const calendarContextKey = contextKey("calendar")
+
+// calendarResponseWriter remembers the calendar the client asked for so that
+// writeJSON can convert the dates in the response.
+type calendarResponseWriter struct {
+	http.ResponseWriter
+	calendar calendar.Calendar
+}
+
+// calendarMiddleware reads the calendar the client works in from the calendar
+// query parameter or, failing that, the Accept-Calendar header. Dates in
+// requests are parsed and dates in JSON responses are written in it.
+func (app *application) calendarMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("calendar")
+		if name == "" {
+			name = r.Header.Get("Accept-Calendar")
+		}
+
+		cal, ok := calendar.Parse(name)
+		if !ok {
+			app.badRequestResponse(w, r, fmt.Errorf("unsupported calendar %q, use gregorian or jalali", name))
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Calendar")
+		if cal != calendar.Gregorian {
+			w.Header().Set("Content-Calendar", string(cal))
+			w = &calendarResponseWriter{ResponseWriter: w, calendar: cal}
+		}
+
+		ctx := context.WithValue(r.Context(), calendarContextKey, cal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (app *application) calendarFor(r *http.Request) calendar.Calendar {
+	cal, ok := r.Context().Value(calendarContextKey).(calendar.Calendar)
+	if !ok {
+		return calendar.Gregorian
+	}
+	return cal
+}
+
+// parseDate parses a YYYY-MM-DD date in the calendar of the request.
+func (app *application) parseDate(r *http.Request, s string) (time.Time, error) {
+	date, err := app.calendarFor(r).ParseDate(s)
+	if err != nil {
+		return time.Time{}, errors.New("invalid date")
+	}
+	return date, nil
+}
+
+// convertJSONDates rewrites the RFC 3339 timestamps in a JSON document in the
+// given calendar. Dates at midnight become plain YYYY-MM-DD dates, and every
+// converted field gets a sibling "<field>_weekday" with the localized weekday
+// name. Integer day_of_week fields get a "day_of_week_name" sibling.
+func convertJSONDates(js []byte, cal calendar.Calendar) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	doc = convertDateValue(doc, cal)
+	return json.MarshalIndent(doc, "", "\t")
+}
+
+func convertDateValue(value any, cal calendar.Calendar) any {
+	switch v := value.(type) {
+	case map[string]any:
+		// The weekday siblings are added once the loop is done, as a range
+		// over a map may or may not visit keys added during it
+		weekdays := make(map[string]any)
+		for key, field := range v {
+			switch field := field.(type) {
+			case string:
+				if t, ok := parseTimestamp(field); ok {
+					v[key] = formatTimestamp(t, cal)
+					weekdays[key+"_weekday"] = cal.WeekdayName(t.Weekday())
+				}
+			case json.Number:
+				if day, err := field.Int64(); err == nil && key == "day_of_week" && day >= 0 && day <= 6 {
+					// day_of_week counts from Saturday, like the scheduler
+					weekdays["day_of_week_name"] = cal.WeekdayName(time.Weekday((day + 6) % 7))
+				}
+			default:
+				v[key] = convertDateValue(field, cal)
+			}
+		}
+		maps.Copy(v, weekdays)
+	case []any:
+		for i, item := range v {
+			if s, ok := item.(string); ok {
+				if t, ok := parseTimestamp(s); ok {
+					v[i] = formatTimestamp(t, cal)
+				}
+				continue
+			}
+			v[i] = convertDateValue(item, cal)
+		}
+	}
+	return value
+}
+
+// parseTimestamp recognises the timestamps encoding/json writes for
+// time.Time. Times of day read from TIME columns carry year 0 and are left
+// alone.
+func parseTimestamp(s string) (time.Time, bool) {
+	if len(s) < len("2006-01-02T15:04:05Z") || s[4] != '-' || s[10] != 'T' {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil || t.Year() < 1000 {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func formatTimestamp(t time.Time, cal calendar.Calendar) string {
+	date := cal.FormatDate(t)
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return date
+	}
+	return date + t.Format("T15:04:05Z07:00")
+}
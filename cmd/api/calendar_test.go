@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Behehap/Alberta/internal/calendar"
+)
+
+func TestConvertJSONDates(t *testing.T) {
+	js := []byte(`{"daily_plan": {"plan_date": "2026-10-17T00:00:00Z", "day_of_week": 0,
+		"start_time": "0000-01-01T08:00:00Z", "created_at": "2026-10-19T09:30:00Z"},
+		"days": [{"day_of_week": 6}]}`)
+
+	tests := []struct {
+		cal      calendar.Calendar
+		planDate string
+		weekday  string
+		// lastDay is the name of day_of_week 6, the last day of the week
+		lastDay   string
+		createdAt string
+	}{
+		{calendar.Gregorian, "2026-10-17", "Saturday", "Friday", "2026-10-19T09:30:00Z"},
+		{calendar.Jalali, "1405-07-25", "شنبه", "جمعه", "1405-07-27T09:30:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.cal), func(t *testing.T) {
+			converted, err := convertJSONDates(js, tt.cal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var doc struct {
+				DailyPlan map[string]any   `json:"daily_plan"`
+				Days      []map[string]any `json:"days"`
+			}
+			if err := json.Unmarshal(converted, &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			want := map[string]any{
+				"plan_date":         tt.planDate,
+				"plan_date_weekday": tt.weekday,
+				"day_of_week_name":  tt.weekday,
+				// times of day are left alone
+				"start_time": "0000-01-01T08:00:00Z",
+				"created_at": tt.createdAt,
+			}
+			for key, value := range want {
+				if doc.DailyPlan[key] != value {
+					t.Errorf("%s: got %v, want %v", key, doc.DailyPlan[key], value)
+				}
+			}
+			if _, ok := doc.DailyPlan["start_time_weekday"]; ok {
+				t.Error("start_time got a weekday")
+			}
+			if got := doc.Days[0]["day_of_week_name"]; got != tt.lastDay {
+				t.Errorf("day_of_week 6: got %v, want %v", got, tt.lastDay)
+			}
+		})
+	}
+}
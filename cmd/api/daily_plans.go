@@ -4,12 +4,15 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/Behehap/Alberta/internal/store"
 	"github.com/go-chi/chi/v5"
 )
 
+type CreateDailyPlanRequest struct {
+	PlanDate string `json:"plan_date" validate:"required"`
+}
+
 func (app *application) createDailyPlanHandler(w http.ResponseWriter, r *http.Request) {
 	weeklyPlan, ok := r.Context().Value(weeklyPlanContextKey).(*store.WeeklyPlan)
 	if !ok {
@@ -17,9 +20,7 @@ func (app *application) createDailyPlanHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	var input struct {
-		PlanDate string `json:"plan_date" validate:"required"`
-	}
+	var input CreateDailyPlanRequest
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -29,11 +30,11 @@ func (app *application) createDailyPlanHandler(w http.ResponseWriter, r *http.Re
 
 	err = Validate.Struct(input)
 	if err != nil {
-		app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		app.validationErrorResponse(w, r, err)
 		return
 	}
 
-	planDate, err := time.Parse("2006-01-02", input.PlanDate)
+	planDate, err := app.parseDate(r, input.PlanDate)
 	if err != nil {
 		app.badRequestResponse(w, r, errors.New("invalid date format for plan_date, please use YYYY-MM-DD"))
 		return
@@ -46,7 +47,7 @@ func (app *application) createDailyPlanHandler(w http.ResponseWriter, r *http.Re
 
 	err = app.store.DailyPlans.Insert(r.Context(), dp)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -63,13 +64,19 @@ func (app *application) listDailyPlansHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	plans, err := app.store.DailyPlans.GetAllForWeeklyPlan(r.Context(), weeklyPlan.ID)
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	plans, metadata, err := app.store.DailyPlans.GetAllForWeeklyPlan(r.Context(), weeklyPlan.ID, opts)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"daily_plans": plans}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"daily_plans": plans, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -97,7 +104,7 @@ func (app *application) deleteDailyPlanHandler(w http.ResponseWriter, r *http.Re
 
 	err = app.store.DailyPlans.Delete(r.Context(), dailyPlanID)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+// demoBooks is the 10th grade experimental sciences curriculum the demo store
+// starts with, a small part of scripts/seed.sql.
+var demoBooks = []struct {
+	title      string
+	difficulty int
+	lessons    []string
+	frequency  int
+}{
+	{"فارسی (۱)", store.DifficultyMedium, []string{"درس اول: چشمه", "درس دوم: از آموختن، ننگ مدار", "درس سوم: پاسداری از حقیقت"}, 2},
+	{"ریاضی (۱)", store.DifficultyHeavy, []string{"فصل اول: مجموعه، الگو و دنباله", "فصل دوم: مثلثات", "فصل سوم: توان‌های گویا و عبارت‌های جبری", "فصل چهارم: معادله‌ها و نامعادله‌ها"}, 4},
+	{"شیمی (۱)", store.DifficultyHeavy, []string{"فصل اول: کیهان زادگاه الفبای هستی", "فصل دوم: ردِّپای گازها در زندگی", "فصل سوم: آب، آهنگ زندگی"}, 3},
+	{"زیست شناسی (۱)", store.DifficultyMedium, []string{"فصل اول: دنیای زنده", "فصل دوم: گوارش و جذب مواد", "فصل سوم: تبادلات گازی", "فصل چهارم: گردش مواد در بدن"}, 3},
+	{"فیزیک (۱) - تجربی", store.DifficultyHeavy, []string{"فصل اول: فیزیک و اندازه‌گیری", "فصل دوم: ویژگی‌های فیزیکی مواد", "فصل سوم: کار، انرژی و توان", "فصل چهارم: دما و گرما"}, 3},
+}
+
+// seedDemo fills an empty demo store with grades, majors, a curriculum with a
+// matching schedule template, and one student to plan for.
+func seedDemo(ctx context.Context, db *store.MemoryDB, storage *store.Storage) error {
+	var grade store.Grade
+	for _, name := range []string{"دهم", "یازدهم", "دوازدهم"} {
+		g := store.Grade{Name: name}
+		if err := db.InsertGrade(&g); err != nil {
+			return err
+		}
+		if grade.ID == 0 {
+			grade = g
+		}
+	}
+
+	var major store.Major
+	for _, name := range []string{"علوم تجربی", "ریاضی فیزیک", "علوم انسانی"} {
+		m := store.Major{Name: name}
+		if err := db.InsertMajor(&m); err != nil {
+			return err
+		}
+		if major.ID == 0 {
+			major = m
+		}
+	}
+
+	template := store.ScheduleTemplate{
+		Name:          "دهم تجربی - پایه",
+		TargetGradeID: grade.ID,
+		TargetMajorID: major.ID,
+	}
+	for _, b := range demoBooks {
+		template.TotalStudyBlocksPerWeek += b.frequency
+	}
+	if err := db.InsertScheduleTemplate(&template); err != nil {
+		return err
+	}
+
+	for _, b := range demoBooks {
+		book := store.Book{Title: b.title, InherentGradeLevelID: grade.ID, Difficulty: b.difficulty}
+		if err := db.InsertBook(&book); err != nil {
+			return err
+		}
+
+		role := store.BookRole{TargetStudentGradeID: grade.ID, MajorID: major.ID, BookID: book.ID, Role: "Core"}
+		if err := db.InsertBookRole(&role); err != nil {
+			return err
+		}
+
+		for _, name := range b.lessons {
+			if err := db.InsertLesson(&store.Lesson{Name: name, BookID: book.ID}); err != nil {
+				return err
+			}
+		}
+
+		rule := store.TemplateRule{TemplateID: template.ID, BookID: book.ID, DefaultFrequency: b.frequency}
+		if err := storage.TemplateRules.Insert(ctx, &rule); err != nil {
+			return err
+		}
+	}
+
+	student := store.Student{
+		FirstName: "سارا",
+		LastName:  "محمدی",
+		Email:     "sara@example.com",
+		GradeID:   grade.ID,
+		MajorID:   major.ID,
+	}
+	return storage.Students.Insert(ctx, &student)
+}
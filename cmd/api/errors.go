@@ -1,8 +1,11 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+
+	"github.com/Behehap/Alberta/internal/store"
 )
 
 func (app *application) logError(r *http.Request, err error) {
@@ -40,5 +43,62 @@ func (app *application) badRequestResponse(w http.ResponseWriter, r *http.Reques
 }
 
 func (app *application) failedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string) {
-	app.errorResponse(w, r, http.StatusUnprocessableEntity, errors)
+	codes := make(map[string]string, len(errors))
+	for field := range errors {
+		codes[field] = fieldCodeInvalid
+	}
+	app.fieldErrorResponse(w, r, errors, codes)
+}
+
+// fieldErrorResponse writes a failed validation response with a message and
+// a code for each field.
+func (app *application) fieldErrorResponse(w http.ResponseWriter, r *http.Request, messages, codes map[string]string) {
+	env := envelope{"error": messages, "code": codeFailedValidation, "field_codes": codes}
+
+	err := app.writeJSON(w, http.StatusUnprocessableEntity, env, nil)
+	if err != nil {
+		app.logError(r, err)
+		w.WriteHeader(500)
+	}
+}
+
+func (app *application) conflictResponse(w http.ResponseWriter, r *http.Request, message string) {
+	app.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (app *application) serviceUnavailableResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the server is busy, please try again later"
+	app.errorResponse(w, r, http.StatusServiceUnavailable, message)
+}
+
+// storeErrorResponse reports an error returned by a store method with the
+// status its kind calls for. Errors of no known kind are the server's.
+func (app *application) storeErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var dbErr *store.DBError
+	errors.As(err, &dbErr)
+
+	switch {
+	case errors.Is(err, store.ErrorNotFound):
+		app.notFoundResponse(w, r)
+	case errors.Is(err, store.ErrorDuplicateEmail):
+		app.failedValidationResponse(w, r, map[string]string{"email": "a user with this email address already exists"})
+	case errors.Is(err, store.ErrorInvalidSort):
+		app.failedValidationResponse(w, r, map[string]string{"sort": err.Error()})
+	case errors.Is(err, store.ErrorConflict):
+		app.conflictResponse(w, r, store.ErrorConflict.Error())
+	case errors.Is(err, store.ErrorForeignKey), errors.Is(err, store.ErrorValidation):
+		field, message := "error", err.Error()
+		if dbErr != nil {
+			if dbErr.Field() != "" {
+				field = dbErr.Field()
+			}
+			message = dbErr.Kind.Error()
+		}
+		app.failedValidationResponse(w, r, map[string]string{field: message})
+	case errors.Is(err, store.ErrorTimeout):
+		app.logError(r, err)
+		app.serviceUnavailableResponse(w, r)
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
 }
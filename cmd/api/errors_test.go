@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+func TestStoreErrorResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		// field is the key of the validation error, if any
+		field string
+	}{
+		{"not found", store.ErrorNotFound, http.StatusNotFound, ""},
+		{"duplicate email", store.ErrorDuplicateEmail, http.StatusUnprocessableEntity, "email"},
+		{"invalid sort", fmt.Errorf("%w: colour", store.ErrorInvalidSort), http.StatusUnprocessableEntity, "sort"},
+		{"conflict", &store.DBError{Kind: store.ErrorConflict, Err: errors.New("pq: duplicate key")}, http.StatusConflict, ""},
+		{"already enrolled", store.ErrorAlreadyEnrolled, http.StatusConflict, ""},
+		{"foreign key", &store.DBError{
+			Kind:       store.ErrorForeignKey,
+			Table:      "study_sessions",
+			Constraint: "study_sessions_book_id_fkey",
+			Err:        errors.New("pq: insert or update violates foreign key constraint"),
+		}, http.StatusUnprocessableEntity, "book_id"},
+		{"not null", &store.DBError{
+			Kind:   store.ErrorValidation,
+			Table:  "students",
+			Column: "first_name",
+			Err:    errors.New("pq: null value in column"),
+		}, http.StatusUnprocessableEntity, "first_name"},
+		{"unnamed check", &store.DBError{Kind: store.ErrorValidation, Err: errors.New("pq: invalid input syntax")}, http.StatusUnprocessableEntity, "error"},
+		{"timeout", &store.DBError{Kind: store.ErrorTimeout, Err: context.DeadlineExceeded}, http.StatusServiceUnavailable, ""},
+		{"unknown", errors.New("pq: connection refused"), http.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &application{logger: log.New(io.Discard, "", 0)}
+			rr := httptest.NewRecorder()
+			app.storeErrorResponse(rr, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+
+			if rr.Code != tt.status {
+				t.Fatalf("status %d, want %d", rr.Code, tt.status)
+			}
+			if tt.field == "" {
+				return
+			}
+
+			var body struct {
+				Error map[string]string `json:"error"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := body.Error[tt.field]; !ok || len(body.Error) != 1 {
+				t.Errorf("errors %v, want one for %s", body.Error, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+func (app *application) enrollInExamHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	exam, ok := r.Context().Value(examScheduleContextKey).(*store.ExamSchedule)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve exam schedule from context"))
+		return
+	}
+
+	enrollment := &store.ExamEnrollment{
+		StudentID: student.ID,
+		ExamID:    exam.ID,
+	}
+
+	status := http.StatusCreated
+	err := app.store.ExamEnrollments.Insert(r.Context(), enrollment)
+	if err != nil {
+		if !errors.Is(err, store.ErrorAlreadyEnrolled) {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+
+		// Enrolling twice is harmless, so return the existing enrollment
+		enrollment, err = app.store.ExamEnrollments.Get(r.Context(), student.ID, exam.ID)
+		if err != nil {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+		status = http.StatusOK
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/students/%d/exam-schedules/%d/enrollment", student.ID, exam.ID))
+
+	err = app.writeJSON(w, status, envelope{"exam_enrollment": enrollment}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getExamEnrollmentHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	exam, ok := r.Context().Value(examScheduleContextKey).(*store.ExamSchedule)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve exam schedule from context"))
+		return
+	}
+
+	enrollment, err := app.store.ExamEnrollments.Get(r.Context(), student.ID, exam.ID)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"exam_enrollment": enrollment}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) unenrollFromExamHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	exam, ok := r.Context().Value(examScheduleContextKey).(*store.ExamSchedule)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve exam schedule from context"))
+		return
+	}
+
+	err := app.store.ExamEnrollments.Delete(r.Context(), student.ID, exam.ID)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "successfully unenrolled from exam"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
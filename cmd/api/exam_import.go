@@ -0,0 +1,390 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+type ExamSeriesInput struct {
+	Title         string `json:"title" validate:"required"`
+	Organisation  string `json:"organisation"`
+	TargetGradeID int64  `json:"target_grade_id" validate:"required,gt=0"`
+	MajorID       int64  `json:"major_id" validate:"required,gt=0"`
+	FirstExamDate string `json:"first_exam_date"`
+	IntervalDays  int    `json:"interval_days" validate:"omitempty,gt=0,lte=365"`
+}
+
+type ExamImportItem struct {
+	Title    string             `json:"title" validate:"required"`
+	ExamDate string             `json:"exam_date"`
+	Scope    []ScopeRangeImport `json:"scope" validate:"required,min=1,dive"`
+}
+
+// ScopeRangeImport selects lessons FromLesson..ToLesson (1-based, in book
+// order) of a book. Leaving both out selects the whole book.
+type ScopeRangeImport struct {
+	BookTitle  string `json:"book_title" validate:"required"`
+	FromLesson int    `json:"from_lesson" validate:"omitempty,gt=0"`
+	ToLesson   int    `json:"to_lesson" validate:"omitempty,gt=0"`
+}
+
+type ExamSeasonImportRequest struct {
+	Series ExamSeriesInput  `json:"series"`
+	Exams  []ExamImportItem `json:"exams" validate:"required,min=1,max=100,dive"`
+}
+
+type ScopeRangeResult struct {
+	BookID      int64  `json:"book_id,omitempty"`
+	BookTitle   string `json:"book_title"`
+	FromLesson  int    `json:"from_lesson"`
+	ToLesson    int    `json:"to_lesson"`
+	LessonCount int    `json:"lesson_count"`
+}
+
+type ExamImportResult struct {
+	ExamID      int64               `json:"exam_id,omitempty"`
+	Title       string              `json:"title"`
+	ExamDate    string              `json:"exam_date"`
+	LessonCount int                 `json:"lesson_count"`
+	Scope       []*ScopeRangeResult `json:"scope"`
+	Errors      []string            `json:"errors,omitempty"`
+}
+
+type ExamImportReport struct {
+	DryRun bool                `json:"dry_run"`
+	Valid  bool                `json:"valid"`
+	Series *store.ExamSeries   `json:"series"`
+	Exams  []*ExamImportResult `json:"exams"`
+	Errors []string            `json:"errors,omitempty"`
+}
+
+func (app *application) importExamSeasonHandler(w http.ResponseWriter, r *http.Request) {
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	var input ExamSeasonImportRequest
+	var err error
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/csv" {
+		input, err = app.readExamSeasonCSV(w, r)
+	} else {
+		err = app.readJSON(w, r, &input)
+	}
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	err = Validate.Struct(input)
+	if err != nil {
+		app.validationErrorResponse(w, r, err)
+		return
+	}
+
+	report, season, err := app.resolveExamSeason(r, input)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+	report.DryRun = dryRun
+
+	if !report.Valid {
+		app.errorResponse(w, r, http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	if dryRun {
+		err = app.writeJSON(w, http.StatusOK, envelope{"import": report}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.store.ExamSeries.InsertSeason(r.Context(), report.Series, season)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	for i, se := range season {
+		report.Exams[i].ExamID = se.Exam.ID
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/exam-series/%d", report.Series.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"import": report}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readExamSeasonCSV reads a season from a CSV body with the header
+// exam_title,exam_date,book_title,from_lesson,to_lesson. Consecutive rows with
+// the same exam title and date form one exam. The series itself is described by the
+// series_title, organisation, target_grade_id, major_id, first_exam_date and
+// interval_days query parameters.
+func (app *application) readExamSeasonCSV(w http.ResponseWriter, r *http.Request) (ExamSeasonImportRequest, error) {
+	var input ExamSeasonImportRequest
+
+	qs := r.URL.Query()
+	input.Series.Title = qs.Get("series_title")
+	input.Series.Organisation = qs.Get("organisation")
+	input.Series.FirstExamDate = qs.Get("first_exam_date")
+
+	var err error
+	input.Series.IntervalDays, err = readIntParam(qs, "interval_days", 0)
+	if err != nil {
+		return input, err
+	}
+	gradeID, err := readIntParam(qs, "target_grade_id", 0)
+	if err != nil {
+		return input, err
+	}
+	majorID, err := readIntParam(qs, "major_id", 0)
+	if err != nil {
+		return input, err
+	}
+	input.Series.TargetGradeID = int64(gradeID)
+	input.Series.MajorID = int64(majorID)
+
+	maxBytes := 1_048_576
+	reader := csv.NewReader(http.MaxBytesReader(w, r.Body, int64(maxBytes)))
+	reader.FieldsPerRecord = 5
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return input, errors.New("body must not be empty")
+		}
+		return input, fmt.Errorf("body contains badly-formed CSV: %v", err)
+	}
+	expected := []string{"exam_title", "exam_date", "book_title", "from_lesson", "to_lesson"}
+	for i, column := range expected {
+		if strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")) != column {
+			return input, fmt.Errorf("CSV header must be %s", strings.Join(expected, ","))
+		}
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return input, fmt.Errorf("body contains badly-formed CSV: %v", err)
+		}
+
+		scope := ScopeRangeImport{BookTitle: strings.TrimSpace(record[2])}
+		for i, dst := range []*int{&scope.FromLesson, &scope.ToLesson} {
+			value := strings.TrimSpace(record[3+i])
+			if value == "" {
+				continue
+			}
+			*dst, err = strconv.Atoi(value)
+			if err != nil {
+				return input, fmt.Errorf("line %d: %s must be an integer", line, expected[3+i])
+			}
+		}
+
+		title := strings.TrimSpace(record[0])
+		examDate := strings.TrimSpace(record[1])
+		last := len(input.Exams) - 1
+		if last < 0 || input.Exams[last].Title != title || input.Exams[last].ExamDate != examDate {
+			input.Exams = append(input.Exams, ExamImportItem{Title: title, ExamDate: examDate})
+			last++
+		}
+		input.Exams[last].Scope = append(input.Exams[last].Scope, scope)
+	}
+
+	return input, nil
+}
+
+// resolveExamSeason checks the season against the database and expands each
+// scope range into lessons. Problems are collected in the report rather than
+// returned, so that a dry run can show every one of them at once.
+func (app *application) resolveExamSeason(r *http.Request, input ExamSeasonImportRequest) (*ExamImportReport, []*store.SeasonExam, error) {
+	report := &ExamImportReport{
+		Series: &store.ExamSeries{
+			Title:         input.Series.Title,
+			Organisation:  input.Series.Organisation,
+			TargetGradeID: input.Series.TargetGradeID,
+			MajorID:       input.Series.MajorID,
+		},
+	}
+	if input.Series.IntervalDays > 0 {
+		intervalDays := int64(input.Series.IntervalDays)
+		report.Series.IntervalDays = &intervalDays
+	}
+
+	if _, err := app.store.Grades.Get(r.Context(), input.Series.TargetGradeID); err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			return nil, nil, err
+		}
+		report.Errors = append(report.Errors, fmt.Sprintf("grade %d does not exist", input.Series.TargetGradeID))
+	}
+	if _, err := app.store.Majors.Get(r.Context(), input.Series.MajorID); err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			return nil, nil, err
+		}
+		report.Errors = append(report.Errors, fmt.Sprintf("major %d does not exist", input.Series.MajorID))
+	}
+
+	var firstExamDate time.Time
+	if input.Series.FirstExamDate != "" {
+		var err error
+		firstExamDate, err = app.parseDate(r, input.Series.FirstExamDate)
+		if err != nil {
+			report.Errors = append(report.Errors, "invalid date format for first_exam_date, please use YYYY-MM-DD")
+		}
+	}
+
+	type bookLessons struct {
+		book    *store.Book
+		lessons []*store.Lesson
+	}
+	books := make(map[string]*bookLessons)
+
+	var season []*store.SeasonExam
+	seenDates := make(map[string]string)
+
+	for i, item := range input.Exams {
+		result := &ExamImportResult{Title: item.Title, ExamDate: item.ExamDate}
+		report.Exams = append(report.Exams, result)
+
+		var examDate time.Time
+		switch {
+		case item.ExamDate != "":
+			var err error
+			examDate, err = app.parseDate(r, item.ExamDate)
+			if err != nil {
+				result.Errors = append(result.Errors, "invalid date format for exam_date, please use YYYY-MM-DD")
+			}
+		case !firstExamDate.IsZero() && input.Series.IntervalDays > 0:
+			examDate = firstExamDate.AddDate(0, 0, i*input.Series.IntervalDays)
+			result.ExamDate = app.calendarFor(r).FormatDate(examDate)
+		default:
+			result.Errors = append(result.Errors, "exam_date is required unless the series has first_exam_date and interval_days")
+		}
+
+		if other, ok := seenDates[result.ExamDate]; ok && !examDate.IsZero() {
+			result.Errors = append(result.Errors, fmt.Sprintf("exam_date is the same as %q", other))
+		}
+		seenDates[result.ExamDate] = item.Title
+
+		se := &store.SeasonExam{
+			Exam: &store.ExamSchedule{
+				Title:         item.Title,
+				ExamDate:      examDate,
+				Organisation:  input.Series.Organisation,
+				TargetGradeID: input.Series.TargetGradeID,
+				MajorID:       input.Series.MajorID,
+			},
+		}
+
+		seenLessons := make(map[int64]bool)
+		for _, scope := range item.Scope {
+			bl, ok := books[scope.BookTitle]
+			if !ok {
+				book, err := app.store.Books.GetByTitle(r.Context(), scope.BookTitle)
+				if err != nil && !errors.Is(err, store.ErrorNotFound) {
+					return nil, nil, err
+				}
+				bl = &bookLessons{book: book}
+				if book != nil {
+					bl.lessons, _, err = app.store.Lessons.GetAllForBook(r.Context(), book.ID, store.QueryOptions{})
+					if err != nil {
+						return nil, nil, err
+					}
+				}
+				books[scope.BookTitle] = bl
+			}
+
+			rangeResult := &ScopeRangeResult{BookTitle: scope.BookTitle, FromLesson: scope.FromLesson, ToLesson: scope.ToLesson}
+			result.Scope = append(result.Scope, rangeResult)
+
+			if bl.book == nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("book %q does not exist", scope.BookTitle))
+				continue
+			}
+			rangeResult.BookID = bl.book.ID
+
+			esi := &store.ExamScopeItem{BookID: &bl.book.ID}
+			if rangeResult.FromLesson == 0 && rangeResult.ToLesson == 0 {
+				rangeResult.FromLesson, rangeResult.ToLesson = 1, len(bl.lessons)
+			} else if rangeResult.FromLesson == 0 || rangeResult.ToLesson == 0 {
+				result.Errors = append(result.Errors, fmt.Sprintf("book %q: from_lesson and to_lesson must be given together", scope.BookTitle))
+				continue
+			}
+
+			if rangeResult.FromLesson > rangeResult.ToLesson {
+				result.Errors = append(result.Errors, fmt.Sprintf("book %q: from_lesson %d is after to_lesson %d", scope.BookTitle, rangeResult.FromLesson, rangeResult.ToLesson))
+				continue
+			}
+			if rangeResult.ToLesson > len(bl.lessons) {
+				result.Errors = append(result.Errors, fmt.Sprintf("book %q has only %d lessons", scope.BookTitle, len(bl.lessons)))
+				continue
+			}
+
+			if scope.FromLesson != 0 {
+				fromLesson, toLesson := int64(scope.FromLesson), int64(scope.ToLesson)
+				esi.FromLessonNumber, esi.ToLessonNumber = &fromLesson, &toLesson
+			}
+			se.ScopeItems = append(se.ScopeItems, esi)
+
+			for _, lesson := range bl.lessons[rangeResult.FromLesson-1 : rangeResult.ToLesson] {
+				rangeResult.LessonCount++
+				seenLessons[lesson.ID] = true
+			}
+		}
+		result.LessonCount = len(seenLessons)
+
+		season = append(season, se)
+	}
+
+	report.Valid = len(report.Errors) == 0
+	for _, result := range report.Exams {
+		if len(result.Errors) > 0 {
+			report.Valid = false
+		}
+	}
+
+	return report, season, nil
+}
+
+func (app *application) getExamSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	seriesID, err := strconv.ParseInt(chi.URLParam(r, "seriesID"), 10, 64)
+	if err != nil || seriesID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	series, err := app.store.ExamSeries.Get(r.Context(), seriesID)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	exams, _, err := app.store.ExamSchedules.GetAllForSeries(r.Context(), series.ID, store.QueryOptions{})
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"exam_series": series, "exam_schedules": exams}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+type BookReadiness struct {
+	BookID                  int64   `json:"book_id"`
+	BookTitle               string  `json:"book_title"`
+	TotalLessons            int     `json:"total_lessons"`
+	StudiedLessons          int     `json:"studied_lessons"`
+	ReviewedLessons         int     `json:"reviewed_lessons"`
+	UpcomingSessions        int     `json:"upcoming_sessions"`
+	ProjectedStudiedLessons int     `json:"projected_studied_lessons"`
+	ProjectedCoverage       float64 `json:"projected_coverage"`
+}
+
+type ExamReadinessResponse struct {
+	ExamSchedule            *store.ExamSchedule          `json:"exam_schedule"`
+	DaysRemaining           int                          `json:"days_remaining"`
+	TotalLessons            int                          `json:"total_lessons"`
+	StudiedLessons          int                          `json:"studied_lessons"`
+	ReviewedLessons         int                          `json:"reviewed_lessons"`
+	Coverage                float64                      `json:"coverage"`
+	ReviewCoverage          float64                      `json:"review_coverage"`
+	TestAccuracy            *float64                     `json:"test_accuracy"`
+	ProjectedStudiedLessons int                          `json:"projected_studied_lessons"`
+	ProjectedCoverage       float64                      `json:"projected_coverage"`
+	Books                   []*BookReadiness             `json:"books"`
+	Lessons                 []*store.ScopeLessonProgress `json:"lessons"`
+}
+
+func (app *application) getExamReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	exam, ok := r.Context().Value(examScheduleContextKey).(*store.ExamSchedule)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve exam schedule from context"))
+		return
+	}
+
+	now := time.Now().In(student.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	lessons, err := app.store.Analytics.GetScopeLessonProgress(r.Context(), student.ID, exam.ID, today, exam.ExamDate)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	var bookIDs []int64
+	seen := make(map[int64]bool)
+	for _, lesson := range lessons {
+		if !seen[lesson.BookID] {
+			seen[lesson.BookID] = true
+			bookIDs = append(bookIDs, lesson.BookID)
+		}
+	}
+
+	unassigned, err := app.store.Analytics.GetUnassignedUpcomingSessions(r.Context(), student.ID, bookIDs, today, exam.ExamDate)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	response := buildExamReadiness(exam, lessons, unassigned, today)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"readiness": response}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// buildExamReadiness summarises scope lesson progress per book and projects
+// coverage on exam day. A lesson not yet studied counts as projected when a
+// session is already planned for it, and any planned sessions of its book
+// that are not tied to a lesson are assumed to cover one new lesson each.
+func buildExamReadiness(exam *store.ExamSchedule, lessons []*store.ScopeLessonProgress, unassigned map[int64]int, today time.Time) *ExamReadinessResponse {
+	response := &ExamReadinessResponse{
+		ExamSchedule: exam,
+		Lessons:      lessons,
+		Books:        []*BookReadiness{},
+	}
+
+	if days := int(exam.ExamDate.Sub(today).Hours() / 24); days > 0 {
+		response.DaysRemaining = days
+	}
+
+	books := make(map[int64]*BookReadiness)
+	unstudiedPlanned := make(map[int64]int)
+	numTests, numWrongTests := 0, 0
+
+	for _, lesson := range lessons {
+		book, ok := books[lesson.BookID]
+		if !ok {
+			book = &BookReadiness{BookID: lesson.BookID, BookTitle: lesson.BookTitle}
+			books[lesson.BookID] = book
+			response.Books = append(response.Books, book)
+		}
+
+		book.TotalLessons++
+		book.UpcomingSessions += lesson.UpcomingSessions
+		numTests += lesson.NumTests
+		numWrongTests += lesson.NumWrongTests
+
+		switch {
+		case lesson.Studied():
+			book.StudiedLessons++
+			if lesson.Reviewed() {
+				book.ReviewedLessons++
+			}
+		case lesson.UpcomingSessions > 0:
+			unstudiedPlanned[lesson.BookID]++
+		}
+	}
+
+	for _, book := range response.Books {
+		book.UpcomingSessions += unassigned[book.BookID]
+
+		remaining := book.TotalLessons - book.StudiedLessons - unstudiedPlanned[book.BookID]
+		book.ProjectedStudiedLessons = book.StudiedLessons + unstudiedPlanned[book.BookID] + min(remaining, unassigned[book.BookID])
+		book.ProjectedCoverage = ratio(book.ProjectedStudiedLessons, book.TotalLessons)
+
+		response.TotalLessons += book.TotalLessons
+		response.StudiedLessons += book.StudiedLessons
+		response.ReviewedLessons += book.ReviewedLessons
+		response.ProjectedStudiedLessons += book.ProjectedStudiedLessons
+	}
+
+	response.Coverage = ratio(response.StudiedLessons, response.TotalLessons)
+	response.ReviewCoverage = ratio(response.ReviewedLessons, response.TotalLessons)
+	response.ProjectedCoverage = ratio(response.ProjectedStudiedLessons, response.TotalLessons)
+
+	if numTests > 0 {
+		accuracy := ratio(numTests-numWrongTests, numTests)
+		response.TestAccuracy = &accuracy
+	}
+
+	return response
+}
+
+func ratio(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+func TestBuildExamReadiness(t *testing.T) {
+	today := time.Date(2026, time.October, 19, 9, 30, 0, 0, time.UTC)
+
+	lesson := func(id, bookID int64, study, review, upcoming int) *store.ScopeLessonProgress {
+		return &store.ScopeLessonProgress{LessonID: id, BookID: bookID, StudySessions: study, ReviewSessions: review, UpcomingSessions: upcoming}
+	}
+	tested := func(p *store.ScopeLessonProgress, tests, wrong int) *store.ScopeLessonProgress {
+		p.NumTests, p.NumWrongTests = tests, wrong
+		return p
+	}
+
+	tests := []struct {
+		name       string
+		examDate   time.Time
+		lessons    []*store.ScopeLessonProgress
+		unassigned map[int64]int
+		days       int
+		total      int
+		studied    int
+		reviewed   int
+		projected  int
+		coverage   float64
+		// projected lessons and upcoming sessions by book
+		books    map[int64]int
+		upcoming map[int64]int
+		accuracy float64
+	}{
+		{
+			name:       "no lessons in scope",
+			examDate:   today.AddDate(0, 0, 10),
+			unassigned: map[int64]int{1: 3},
+			days:       10,
+			books:      map[int64]int{},
+			upcoming:   map[int64]int{},
+		},
+		{
+			// One lesson is studied, one reviewed and one planned, so the
+			// five unassigned sessions only have one lesson left to cover
+			name:     "unassigned sessions are capped by the lessons left",
+			examDate: today.AddDate(0, 0, 3),
+			lessons: []*store.ScopeLessonProgress{
+				lesson(1, 1, 1, 0, 0),
+				tested(lesson(2, 1, 0, 1, 0), 10, 2),
+				lesson(3, 1, 0, 0, 1),
+				lesson(4, 1, 0, 0, 0),
+			},
+			unassigned: map[int64]int{1: 5},
+			days:       3,
+			total:      4,
+			studied:    2,
+			reviewed:   1,
+			projected:  4,
+			coverage:   0.5,
+			books:      map[int64]int{1: 4},
+			upcoming:   map[int64]int{1: 6},
+			accuracy:   0.8,
+		},
+		{
+			// A session planned for a studied lesson projects nothing new,
+			// and unassigned sessions only count for their own book
+			name:     "unassigned sessions cover one lesson each",
+			examDate: today.AddDate(0, 0, -2),
+			lessons: []*store.ScopeLessonProgress{
+				lesson(1, 1, 2, 0, 2),
+				lesson(2, 1, 0, 0, 0),
+				lesson(3, 2, 0, 0, 0),
+				lesson(4, 2, 0, 0, 0),
+				lesson(5, 2, 0, 0, 0),
+			},
+			unassigned: map[int64]int{2: 1, 9: 4},
+			total:      5,
+			studied:    1,
+			projected:  2,
+			coverage:   0.2,
+			books:      map[int64]int{1: 1, 2: 1},
+			upcoming:   map[int64]int{1: 2, 2: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exam := &store.ExamSchedule{ID: 1, ExamDate: tt.examDate}
+			r := buildExamReadiness(exam, tt.lessons, tt.unassigned, today)
+
+			if r.DaysRemaining != tt.days {
+				t.Errorf("%d days remaining, want %d", r.DaysRemaining, tt.days)
+			}
+			if r.TotalLessons != tt.total || r.StudiedLessons != tt.studied || r.ReviewedLessons != tt.reviewed || r.ProjectedStudiedLessons != tt.projected {
+				t.Errorf("total %d, studied %d, reviewed %d, projected %d; want %d, %d, %d and %d",
+					r.TotalLessons, r.StudiedLessons, r.ReviewedLessons, r.ProjectedStudiedLessons, tt.total, tt.studied, tt.reviewed, tt.projected)
+			}
+			if r.Coverage != tt.coverage {
+				t.Errorf("coverage %v, want %v", r.Coverage, tt.coverage)
+			}
+			if want := ratio(tt.projected, tt.total); r.ProjectedCoverage != want {
+				t.Errorf("projected coverage %v, want %v", r.ProjectedCoverage, want)
+			}
+
+			books, upcoming := map[int64]int{}, map[int64]int{}
+			for _, book := range r.Books {
+				books[book.BookID] = book.ProjectedStudiedLessons
+				upcoming[book.BookID] = book.UpcomingSessions
+				if want := ratio(book.ProjectedStudiedLessons, book.TotalLessons); book.ProjectedCoverage != want {
+					t.Errorf("book %d: projected coverage %v, want %v", book.BookID, book.ProjectedCoverage, want)
+				}
+			}
+			if !maps.Equal(books, tt.books) {
+				t.Errorf("projected lessons by book %v, want %v", books, tt.books)
+			}
+			if !maps.Equal(upcoming, tt.upcoming) {
+				t.Errorf("upcoming sessions by book %v, want %v", upcoming, tt.upcoming)
+			}
+
+			switch {
+			case tt.accuracy == 0 && r.TestAccuracy != nil:
+				t.Errorf("test accuracy %v, want none", *r.TestAccuracy)
+			case tt.accuracy != 0 && (r.TestAccuracy == nil || *r.TestAccuracy != tt.accuracy):
+				t.Errorf("test accuracy %v, want %v", r.TestAccuracy, tt.accuracy)
+			}
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+type ExamResultInput struct {
+	BookID     int64   `json:"book_id" validate:"required,gt=0"`
+	RawScore   float64 `json:"raw_score"`
+	Percentage float64 `json:"percentage" validate:"gte=-100,lte=100"`
+	Rank       *int64  `json:"rank,omitempty" validate:"omitempty,gt=0"`
+	NumCorrect int     `json:"num_correct" validate:"gte=0"`
+	NumWrong   int     `json:"num_wrong" validate:"gte=0"`
+	NumBlank   int     `json:"num_blank" validate:"gte=0"`
+}
+
+type RecordExamResultsRequest struct {
+	Results []ExamResultInput `json:"results" validate:"required,min=1,max=30,dive"`
+}
+
+// WeakExamSubjectParams controls which subjects count as weak in the exam
+// history: those whose average percentage over the RecentExams latest exams
+// they appeared in is below MaxPercentage.
+type WeakExamSubjectParams struct {
+	RecentExams   int
+	MaxPercentage float64
+}
+
+var defaultWeakExamSubjectParams = WeakExamSubjectParams{
+	RecentExams:   3,
+	MaxPercentage: 40,
+}
+
+type SubjectResultPoint struct {
+	ExamID     int64     `json:"exam_id"`
+	ExamDate   time.Time `json:"exam_date"`
+	Percentage float64   `json:"percentage"`
+	Rank       *int64    `json:"rank,omitempty"`
+}
+
+// SubjectPerformance is the series of one subject's results across exams.
+// Trend is the change in percentage from the first to the last exam.
+type SubjectPerformance struct {
+	BookID            int64                 `json:"book_id"`
+	BookTitle         string                `json:"book_title"`
+	Results           []*SubjectResultPoint `json:"results"`
+	AveragePercentage float64               `json:"average_percentage"`
+	RecentPercentage  float64               `json:"recent_percentage"`
+	Trend             float64               `json:"trend"`
+	Weak              bool                  `json:"weak"`
+}
+
+type ExamPerformance struct {
+	ExamID            int64                    `json:"exam_id"`
+	ExamTitle         string                   `json:"exam_title"`
+	ExamDate          time.Time                `json:"exam_date"`
+	SeriesID          *int64                   `json:"series_id,omitempty"`
+	AveragePercentage float64                  `json:"average_percentage"`
+	Results           []*store.ExamResultEntry `json:"results"`
+}
+
+type ExamResultHistory struct {
+	Exams         []*ExamPerformance    `json:"exams"`
+	Subjects      []*SubjectPerformance `json:"subjects"`
+	WeakBookIDs   []int64               `json:"weak_book_ids"`
+	WeakThreshold float64               `json:"weak_percentage_threshold"`
+}
+
+func (app *application) recordExamResultsHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	exam, ok := r.Context().Value(examScheduleContextKey).(*store.ExamSchedule)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve exam schedule from context"))
+		return
+	}
+
+	var input RecordExamResultsRequest
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	err = Validate.Struct(input)
+	if err != nil {
+		app.validationErrorResponse(w, r, err)
+		return
+	}
+
+	seen := make(map[int64]bool)
+	results := make([]*store.ExamResult, 0, len(input.Results))
+	for i, result := range input.Results {
+		field := fmt.Sprintf("results[%d].book_id", i)
+		if seen[result.BookID] {
+			app.failedValidationResponse(w, r, map[string]string{field: "book is listed more than once"})
+			return
+		}
+		seen[result.BookID] = true
+
+		_, err := app.store.Books.Get(r.Context(), result.BookID)
+		if err != nil {
+			if errors.Is(err, store.ErrorNotFound) {
+				app.failedValidationResponse(w, r, map[string]string{field: "book does not exist"})
+				return
+			}
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+
+		er := &store.ExamResult{
+			StudentID:  student.ID,
+			ExamID:     exam.ID,
+			BookID:     result.BookID,
+			RawScore:   result.RawScore,
+			Percentage: result.Percentage,
+			Rank:       result.Rank,
+			NumCorrect: result.NumCorrect,
+			NumWrong:   result.NumWrong,
+			NumBlank:   result.NumBlank,
+		}
+		results = append(results, er)
+	}
+
+	err = app.store.ExamResults.Upsert(r.Context(), results)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	stored, _, err := app.store.ExamResults.GetAllForExam(r.Context(), student.ID, exam.ID, store.QueryOptions{})
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"exam_results": stored}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listExamResultsHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	exam, ok := r.Context().Value(examScheduleContextKey).(*store.ExamSchedule)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve exam schedule from context"))
+		return
+	}
+
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	results, metadata, err := app.store.ExamResults.GetAllForExam(r.Context(), student.ID, exam.ID, opts)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"exam_results": results, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteExamResultsHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	exam, ok := r.Context().Value(examScheduleContextKey).(*store.ExamSchedule)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve exam schedule from context"))
+		return
+	}
+
+	err := app.store.ExamResults.DeleteForExam(r.Context(), student.ID, exam.ID)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "exam results successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getExamResultHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	dateRange, err := app.readDateRange(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	filter := store.ExamResultFilter{AnalyticsFilter: dateRange}
+
+	qs := r.URL.Query()
+	params := defaultWeakExamSubjectParams
+	problems := make(map[string]string)
+
+	seriesID, err := readIntParam(qs, "series_id", 0)
+	if err != nil {
+		problems["series_id"] = err.Error()
+	}
+	filter.SeriesID = int64(seriesID)
+
+	bookID, err := readIntParam(qs, "book_id", 0)
+	if err != nil {
+		problems["book_id"] = err.Error()
+	}
+	filter.BookID = int64(bookID)
+
+	params.RecentExams, err = readIntParam(qs, "recent_exams", params.RecentExams)
+	if err != nil {
+		problems["recent_exams"] = err.Error()
+	} else if params.RecentExams < 1 || params.RecentExams > 20 {
+		problems["recent_exams"] = "must be between 1 and 20"
+	}
+
+	params.MaxPercentage, err = readFloatParam(qs, "max_percentage", params.MaxPercentage)
+	if err != nil {
+		problems["max_percentage"] = err.Error()
+	} else if params.MaxPercentage < -100 || params.MaxPercentage > 100 {
+		problems["max_percentage"] = "must be between -100 and 100"
+	}
+
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	entries, err := app.store.ExamResults.GetHistory(r.Context(), student.ID, filter)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"exam_result_history": buildExamResultHistory(entries, params)}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// buildExamResultHistory groups history entries, which are ordered by exam
+// date, both per exam and per subject.
+func buildExamResultHistory(entries []*store.ExamResultEntry, params WeakExamSubjectParams) *ExamResultHistory {
+	history := &ExamResultHistory{
+		Exams:         []*ExamPerformance{},
+		Subjects:      []*SubjectPerformance{},
+		WeakBookIDs:   []int64{},
+		WeakThreshold: params.MaxPercentage,
+	}
+
+	exams := make(map[int64]*ExamPerformance)
+	subjects := make(map[int64]*SubjectPerformance)
+
+	for _, entry := range entries {
+		exam, ok := exams[entry.ExamID]
+		if !ok {
+			exam = &ExamPerformance{
+				ExamID:    entry.ExamID,
+				ExamTitle: entry.ExamTitle,
+				ExamDate:  entry.ExamDate,
+				SeriesID:  entry.SeriesID,
+			}
+			exams[entry.ExamID] = exam
+			history.Exams = append(history.Exams, exam)
+		}
+		exam.Results = append(exam.Results, entry)
+		exam.AveragePercentage += entry.Percentage
+
+		subject, ok := subjects[entry.BookID]
+		if !ok {
+			subject = &SubjectPerformance{BookID: entry.BookID, BookTitle: entry.BookTitle}
+			subjects[entry.BookID] = subject
+			history.Subjects = append(history.Subjects, subject)
+		}
+		point := &SubjectResultPoint{ExamID: entry.ExamID, ExamDate: entry.ExamDate, Percentage: entry.Percentage, Rank: entry.Rank}
+		subject.Results = append(subject.Results, point)
+	}
+
+	for _, exam := range history.Exams {
+		exam.AveragePercentage /= float64(len(exam.Results))
+	}
+
+	for _, subject := range history.Subjects {
+		total := 0.0
+		for _, point := range subject.Results {
+			total += point.Percentage
+		}
+		subject.AveragePercentage = total / float64(len(subject.Results))
+
+		recent := subject.Results
+		if len(recent) > params.RecentExams {
+			recent = recent[len(recent)-params.RecentExams:]
+		}
+		total = 0
+		for _, point := range recent {
+			total += point.Percentage
+		}
+		subject.RecentPercentage = total / float64(len(recent))
+		subject.Trend = subject.Results[len(subject.Results)-1].Percentage - subject.Results[0].Percentage
+		subject.Weak = subject.RecentPercentage < params.MaxPercentage
+	}
+
+	sort.SliceStable(history.Subjects, func(i, j int) bool {
+		return history.Subjects[i].RecentPercentage < history.Subjects[j].RecentPercentage
+	})
+	for _, subject := range history.Subjects {
+		if subject.Weak {
+			history.WeakBookIDs = append(history.WeakBookIDs, subject.BookID)
+		}
+	}
+
+	return history
+}
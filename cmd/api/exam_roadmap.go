@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/scheduler"
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+type RoadmapWeekResult struct {
+	*scheduler.RoadmapWeek
+	WeeklyPlanID int64  `json:"weekly_plan_id,omitempty"`
+	Status       string `json:"status"`
+}
+
+type ExamRoadmapResponse struct {
+	ExamSchedule    *store.ExamSchedule  `json:"exam_schedule"`
+	ReviewStartDate time.Time            `json:"review_start_date"`
+	TotalLessons    int                  `json:"total_lessons"`
+	DryRun          bool                 `json:"dry_run"`
+	Weeks           []*RoadmapWeekResult `json:"weeks"`
+}
+
+type ExamRoadmapRequest struct {
+	DailyStudyHours int    `json:"daily_study_hours" validate:"required,gt=0,lte=16"`
+	DayStartTime    string `json:"day_start_time"`
+	ReviewDays      *int   `json:"review_days" validate:"omitempty,gte=0,lte=60"`
+	ReplaceExisting bool   `json:"replace_existing"`
+	DryRun          bool   `json:"dry_run"`
+}
+
+func (app *application) createExamRoadmapHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	exam, ok := r.Context().Value(examScheduleContextKey).(*store.ExamSchedule)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve exam schedule from context"))
+		return
+	}
+
+	var input ExamRoadmapRequest
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	err = Validate.Struct(input)
+	if err != nil {
+		app.validationErrorResponse(w, r, err)
+		return
+	}
+
+	dayStartTime, err := parseDayStartTime(input.DayStartTime)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	reviewDays := 7
+	if input.ReviewDays != nil {
+		reviewDays = *input.ReviewDays
+	}
+
+	lessons, _, err := app.store.Lessons.GetAllForExamScope(r.Context(), exam.ID, store.QueryOptions{})
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+	if len(lessons) == 0 {
+		app.failedValidationResponse(w, r, map[string]string{"exam_id": "the exam has no scope lessons to plan for"})
+		return
+	}
+
+	// Same block calculation as createWeeklyPlanHandler
+	blocksPerWeek := input.DailyStudyHours * scheduler.StudyDaysPerWeek * 60 / 100
+
+	roadmap, err := scheduler.BuildRoadmap(lessons, scheduler.RoadmapOptions{
+		Today:          time.Now().In(student.Location()),
+		ExamDate:       exam.ExamDate,
+		ReviewDays:     reviewDays,
+		BlocksPerWeek:  blocksPerWeek,
+		FirstDayOfWeek: app.config.weekStart,
+	})
+	if err != nil {
+		if errors.Is(err, scheduler.ErrNoStudyDays) {
+			app.failedValidationResponse(w, r, map[string]string{"exam_date": err.Error()})
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	response := ExamRoadmapResponse{
+		ExamSchedule:    exam,
+		ReviewStartDate: roadmap.ReviewStartDate,
+		TotalLessons:    roadmap.TotalLessons,
+		DryRun:          input.DryRun,
+	}
+
+	for _, week := range roadmap.Weeks {
+		result := &RoadmapWeekResult{RoadmapWeek: week, Status: "planned"}
+		response.Weeks = append(response.Weeks, result)
+		if input.DryRun {
+			continue
+		}
+
+		plan, err := app.store.WeeklyPlans.GetByStudentAndStartDate(r.Context(), student.ID, week.StartDateOfWeek)
+		switch {
+		case err == nil && !input.ReplaceExisting:
+			result.WeeklyPlanID = plan.ID
+			result.Status = "skipped"
+			continue
+		case err == nil:
+			result.Status = "replaced"
+		case errors.Is(err, store.ErrorNotFound):
+			plan = &store.WeeklyPlan{
+				StudentID:                student.ID,
+				StartDateOfWeek:          week.StartDateOfWeek,
+				DayStartTime:             dayStartTime,
+				MaxStudyTimeHoursPerWeek: blocksPerWeek,
+			}
+			err = app.store.WeeklyPlans.Insert(r.Context(), plan)
+			if err != nil {
+				app.storeErrorResponse(w, r, err)
+				return
+			}
+			result.Status = "created"
+		default:
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+
+		result.WeeklyPlanID = plan.ID
+		var frequencies []*store.SubjectFrequency
+		for _, frequency := range week.Frequencies {
+			frequencies = append(frequencies, &store.SubjectFrequency{
+				BookID:           frequency.BookID,
+				FrequencyPerWeek: frequency.FrequencyPerWeek,
+			})
+		}
+		err = app.store.SubjectFrequencies.ReplaceForWeeklyPlan(r.Context(), plan.ID, frequencies)
+		if err != nil {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	status := http.StatusCreated
+	if input.DryRun {
+		status = http.StatusOK
+	}
+
+	err = app.writeJSON(w, status, envelope{"roadmap": response}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -3,19 +3,20 @@ package main
 import (
 	"errors"
 	"net/http"
-	"time"
 
 	"github.com/Behehap/Alberta/internal/store"
 )
 
+type CreateExamScheduleRequest struct {
+	Title         string `json:"title" validate:"required"`
+	ExamDate      string `json:"exam_date" validate:"required"` // Expects "YYYY-MM-DD"
+	Organisation  string `json:"organisation"`
+	TargetGradeID int64  `json:"target_grade_id" validate:"required,gt=0"`
+	MajorID       int64  `json:"major_id" validate:"required,gt=0"`
+}
+
 func (app *application) createExamScheduleHandler(w http.ResponseWriter, r *http.Request) {
-	var input struct {
-		Title         string `json:"title" validate:"required"`
-		ExamDate      string `json:"exam_date" validate:"required"` // Expects "YYYY-MM-DD"
-		Organisation  string `json:"organisation"`
-		TargetGradeID int64  `json:"target_grade_id" validate:"required,gt=0"`
-		MajorID       int64  `json:"major_id" validate:"required,gt=0"`
-	}
+	var input CreateExamScheduleRequest
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -25,11 +26,11 @@ func (app *application) createExamScheduleHandler(w http.ResponseWriter, r *http
 
 	err = Validate.Struct(input)
 	if err != nil {
-		app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		app.validationErrorResponse(w, r, err)
 		return
 	}
 
-	examDate, err := time.Parse("2006-01-02", input.ExamDate)
+	examDate, err := app.parseDate(r, input.ExamDate)
 	if err != nil {
 		app.badRequestResponse(w, r, errors.New("invalid date format for exam_date, please use YYYY-MM-DD"))
 		return
@@ -45,7 +46,7 @@ func (app *application) createExamScheduleHandler(w http.ResponseWriter, r *http
 
 	err = app.store.ExamSchedules.Insert(r.Context(), es)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -62,13 +63,44 @@ func (app *application) listExamSchedulesHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	exams, err := app.store.ExamSchedules.GetAllForStudentCurriculum(r.Context(), student.GradeID, student.MajorID)
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	exams, metadata, err := app.store.ExamSchedules.GetAllForStudent(r.Context(), student.ID, opts)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"exam_schedules": exams, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listAvailableExamSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	exams, metadata, err := app.store.ExamSchedules.GetAllForStudentCurriculum(r.Context(), student.GradeID, student.MajorID, opts)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"exam_schedules": exams}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"exam_schedules": exams, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
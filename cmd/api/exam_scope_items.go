@@ -3,10 +3,29 @@ package main
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/Behehap/Alberta/internal/store"
 )
 
+// ExamScopeDiff compares the expanded scope of an exam with another one,
+// usually the previous exam of the same series.
+type ExamScopeDiff struct {
+	Exam      *store.ExamSchedule `json:"exam"`
+	Against   *store.ExamSchedule `json:"against"`
+	Added     []*store.Lesson     `json:"added"`
+	Removed   []*store.Lesson     `json:"removed"`
+	Unchanged []*store.Lesson     `json:"unchanged"`
+}
+
+type CreateExamScopeItemRequest struct {
+	LessonID         int64  `json:"lesson_id" validate:"required_without=BookID,excluded_with=BookID,omitempty,gt=0"`
+	BookID           int64  `json:"book_id" validate:"omitempty,gt=0"`
+	FromLessonNumber int64  `json:"from_lesson_number" validate:"required_with=ToLessonNumber,excluded_without=BookID,omitempty,gt=0"`
+	ToLessonNumber   int64  `json:"to_lesson_number" validate:"required_with=FromLessonNumber,omitempty,gtefield=FromLessonNumber"`
+	TitleOverride    string `json:"title_override"`
+}
+
 func (app *application) createExamScopeItemHandler(w http.ResponseWriter, r *http.Request) {
 
 	exam, ok := r.Context().Value(examScheduleContextKey).(*store.ExamSchedule)
@@ -15,10 +34,7 @@ func (app *application) createExamScopeItemHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
-	var input struct {
-		LessonID      int64  `json:"lesson_id" validate:"required,gt=0"`
-		TitleOverride string `json:"title_override"`
-	}
+	var input CreateExamScopeItemRequest
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -28,19 +44,32 @@ func (app *application) createExamScopeItemHandler(w http.ResponseWriter, r *htt
 
 	err = Validate.Struct(input)
 	if err != nil {
-		app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		app.validationErrorResponse(w, r, err)
 		return
 	}
 
 	esi := &store.ExamScopeItem{
-		ExamID:        exam.ID,
-		LessonID:      input.LessonID,
-		TitleOverride: input.TitleOverride,
+		ExamID:           exam.ID,
+		LessonID:         optionalID(input.LessonID),
+		BookID:           optionalID(input.BookID),
+		FromLessonNumber: optionalID(input.FromLessonNumber),
+		ToLessonNumber:   optionalID(input.ToLessonNumber),
+		TitleOverride:    input.TitleOverride,
+	}
+
+	validationErrors, err := app.validateScopeItemTarget(r, esi)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+	if validationErrors != nil {
+		app.failedValidationResponse(w, r, validationErrors)
+		return
 	}
 
 	err = app.store.ExamScopeItems.Insert(r.Context(), esi)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -50,6 +79,49 @@ func (app *application) createExamScopeItemHandler(w http.ResponseWriter, r *htt
 	}
 }
 
+// optionalID returns nil for an ID or number the request left out.
+func optionalID(v int64) *int64 {
+	if v <= 0 {
+		return nil
+	}
+	return &v
+}
+
+// validateScopeItemTarget checks that the lesson or book of a scope item
+// exists and that a lesson range lies within the book.
+func (app *application) validateScopeItemTarget(r *http.Request, esi *store.ExamScopeItem) (map[string]string, error) {
+	if esi.LessonID != nil {
+		_, err := app.store.Lessons.Get(r.Context(), *esi.LessonID)
+		if err != nil {
+			if errors.Is(err, store.ErrorNotFound) {
+				return map[string]string{"lesson_id": "lesson does not exist"}, nil
+			}
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	_, err := app.store.Books.Get(r.Context(), *esi.BookID)
+	if err != nil {
+		if errors.Is(err, store.ErrorNotFound) {
+			return map[string]string{"book_id": "book does not exist"}, nil
+		}
+		return nil, err
+	}
+
+	if esi.ToLessonNumber != nil {
+		lessons, _, err := app.store.Lessons.GetAllForBook(r.Context(), *esi.BookID, store.QueryOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if *esi.ToLessonNumber > int64(len(lessons)) {
+			return map[string]string{"to_lesson_number": "book has only " + strconv.Itoa(len(lessons)) + " lessons"}, nil
+		}
+	}
+
+	return nil, nil
+}
+
 func (app *application) listExamScopeItemsHandler(w http.ResponseWriter, r *http.Request) {
 
 	exam, ok := r.Context().Value(examScheduleContextKey).(*store.ExamSchedule)
@@ -58,14 +130,105 @@ func (app *application) listExamScopeItemsHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	items, err := app.store.ExamScopeItems.GetAllForExam(r.Context(), exam.ID)
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	items, metadata, err := app.store.ExamScopeItems.GetAllForExam(r.Context(), exam.ID, opts)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	lessons, _, err := app.store.Lessons.GetAllForExamScope(r.Context(), exam.ID, store.QueryOptions{})
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"exam_scope_items": items, "lessons": lessons, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// diffExamScopeHandler compares the exam's scope with the exam given by the
+// against query parameter, or with the previous exam when it is left out.
+func (app *application) diffExamScopeHandler(w http.ResponseWriter, r *http.Request) {
+
+	exam, ok := r.Context().Value(examScheduleContextKey).(*store.ExamSchedule)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve exam schedule from context"))
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"exam_scope_items": items}, nil)
+	var against *store.ExamSchedule
+	var err error
+
+	if raw := r.URL.Query().Get("against"); raw != "" {
+		againstID, parseErr := strconv.ParseInt(raw, 10, 64)
+		if parseErr != nil || againstID < 1 {
+			app.badRequestResponse(w, r, errors.New("against must be a positive integer"))
+			return
+		}
+		against, err = app.store.ExamSchedules.Get(r.Context(), againstID)
+	} else {
+		against, err = app.store.ExamSchedules.GetPrevious(r.Context(), exam)
+	}
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	lessons, _, err := app.store.Lessons.GetAllForExamScope(r.Context(), exam.ID, store.QueryOptions{})
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	againstLessons, _, err := app.store.Lessons.GetAllForExamScope(r.Context(), against.ID, store.QueryOptions{})
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"scope_diff": diffScopes(exam, against, lessons, againstLessons)}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+func diffScopes(exam, against *store.ExamSchedule, lessons, againstLessons []*store.Lesson) *ExamScopeDiff {
+	diff := &ExamScopeDiff{
+		Exam:      exam,
+		Against:   against,
+		Added:     []*store.Lesson{},
+		Removed:   []*store.Lesson{},
+		Unchanged: []*store.Lesson{},
+	}
+
+	inAgainst := make(map[int64]bool)
+	for _, lesson := range againstLessons {
+		inAgainst[lesson.ID] = true
+	}
+
+	inExam := make(map[int64]bool)
+	for _, lesson := range lessons {
+		inExam[lesson.ID] = true
+		if inAgainst[lesson.ID] {
+			diff.Unchanged = append(diff.Unchanged, lesson)
+		} else {
+			diff.Added = append(diff.Added, lesson)
+		}
+	}
+
+	for _, lesson := range againstLessons {
+		if !inExam[lesson.ID] {
+			diff.Removed = append(diff.Removed, lesson)
+		}
+	}
+
+	return diff
+}
@@ -6,18 +6,24 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/Behehap/Alberta/internal/scheduler"
 	"github.com/Behehap/Alberta/internal/store"
 )
 
 type FrequencyCalculationRequest struct {
 	SelectedSubjects []int64 `json:"selected_subjects" validate:"required,min=1,max=20"`
 	TemplateID       *int64  `json:"template_id,omitempty"`
+	BiasWeakTopics   bool    `json:"bias_weak_topics,omitempty"`
+	BiasExamResults  bool    `json:"bias_exam_results,omitempty"`
+	WeakTopicBlocks  *int    `json:"weak_topic_blocks,omitempty" validate:"omitempty,gte=0,lte=20"`
 }
 
 type FrequencyCalculationResponse struct {
 	RecommendedTemplate *store.ScheduleTemplate `json:"recommended_template"`
 	AdjustedFrequencies map[int64]int           `json:"adjusted_frequencies"`
 	TotalWeeklyBlocks   int                     `json:"total_weekly_blocks"`
+	WeakBookIDs         []int64                 `json:"weak_book_ids,omitempty"`
+	BiasedBlocks        int                     `json:"biased_blocks,omitempty"`
 }
 
 func (app *application) calculateFrequenciesHandler(w http.ResponseWriter, r *http.Request) {
@@ -42,7 +48,7 @@ func (app *application) calculateFrequenciesHandler(w http.ResponseWriter, r *ht
 
 	err = Validate.Struct(input)
 	if err != nil {
-		app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		app.validationErrorResponse(w, r, err)
 		return
 	}
 
@@ -62,7 +68,7 @@ func (app *application) calculateFrequenciesHandler(w http.ResponseWriter, r *ht
 		// Find closest template automatically
 		selectedTemplate, err = app.scheduler.FindClosestTemplate(r.Context(), student.GradeID, student.MajorID, totalWeeklyBlocks)
 		if err != nil {
-			app.serverErrorResponse(w, r, err)
+			app.storeErrorResponse(w, r, err)
 			return
 		}
 	}
@@ -80,7 +86,7 @@ func (app *application) calculateFrequenciesHandler(w http.ResponseWriter, r *ht
 		totalWeeklyBlocks,
 	)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -90,6 +96,43 @@ func (app *application) calculateFrequenciesHandler(w http.ResponseWriter, r *ht
 		TotalWeeklyBlocks:   totalWeeklyBlocks,
 	}
 
+	// Shift extra blocks toward books the student has been struggling with,
+	// either in session reports or in recorded exam results
+	var weakBookIDs []int64
+	if input.BiasWeakTopics {
+		topics, err := app.store.Analytics.GetWeakTopics(r.Context(), student.ID, defaultWeakTopicParams)
+		if err != nil {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+		weakBookIDs = append(weakBookIDs, scheduler.WeakBookIDs(topics)...)
+	}
+
+	if input.BiasExamResults {
+		entries, err := app.store.ExamResults.GetHistory(r.Context(), student.ID, store.ExamResultFilter{})
+		if err != nil {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+		weakBookIDs = append(weakBookIDs, buildExamResultHistory(entries, defaultWeakExamSubjectParams).WeakBookIDs...)
+	}
+
+	if input.BiasWeakTopics || input.BiasExamResults {
+		seen := make(map[int64]bool)
+		for _, bookID := range weakBookIDs {
+			if _, selected := adjustedFrequencies[bookID]; selected && !seen[bookID] {
+				seen[bookID] = true
+				response.WeakBookIDs = append(response.WeakBookIDs, bookID)
+			}
+		}
+
+		extraBlocks := len(response.WeakBookIDs)
+		if input.WeakTopicBlocks != nil {
+			extraBlocks = *input.WeakTopicBlocks
+		}
+		response.BiasedBlocks = scheduler.BiasFrequencies(adjustedFrequencies, response.WeakBookIDs, extraBlocks)
+	}
+
 	err = app.writeJSON(w, http.StatusOK, envelope{"frequency_calculation": response}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -143,7 +186,7 @@ func (app *application) getRecommendedTemplateHandler(w http.ResponseWriter, r *
 
 	recommendedTemplate, err := app.scheduler.FindClosestTemplate(r.Context(), student.GradeID, student.MajorID, totalWeeklyBlocks)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
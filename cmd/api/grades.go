@@ -5,13 +5,19 @@ import (
 )
 
 func (app *application) listGradesHandler(w http.ResponseWriter, r *http.Request) {
-	grades, err := app.store.Grades.GetAll(r.Context())
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	grades, metadata, err := app.store.Grades.GetAll(r.Context(), opts)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"grades": grades}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"grades": grades, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
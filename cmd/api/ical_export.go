@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/ical"
+	"github.com/Behehap/Alberta/internal/scheduler"
+	"github.com/Behehap/Alberta/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+const icalProdID = "-//Alberta//Study Planner//EN"
+
+// calendarFeedWeeks is the number of weeks, starting with the current one,
+// served by the subscription feed.
+const calendarFeedWeeks = 2
+
+func (app *application) exportWeeklyPlanICSHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	weeklyPlan, ok := r.Context().Value(weeklyPlanContextKey).(*store.WeeklyPlan)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve weekly plan from context"))
+		return
+	}
+
+	cal := &ical.Calendar{
+		ProdID: icalProdID,
+		Name:   fmt.Sprintf("%s %s - %s", student.FirstName, student.LastName, weeklyPlan.StartDateOfWeek.Format("2006-01-02")),
+	}
+
+	events, err := app.weekEvents(r.Context(), student, weeklyPlan)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+	cal.Events = events
+
+	exams, err := app.examEvents(r.Context(), student, weeklyPlan.StartDateOfWeek, weeklyPlan.StartDateOfWeek.AddDate(0, 0, 7))
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+	cal.Events = append(cal.Events, exams...)
+
+	filename := fmt.Sprintf("weekly-plan-%s.ics", weeklyPlan.StartDateOfWeek.Format("2006-01-02"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	app.writeICS(w, r, cal)
+}
+
+// createCalendarFeedHandler issues a new secret subscription URL for the
+// student. Any earlier URL stops working.
+func (app *application) createCalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	randomBytes := make([]byte, 32)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	token := base64.RawURLEncoding.EncodeToString(randomBytes)
+	hash := sha256.Sum256([]byte(token))
+
+	err = app.store.Students.SetCalendarTokenHash(r.Context(), student.ID, hash[:])
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	feed := map[string]string{
+		"url":   fmt.Sprintf("/v1/calendar-feeds/%s.ics", token),
+		"token": token,
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"calendar_feed": feed}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteCalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	err := app.store.Students.SetCalendarTokenHash(r.Context(), student.ID, nil)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "calendar feed successfully disabled"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// calendarFeedHandler serves the current and next weeks of the student the
+// token belongs to. Weeks without a weekly plan are left out.
+func (app *application) calendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSuffix(chi.URLParam(r, "token"), ".ics")
+	hash := sha256.Sum256([]byte(token))
+
+	student, err := app.store.Students.GetByCalendarTokenHash(r.Context(), hash[:])
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	now := time.Now().In(student.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	firstWeek := scheduler.WeekStart(today, app.config.weekStart)
+
+	cal := &ical.Calendar{
+		ProdID:          icalProdID,
+		Name:            fmt.Sprintf("%s %s - Study plan", student.FirstName, student.LastName),
+		RefreshInterval: time.Hour,
+	}
+
+	for i := 0; i < calendarFeedWeeks; i++ {
+		weeklyPlan, err := app.store.WeeklyPlans.GetByStudentAndStartDate(r.Context(), student.ID, firstWeek.AddDate(0, 0, 7*i))
+		if err != nil {
+			if errors.Is(err, store.ErrorNotFound) {
+				continue
+			}
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+
+		events, err := app.weekEvents(r.Context(), student, weeklyPlan)
+		if err != nil {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+		cal.Events = append(cal.Events, events...)
+	}
+
+	exams, err := app.examEvents(r.Context(), student, firstWeek, firstWeek.AddDate(0, 0, 7*calendarFeedWeeks))
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+	cal.Events = append(cal.Events, exams...)
+
+	app.writeICS(w, r, cal)
+}
+
+// weekEvents returns one timed event per study session of the weekly plan.
+// Session times are wall-clock times in the student's time zone.
+func (app *application) weekEvents(ctx context.Context, student *store.Student, weeklyPlan *store.WeeklyPlan) ([]*ical.Event, error) {
+	dailySchedules, err := app.loadWeeklyCalendar(ctx, weeklyPlan)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := student.Location()
+	var events []*ical.Event
+	for _, day := range dailySchedules {
+		for _, session := range day.StudySessions {
+			start, err := wallClock(day.DailyPlan.PlanDate, session.StartTime, loc)
+			if err != nil {
+				return nil, err
+			}
+			end, err := wallClock(day.DailyPlan.PlanDate, session.EndTime, loc)
+			if err != nil {
+				return nil, err
+			}
+
+			summary := "Study session"
+			if session.Book != nil {
+				summary = session.Book.Title
+			}
+			if session.IsCompleted {
+				summary += " (done)"
+			}
+
+			events = append(events, &ical.Event{
+				UID:        fmt.Sprintf("study-session-%d@alberta", session.ID),
+				Summary:    summary,
+				Start:      start,
+				End:        end,
+				Categories: []string{"Study"},
+			})
+		}
+	}
+
+	return events, nil
+}
+
+// examEvents returns an all-day event for each of the student's enrolled
+// exams dated within [from, until).
+func (app *application) examEvents(ctx context.Context, student *store.Student, from, until time.Time) ([]*ical.Event, error) {
+	exams, _, err := app.store.ExamSchedules.GetAllForStudent(ctx, student.ID, store.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*ical.Event
+	for _, exam := range exams {
+		if exam.ExamDate.Before(from) || !exam.ExamDate.Before(until) {
+			continue
+		}
+
+		events = append(events, &ical.Event{
+			UID:         fmt.Sprintf("exam-%d@alberta", exam.ID),
+			Summary:     exam.Title,
+			Description: exam.Organisation,
+			Start:       exam.ExamDate,
+			End:         exam.ExamDate.AddDate(0, 0, 1),
+			AllDay:      true,
+			Categories:  []string{"Exam"},
+		})
+	}
+
+	return events, nil
+}
+
+// wallClock combines the calendar date of date with an HH:MM:SS time of day
+// in loc.
+func wallClock(date time.Time, clock string, loc *time.Location) (time.Time, error) {
+	t, err := time.Parse("15:04:05", clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), nil
+}
+
+func (app *application) writeICS(w http.ResponseWriter, r *http.Request, cal *ical.Calendar) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	err := cal.Encode(w, time.Now())
+	if err != nil {
+		app.logError(r, err)
+	}
+}
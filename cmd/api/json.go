@@ -7,17 +7,8 @@ import (
 	"io"
 	"net/http"
 	"strings"
-
-	"github.com/go-playground/validator/v10"
 )
 
-var Validate *validator.Validate
-
-func init() {
-
-	Validate = validator.New(validator.WithRequiredStructEnabled())
-}
-
 type envelope map[string]any
 
 func (app *application) writeJSON(w http.ResponseWriter, status int, data envelope, headers http.Header) error {
@@ -25,6 +16,13 @@ func (app *application) writeJSON(w http.ResponseWriter, status int, data envelo
 	if err != nil {
 		return err
 	}
+
+	if cw, ok := w.(*calendarResponseWriter); ok {
+		js, err = convertJSONDates(js, cw.calendar)
+		if err != nil {
+			return err
+		}
+	}
 	js = append(js, '\n')
 
 	for key, value := range headers {
@@ -15,13 +15,19 @@ func (app *application) listLessonsForBookHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	lessons, err := app.store.Lessons.GetAllForBook(r.Context(), bookID)
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	lessons, metadata, err := app.store.Lessons.GetAllForBook(r.Context(), bookID, opts)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"lessons": lessons}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"lessons": lessons, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -6,13 +6,19 @@ import (
 
 func (app *application) listMajorsHandler(w http.ResponseWriter, r *http.Request) {
 
-	majors, err := app.store.Majors.GetAll(r.Context())
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	majors, metadata, err := app.store.Majors.GetAll(r.Context(), opts)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"majors": majors}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"majors": majors, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
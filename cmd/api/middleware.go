@@ -30,7 +30,7 @@ func (app *application) studentContextMiddleware(next http.Handler) http.Handler
 
 		student, err := app.store.Students.Get(r.Context(), studentID)
 		if err != nil {
-			app.notFoundResponse(w, r)
+			app.storeErrorResponse(w, r, err)
 			return
 		}
 
@@ -55,7 +55,7 @@ func (app *application) weeklyPlanContextMiddleware(next http.Handler) http.Hand
 
 		plan, err := app.store.WeeklyPlans.Get(r.Context(), planID)
 		if err != nil {
-			app.notFoundResponse(w, r)
+			app.storeErrorResponse(w, r, err)
 			return
 		}
 
@@ -79,7 +79,7 @@ func (app *application) dailyPlanContextMiddleware(next http.Handler) http.Handl
 
 		dailyPlan, err := app.store.DailyPlans.Get(r.Context(), dailyPlanID)
 		if err != nil {
-			app.notFoundResponse(w, r)
+			app.storeErrorResponse(w, r, err)
 			return
 		}
 
@@ -98,7 +98,7 @@ func (app *application) studySessionContextMiddleware(next http.Handler) http.Ha
 
 		session, err := app.store.StudySessions.Get(r.Context(), sessionID)
 		if err != nil {
-			app.notFoundResponse(w, r)
+			app.storeErrorResponse(w, r, err)
 			return
 		}
 
@@ -117,7 +117,7 @@ func (app *application) examScheduleContextMiddleware(next http.Handler) http.Ha
 
 		exam, err := app.store.ExamSchedules.Get(r.Context(), examID)
 		if err != nil {
-			app.notFoundResponse(w, r)
+			app.storeErrorResponse(w, r, err)
 			return
 		}
 
@@ -136,7 +136,7 @@ func (app *application) scheduleTemplateContextMiddleware(next http.Handler) htt
 
 		template, err := app.store.ScheduleTemplates.Get(r.Context(), templateID)
 		if err != nil {
-			app.notFoundResponse(w, r)
+			app.storeErrorResponse(w, r, err)
 			return
 		}
 
@@ -144,3 +144,66 @@ func (app *application) scheduleTemplateContextMiddleware(next http.Handler) htt
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// studentExamContextMiddleware loads the exam schedule from the URL and makes
+// sure it applies to the student already in the request context, either
+// because it targets the student's grade and major or because the student is
+// already enrolled in it.
+func (app *application) studentExamContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		student, ok := r.Context().Value(studentContextKey).(*store.Student)
+		if !ok {
+			app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+			return
+		}
+
+		examID, err := strconv.ParseInt(chi.URLParam(r, "examID"), 10, 64)
+		if err != nil || examID < 1 {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		exam, err := app.store.ExamSchedules.Get(r.Context(), examID)
+		if err != nil {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+
+		if exam.TargetGradeID != student.GradeID || exam.MajorID != student.MajorID {
+			_, err = app.store.ExamEnrollments.Get(r.Context(), student.ID, exam.ID)
+			if err != nil {
+				app.storeErrorResponse(w, r, err)
+				return
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), examScheduleContextKey, exam)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireExamEnrollmentMiddleware restricts exam-aware features to exams the
+// student has enrolled in. It must run after studentExamContextMiddleware.
+func (app *application) requireExamEnrollmentMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		student, ok := r.Context().Value(studentContextKey).(*store.Student)
+		if !ok {
+			app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+			return
+		}
+
+		exam, ok := r.Context().Value(examScheduleContextKey).(*store.ExamSchedule)
+		if !ok {
+			app.serverErrorResponse(w, r, errors.New("could not retrieve exam schedule from context"))
+			return
+		}
+
+		_, err := app.store.ExamEnrollments.Get(r.Context(), student.ID, exam.ID)
+		if err != nil {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Behehap/Alberta/internal/openapi"
+	"github.com/Behehap/Alberta/internal/scheduler"
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+// apiRoute documents one route of mount(). Paths are chi patterns below /v1.
+// JSON bodies and envelopes are described by example values whose types are
+// the ones the handler decodes into and writes.
+type apiRoute struct {
+	Method  string
+	Path    string
+	Handler http.HandlerFunc
+	Tag     string
+	Summary string
+	Query   []*openapi.Parameter
+	// Request is the JSON body, and RequestMedia the other media types the
+	// body may be sent as.
+	Request      any
+	RequestMedia []string
+	// Statuses are the success statuses, 200 when empty.
+	Statuses []int
+	// Response is the JSON envelope, or nil for routes that write ContentType.
+	Response    envelope
+	ContentType string
+}
+
+var (
+	pageParams  = []*openapi.Parameter{openapi.ParameterRef("page"), openapi.ParameterRef("page_size"), openapi.ParameterRef("sort")}
+	dateParams  = []*openapi.Parameter{openapi.ParameterRef("from"), openapi.ParameterRef("to")}
+	dryRunParam = openapi.ParameterRef("dry_run")
+)
+
+func queryParam(name, typ, description string, required bool) *openapi.Parameter {
+	schema := &openapi.Schema{Type: typ}
+	if typ == "integer" {
+		schema.Format = "int64"
+	}
+	return &openapi.Parameter{Name: name, In: "query", Description: description, Required: required, Schema: schema}
+}
+
+func params(groups ...[]*openapi.Parameter) []*openapi.Parameter {
+	var all []*openapi.Parameter
+	for _, group := range groups {
+		all = append(all, group...)
+	}
+	return all
+}
+
+func (app *application) apiRoutes() []apiRoute {
+	return []apiRoute{
+		{Method: "GET", Path: "/healthcheck", Handler: app.healthcheckHandler, Tag: "meta", Summary: "Report that the API is available",
+			Response: envelope{"status": "", "system_info": map[string]string{}}},
+		{Method: "GET", Path: "/openapi.json", Handler: app.openAPIHandler, Tag: "meta", Summary: "This OpenAPI document",
+			ContentType: "application/json"},
+
+		{Method: "GET", Path: "/grades", Handler: app.listGradesHandler, Tag: "curriculum", Summary: "List grades",
+			Query: pageParams, Response: envelope{"grades": []*store.Grade{}, "metadata": store.Metadata{}}},
+		{Method: "GET", Path: "/majors", Handler: app.listMajorsHandler, Tag: "curriculum", Summary: "List majors",
+			Query: pageParams, Response: envelope{"majors": []*store.Major{}, "metadata": store.Metadata{}}},
+		{Method: "GET", Path: "/curriculum/books", Handler: app.listBooksForCurriculumHandler, Tag: "curriculum", Summary: "List the books of a grade and major",
+			Query: params([]*openapi.Parameter{
+				queryParam("grade", "integer", "Grade ID", true),
+				queryParam("major", "integer", "Major ID", true),
+			}, pageParams),
+			Response: envelope{"books": []*store.Book{}, "metadata": store.Metadata{}}},
+		{Method: "GET", Path: "/books/{bookID}/lessons", Handler: app.listLessonsForBookHandler, Tag: "curriculum", Summary: "List the lessons of a book",
+			Query: pageParams, Response: envelope{"lessons": []*store.Lesson{}, "metadata": store.Metadata{}}},
+
+		{Method: "POST", Path: "/exam-schedules", Handler: app.createExamScheduleHandler, Tag: "exams", Summary: "Create an exam",
+			Request: CreateExamScheduleRequest{}, Statuses: []int{http.StatusCreated},
+			Response: envelope{"exam_schedule": &store.ExamSchedule{}}},
+		{Method: "POST", Path: "/exam-schedules/import", Handler: app.importExamSeasonHandler, Tag: "exams", Summary: "Import an exam season as a series of exams with their scope",
+			Query: []*openapi.Parameter{
+				dryRunParam,
+				queryParam("series_title", "string", "Series title, for CSV bodies", false),
+				queryParam("organisation", "string", "Organisation, for CSV bodies", false),
+				queryParam("first_exam_date", "string", "First exam date, for CSV bodies", false),
+				queryParam("interval_days", "integer", "Days between exams, for CSV bodies", false),
+				queryParam("target_grade_id", "integer", "Grade ID, for CSV bodies", false),
+				queryParam("major_id", "integer", "Major ID, for CSV bodies", false),
+			},
+			Request: ExamSeasonImportRequest{}, RequestMedia: []string{"text/csv"}, Statuses: []int{http.StatusOK, http.StatusCreated},
+			Response: envelope{"import": &ExamImportReport{}}},
+		{Method: "GET", Path: "/exam-series/{seriesID}", Handler: app.getExamSeriesHandler, Tag: "exams", Summary: "Get an exam series and its exams",
+			Response: envelope{"exam_series": &store.ExamSeries{}, "exam_schedules": []*store.ExamSchedule{}}},
+		{Method: "GET", Path: "/exam-schedules/{examID}", Handler: app.getExamScheduleHandler, Tag: "exams", Summary: "Get an exam",
+			Response: envelope{"exam_schedule": &store.ExamSchedule{}}},
+		{Method: "GET", Path: "/exam-schedules/{examID}/scope", Handler: app.listExamScopeItemsHandler, Tag: "exams", Summary: "List the scope of an exam",
+			Query:    pageParams,
+			Response: envelope{"exam_scope_items": []*store.ExamScopeItem{}, "lessons": []*store.Lesson{}, "metadata": store.Metadata{}}},
+		{Method: "POST", Path: "/exam-schedules/{examID}/scope", Handler: app.createExamScopeItemHandler, Tag: "exams", Summary: "Add a lesson or lesson range to the scope of an exam",
+			Request: CreateExamScopeItemRequest{}, Statuses: []int{http.StatusCreated},
+			Response: envelope{"exam_scope_item": &store.ExamScopeItem{}}},
+		{Method: "GET", Path: "/exam-schedules/{examID}/scope/diff", Handler: app.diffExamScopeHandler, Tag: "exams", Summary: "Compare the scope of an exam with another exam",
+			Query:    []*openapi.Parameter{queryParam("against", "integer", "Exam to compare with, the previous exam of the series by default", false)},
+			Response: envelope{"scope_diff": &ExamScopeDiff{}}},
+
+		{Method: "GET", Path: "/calendar-feeds/{token}", Handler: app.calendarFeedHandler, Tag: "calendar", Summary: "Subscribe to a student's study calendar",
+			ContentType: "text/calendar"},
+
+		{Method: "POST", Path: "/students", Handler: app.createStudentHandler, Tag: "students", Summary: "Create a student",
+			Request: CreateStudentRequest{}, Statuses: []int{http.StatusCreated},
+			Response: envelope{"student": &store.Student{}}},
+		{Method: "GET", Path: "/students/{studentID}", Handler: app.getStudentHandler, Tag: "students", Summary: "Get a student",
+			Response: envelope{"student": &store.Student{}}},
+		{Method: "PATCH", Path: "/students/{studentID}", Handler: app.updateStudentHandler, Tag: "students", Summary: "Update a student",
+			Request: UpdateStudentRequest{}, Response: envelope{"student": &store.Student{}}},
+		{Method: "DELETE", Path: "/students/{studentID}", Handler: app.deleteStudentHandler, Tag: "students", Summary: "Delete a student",
+			Response: envelope{"message": ""}},
+
+		{Method: "GET", Path: "/students/{studentID}/analytics", Handler: app.getStudentAnalyticsHandler, Tag: "analytics", Summary: "Get a student's study analytics",
+			Query: dateParams, Response: envelope{"analytics": StudentAnalyticsResponse{}}},
+		{Method: "GET", Path: "/students/{studentID}/weak-topics", Handler: app.listWeakTopicsHandler, Tag: "analytics", Summary: "List the lessons a student is weak in",
+			Query: []*openapi.Parameter{
+				queryParam("recent_sessions", "integer", "Recent sessions per lesson to consider, 1 to 50", false),
+				queryParam("max_wrong_ratio", "number", "Highest ratio of wrong tests that is not weak, 0 to 1", false),
+				queryParam("min_tests", "integer", "Fewest tests a lesson needs to be judged", false),
+				queryParam("min_score", "number", "Lowest session score that is not weak", false),
+			},
+			Response: envelope{"weak_topics": []*store.WeakTopic{}}},
+		{Method: "GET", Path: "/students/{studentID}/exam-results", Handler: app.getExamResultHistoryHandler, Tag: "analytics", Summary: "Get a student's exam results over time",
+			Query: params(dateParams, []*openapi.Parameter{
+				queryParam("series_id", "integer", "Only exams of this series", false),
+				queryParam("book_id", "integer", "Only results of this book", false),
+				queryParam("recent_exams", "integer", "Recent exams per subject to judge weakness by, 1 to 20", false),
+				queryParam("max_percentage", "number", "Highest average percentage that is weak, -100 to 100", false),
+			}),
+			Response: envelope{"exam_result_history": &ExamResultHistory{}}},
+
+		{Method: "POST", Path: "/students/{studentID}/calendar-feed", Handler: app.createCalendarFeedHandler, Tag: "calendar", Summary: "Create or replace a student's calendar feed",
+			Statuses: []int{http.StatusCreated}, Response: envelope{"calendar_feed": map[string]string{}}},
+		{Method: "DELETE", Path: "/students/{studentID}/calendar-feed", Handler: app.deleteCalendarFeedHandler, Tag: "calendar", Summary: "Disable a student's calendar feed",
+			Response: envelope{"message": ""}},
+
+		{Method: "GET", Path: "/students/{studentID}/exam-schedules", Handler: app.listExamSchedulesHandler, Tag: "exams", Summary: "List the exams a student is enrolled in",
+			Query: params(pageParams, dateParams), Response: envelope{"exam_schedules": []*store.ExamSchedule{}, "metadata": store.Metadata{}}},
+		{Method: "GET", Path: "/students/{studentID}/available-exam-schedules", Handler: app.listAvailableExamSchedulesHandler, Tag: "exams", Summary: "List the exams of a student's grade and major",
+			Query: params(pageParams, dateParams), Response: envelope{"exam_schedules": []*store.ExamSchedule{}, "metadata": store.Metadata{}}},
+		{Method: "GET", Path: "/students/{studentID}/exam-schedules/{examID}/enrollment", Handler: app.getExamEnrollmentHandler, Tag: "exams", Summary: "Get a student's enrollment in an exam",
+			Response: envelope{"exam_enrollment": &store.ExamEnrollment{}}},
+		{Method: "POST", Path: "/students/{studentID}/exam-schedules/{examID}/enrollment", Handler: app.enrollInExamHandler, Tag: "exams", Summary: "Enroll a student in an exam",
+			Statuses: []int{http.StatusOK, http.StatusCreated}, Response: envelope{"exam_enrollment": &store.ExamEnrollment{}}},
+		{Method: "DELETE", Path: "/students/{studentID}/exam-schedules/{examID}/enrollment", Handler: app.unenrollFromExamHandler, Tag: "exams", Summary: "Unenroll a student from an exam",
+			Response: envelope{"message": ""}},
+		{Method: "GET", Path: "/students/{studentID}/exam-schedules/{examID}/readiness", Handler: app.getExamReadinessHandler, Tag: "exams", Summary: "Get how ready a student is for an exam",
+			Response: envelope{"readiness": &ExamReadinessResponse{}}},
+		{Method: "POST", Path: "/students/{studentID}/exam-schedules/{examID}/roadmap", Handler: app.createExamRoadmapHandler, Tag: "exams", Summary: "Plan the weeks up to an exam",
+			Request: ExamRoadmapRequest{}, Statuses: []int{http.StatusOK, http.StatusCreated},
+			Response: envelope{"roadmap": ExamRoadmapResponse{}}},
+		{Method: "GET", Path: "/students/{studentID}/exam-schedules/{examID}/results", Handler: app.listExamResultsHandler, Tag: "exams", Summary: "List a student's results in an exam",
+			Query: pageParams, Response: envelope{"exam_results": []*store.ExamResult{}, "metadata": store.Metadata{}}},
+		{Method: "POST", Path: "/students/{studentID}/exam-schedules/{examID}/results", Handler: app.recordExamResultsHandler, Tag: "exams", Summary: "Record a student's results in an exam",
+			Request: RecordExamResultsRequest{}, Response: envelope{"exam_results": []*store.ExamResult{}}},
+		{Method: "DELETE", Path: "/students/{studentID}/exam-schedules/{examID}/results", Handler: app.deleteExamResultsHandler, Tag: "exams", Summary: "Delete a student's results in an exam",
+			Response: envelope{"message": ""}},
+
+		{Method: "GET", Path: "/students/{studentID}/unavailable-times", Handler: app.listUnavailableTimesHandler, Tag: "unavailable-times", Summary: "List the times a student cannot study",
+			Query: params(pageParams, dateParams), Response: envelope{"unavailable_times": []*UnavailableTimeDisplay{}, "metadata": store.Metadata{}}},
+		{Method: "POST", Path: "/students/{studentID}/unavailable-times", Handler: app.createUnavailableTimeHandler, Tag: "unavailable-times", Summary: "Add a time a student cannot study",
+			Request: CreateUnavailableTimeRequest{}, Statuses: []int{http.StatusCreated},
+			Response: envelope{"unavailable_time": &UnavailableTimeDisplay{}}},
+		{Method: "POST", Path: "/students/{studentID}/unavailable-times/import", Handler: app.importUnavailableTimesHandler, Tag: "unavailable-times", Summary: "Import a school timetable as unavailable times",
+			Query: []*openapi.Parameter{dryRunParam}, RequestMedia: []string{"text/calendar", "text/csv"}, Statuses: []int{http.StatusOK, http.StatusCreated},
+			Response: envelope{"import": &UnavailableImportReport{}}},
+
+		{Method: "GET", Path: "/students/{studentID}/weekly-plans", Handler: app.listWeeklyPlansHandler, Tag: "weekly-plans", Summary: "List a student's weekly plans",
+			Query: params(pageParams, dateParams), Response: envelope{"weekly_plans": []*WeeklyPlanDisplay{}, "metadata": store.Metadata{}}},
+		{Method: "POST", Path: "/students/{studentID}/weekly-plans", Handler: app.createWeeklyPlanHandler, Tag: "weekly-plans", Summary: "Create a weekly plan",
+			Request: CreateWeeklyPlanRequest{}, Response: envelope{"weekly_plan": &WeeklyPlanDisplay{}}},
+		{Method: "POST", Path: "/students/{studentID}/weekly-plans/week-of", Handler: app.getOrCreateWeekPlanHandler, Tag: "weekly-plans", Summary: "Get or create the weekly plan of the week containing a date",
+			Request: WeekOfRequest{}, Statuses: []int{http.StatusOK, http.StatusCreated},
+			Response: envelope{"weekly_plan": &WeeklyPlanDisplay{}}},
+		{Method: "GET", Path: "/students/{studentID}/weekly-plans/{planID}/recommended-template", Handler: app.getRecommendedTemplateHandler, Tag: "weekly-plans", Summary: "Recommend a schedule template for a weekly plan",
+			Query:    []*openapi.Parameter{queryParam("selected_subjects", "string", "Comma-separated book IDs", false)},
+			Response: envelope{"recommended_template": &store.ScheduleTemplate{}}},
+		{Method: "POST", Path: "/students/{studentID}/weekly-plans/{planID}/calculate-frequencies", Handler: app.calculateFrequenciesHandler, Tag: "weekly-plans", Summary: "Calculate how often to study each subject in a week",
+			Request: FrequencyCalculationRequest{}, Response: envelope{"frequency_calculation": FrequencyCalculationResponse{}}},
+		{Method: "POST", Path: "/students/{studentID}/weekly-plans/{planID}/generate", Handler: app.generateWeeklyScheduleHandler, Tag: "weekly-plans", Summary: "Generate the study sessions of a weekly plan",
+			Request: ScheduleGenerationRequest{}, Response: envelope{"message": "", "generation": &scheduler.GenerationResult{}}},
+		{Method: "GET", Path: "/students/{studentID}/weekly-plans/{planID}/calendar", Handler: app.getFullWeeklyCalendarHandler, Tag: "weekly-plans", Summary: "Get a weekly plan with its days and sessions",
+			Response: envelope{"weekly_calendar": WeeklyCalendarResponse{}}},
+		{Method: "GET", Path: "/students/{studentID}/weekly-plans/{planID}/calendar.ics", Handler: app.exportWeeklyPlanICSHandler, Tag: "weekly-plans", Summary: "Export a weekly plan as iCalendar",
+			ContentType: "text/calendar"},
+		{Method: "GET", Path: "/students/{studentID}/weekly-plans/{planID}/planner.html", Handler: app.exportWeeklyPlannerHTMLHandler, Tag: "weekly-plans", Summary: "Export a weekly plan as a printable page",
+			ContentType: "text/html"},
+		{Method: "GET", Path: "/students/{studentID}/weekly-plans/{planID}/planner.pdf", Handler: app.exportWeeklyPlannerPDFHandler, Tag: "weekly-plans", Summary: "Export a weekly plan as a PDF",
+			ContentType: "application/pdf"},
+		{Method: "GET", Path: "/students/{studentID}/weekly-plans/{planID}/quality", Handler: app.getWeeklyPlanQualityHandler, Tag: "weekly-plans", Summary: "Score how well a weekly plan is laid out",
+			Query:    []*openapi.Parameter{queryParam("template_id", "integer", "Schedule template whose time preferences are checked", false)},
+			Response: envelope{"quality": &scheduler.QualityReport{}}},
+		{Method: "GET", Path: "/students/{studentID}/weekly-plans/{planID}/subject-frequencies", Handler: app.listSubjectFrequenciesHandler, Tag: "weekly-plans", Summary: "List the subject frequencies of a weekly plan",
+			Query: pageParams, Response: envelope{"subject_frequencies": []*store.SubjectFrequency{}, "metadata": store.Metadata{}}},
+		{Method: "POST", Path: "/students/{studentID}/weekly-plans/{planID}/subject-frequencies", Handler: app.createSubjectFrequencyHandler, Tag: "weekly-plans", Summary: "Set how often to study a subject in a weekly plan",
+			Request: CreateSubjectFrequencyRequest{}, Statuses: []int{http.StatusCreated},
+			Response: envelope{"subject_frequency": &store.SubjectFrequency{}}},
+		{Method: "GET", Path: "/students/{studentID}/weekly-plans/{planID}/daily-plans", Handler: app.listDailyPlansHandler, Tag: "daily-plans", Summary: "List the daily plans of a weekly plan",
+			Query: params(pageParams, dateParams), Response: envelope{"daily_plans": []*store.DailyPlan{}, "metadata": store.Metadata{}}},
+		{Method: "POST", Path: "/students/{studentID}/weekly-plans/{planID}/daily-plans", Handler: app.createDailyPlanHandler, Tag: "daily-plans", Summary: "Create a daily plan",
+			Request: CreateDailyPlanRequest{}, Statuses: []int{http.StatusCreated},
+			Response: envelope{"daily_plan": &store.DailyPlan{}}},
+
+		{Method: "GET", Path: "/daily-plans/{dailyPlanID}", Handler: app.getDailyPlanHandler, Tag: "daily-plans", Summary: "Get a daily plan",
+			Response: envelope{"daily_plan": &store.DailyPlan{}}},
+		{Method: "GET", Path: "/daily-plans/{dailyPlanID}/study-sessions", Handler: app.listStudySessionsHandler, Tag: "study-sessions", Summary: "List the study sessions of a daily plan",
+			Query:    params(pageParams, dateParams, []*openapi.Parameter{openapi.ParameterRef("completed")}),
+			Response: envelope{"study_sessions": []*store.StudySession{}, "metadata": store.Metadata{}}},
+		{Method: "POST", Path: "/daily-plans/{dailyPlanID}/study-sessions", Handler: app.createStudySessionHandler, Tag: "study-sessions", Summary: "Add a study session to a daily plan",
+			Request: CreateStudySessionRequest{}, Statuses: []int{http.StatusCreated},
+			Response: envelope{"study_session": &store.StudySession{}}},
+
+		{Method: "GET", Path: "/study-sessions/{sessionID}", Handler: app.getStudySessionHandler, Tag: "study-sessions", Summary: "Get a study session",
+			Response: envelope{"study_session": &store.StudySession{}}},
+		{Method: "PATCH", Path: "/study-sessions/{sessionID}", Handler: app.updateStudySessionHandler, Tag: "study-sessions", Summary: "Update a study session",
+			Request: UpdateStudySessionRequest{}, Response: envelope{"study_session": &store.StudySession{}}},
+		{Method: "DELETE", Path: "/study-sessions/{sessionID}", Handler: app.deleteStudySessionHandler, Tag: "study-sessions", Summary: "Delete a study session",
+			Response: envelope{"message": ""}},
+		{Method: "GET", Path: "/study-sessions/{sessionID}/report", Handler: app.getSessionReportHandler, Tag: "study-sessions", Summary: "Get the report of a study session",
+			Response: envelope{"session_report": &store.SessionReport{}}},
+		{Method: "POST", Path: "/study-sessions/{sessionID}/report", Handler: app.createSessionReportHandler, Tag: "study-sessions", Summary: "Report on a study session",
+			Request: CreateSessionReportRequest{}, Statuses: []int{http.StatusCreated},
+			Response: envelope{"session_report": &store.SessionReport{}}},
+	}
+}
+
+var pathParamRE = regexp.MustCompile(`\{(\w+)\}`)
+
+// openAPISpec builds the OpenAPI document of the routes in apiRoutes.
+func (app *application) openAPISpec() *openapi.Document {
+	g := openapi.NewGenerator()
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:   "Alberta API",
+			Version: version,
+			Description: "Responses are JSON envelopes keyed by resource name; errors have an error key holding " +
+				"a message, or a map of messages by field when validation fails. Validation messages are in " +
+				"English or Persian following the Accept-Language header. Dates are written in the " +
+				"calendar named by the calendar query parameter or the Accept-Calendar header.",
+		},
+		Servers: []openapi.Server{{URL: "/v1"}},
+		Paths:   make(map[string]openapi.PathItem),
+		Components: openapi.Components{
+			Parameters: map[string]*openapi.Parameter{
+				"calendar":  queryParam("calendar", "string", "Calendar of the dates in the request and response: gregorian (default) or jalali", false),
+				"page":      queryParam("page", "integer", "Page to list, from 1", false),
+				"page_size": queryParam("page_size", "integer", fmt.Sprintf("Rows per page, 1 to %d; every row is listed when absent", maxPageSize), false),
+				"sort":      queryParam("sort", "string", "Field to sort by, prefixed with - for descending order", false),
+				"from":      queryParam("from", "string", "Earliest date to list, YYYY-MM-DD", false),
+				"to":        queryParam("to", "string", "Latest date to list, YYYY-MM-DD", false),
+				"completed": queryParam("completed", "boolean", "Only completed, or only uncompleted, sessions", false),
+				"dry_run":   queryParam("dry_run", "boolean", "Validate and report without saving anything", false),
+			},
+		},
+	}
+
+	tags := make(map[string]bool)
+	for _, route := range app.apiRoutes() {
+		op := &openapi.Operation{
+			OperationID: handlerName(route.Handler),
+			Summary:     route.Summary,
+			Tags:        []string{route.Tag},
+			Responses:   make(map[string]*openapi.Response),
+		}
+		if !tags[route.Tag] {
+			tags[route.Tag] = true
+			doc.Tags = append(doc.Tags, openapi.Tag{Name: route.Tag})
+		}
+
+		for _, match := range pathParamRE.FindAllStringSubmatch(route.Path, -1) {
+			schema := &openapi.Schema{Type: "integer", Format: "int64"}
+			if !strings.HasSuffix(match[1], "ID") {
+				schema = &openapi.Schema{Type: "string"}
+			}
+			op.Parameters = append(op.Parameters, &openapi.Parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+		}
+		op.Parameters = append(op.Parameters, route.Query...)
+		op.Parameters = append(op.Parameters, openapi.ParameterRef("calendar"))
+
+		if route.Request != nil || len(route.RequestMedia) > 0 {
+			op.RequestBody = &openapi.RequestBody{Required: true, Content: make(map[string]openapi.MediaType)}
+			if route.Request != nil {
+				op.RequestBody.Content["application/json"] = openapi.MediaType{Schema: g.SchemaOf(route.Request)}
+			}
+			for _, media := range route.RequestMedia {
+				op.RequestBody.Content[media] = openapi.MediaType{Schema: &openapi.Schema{Type: "string"}}
+			}
+		}
+
+		var content map[string]openapi.MediaType
+		switch {
+		case route.Response != nil:
+			content = map[string]openapi.MediaType{"application/json": {Schema: envelopeSchema(g, route.Response)}}
+		case route.ContentType == "application/pdf":
+			content = map[string]openapi.MediaType{route.ContentType: {Schema: &openapi.Schema{Type: "string", Format: "binary"}}}
+		case route.ContentType == "application/json":
+			content = map[string]openapi.MediaType{route.ContentType: {Schema: &openapi.Schema{Type: "object"}}}
+		default:
+			content = map[string]openapi.MediaType{route.ContentType: {Schema: &openapi.Schema{Type: "string"}}}
+		}
+		statuses := route.Statuses
+		if len(statuses) == 0 {
+			statuses = []int{http.StatusOK}
+		}
+		for _, status := range statuses {
+			op.Responses[strconv.Itoa(status)] = &openapi.Response{Description: http.StatusText(status), Content: content}
+		}
+		op.Responses["default"] = &openapi.Response{
+			Description: "Error",
+			Content:     map[string]openapi.MediaType{"application/json": {Schema: openapi.Ref("Error")}},
+		}
+
+		path := strings.TrimSuffix(route.Path, "/")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(openapi.PathItem)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	schemas := g.Schemas()
+	schemas["Error"] = &openapi.Schema{
+		Type:     "object",
+		Required: []string{"error"},
+		Properties: map[string]*openapi.Schema{
+			"error": {OneOf: []*openapi.Schema{
+				{Type: "string"},
+				{Type: "object", AdditionalProperties: &openapi.Schema{Type: "string"}},
+			}},
+			"code": {Type: "string", Enum: []string{codeFailedValidation}},
+			"field_codes": {
+				Type:        "object",
+				Description: "Code of the rule each field failed, keyed like the messages in error",
+				AdditionalProperties: &openapi.Schema{Type: "string", Enum: []string{
+					fieldCodeRequired, fieldCodeNotAllowed, fieldCodeTooSmall, fieldCodeTooLarge, fieldCodeTooShort,
+					fieldCodeTooLong, fieldCodeInvalidEmail, fieldCodeInvalidTimezone, fieldCodeInvalidChoice, fieldCodeInvalid,
+				}},
+			},
+		},
+	}
+	doc.Components.Schemas = schemas
+
+	return doc
+}
+
+// envelopeSchema describes a response envelope, whose keys are always present.
+func envelopeSchema(g *openapi.Generator, env envelope) *openapi.Schema {
+	s := &openapi.Schema{Type: "object", Properties: make(map[string]*openapi.Schema)}
+	for key, value := range env {
+		s.Properties[key] = g.SchemaOf(value)
+		s.Required = append(s.Required, key)
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// handlerName returns the name of a handler method without its Handler
+// suffix, such as listGrades.
+func handlerName(h http.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+	name = name[strings.LastIndex(name, ".")+1:]
+	return strings.TrimSuffix(name, "Handler")
+}
+
+func (app *application) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	js, err := json.MarshalIndent(app.openAPISpec(), "", "\t")
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(js, '\n'))
+}
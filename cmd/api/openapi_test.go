@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/openapi"
+	"github.com/Behehap/Alberta/internal/scheduler"
+	"github.com/Behehap/Alberta/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestOpenAPICoversEveryRoute fails when a route is added to mount() without
+// being documented in apiRoutes, or the other way round.
+func TestOpenAPICoversEveryRoute(t *testing.T) {
+	app := &application{}
+
+	routed := make(map[string]bool)
+	err := chi.Walk(app.mount().(chi.Routes), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = strings.TrimPrefix(route, "/v1")
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		routed[method+" "+route] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	documented := make(map[string]bool)
+	for _, route := range app.apiRoutes() {
+		key := route.Method + " " + route.Path
+		if documented[key] {
+			t.Errorf("%s is documented twice", key)
+		}
+		documented[key] = true
+		if !routed[key] {
+			t.Errorf("%s is documented but not routed", key)
+		}
+	}
+	for key := range routed {
+		if !documented[key] {
+			t.Errorf("%s is routed but not documented in apiRoutes", key)
+		}
+	}
+}
+
+// TestOpenAPISpec compares the served spec with testdata/openapi.json. The
+// spec is derived from the request and response types, so changing their
+// fields, json names or validate tags fails this test until the golden file is
+// regenerated with -update and the change reviewed as an API change.
+func TestOpenAPISpec(t *testing.T) {
+	app := &application{}
+
+	rr := httptest.NewRecorder()
+	app.mount().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /v1/openapi.json: status %d, body %s", rr.Code, rr.Body)
+	}
+	got := rr.Body.Bytes()
+
+	var doc map[string]any
+	if err := json.Unmarshal(got, &doc); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	checkRefs(t, got, doc)
+
+	golden := filepath.Join("testdata", "openapi.json")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v; run go test ./cmd/api -run TestOpenAPISpec -update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("the API no longer matches %s:\n%s\nif the change is intended, run go test ./cmd/api -run TestOpenAPISpec -update", golden, lineDiff(string(want), string(got)))
+	}
+}
+
+var refRE = regexp.MustCompile(`"\$ref": "#/components/(\w+)/(\w+)"`)
+
+// checkRefs fails for references to components the spec does not define.
+func checkRefs(t *testing.T, js []byte, doc map[string]any) {
+	t.Helper()
+
+	components, _ := doc["components"].(map[string]any)
+	for _, match := range refRE.FindAllSubmatch(js, -1) {
+		section, _ := components[string(match[1])].(map[string]any)
+		if _, ok := section[string(match[2])]; !ok {
+			t.Errorf("reference to undefined component %s/%s", match[1], match[2])
+		}
+	}
+}
+
+// lineDiff lists the lines only in want or only in got.
+func lineDiff(want, got string) string {
+	count := func(s string) map[string]int {
+		lines := make(map[string]int)
+		for _, line := range strings.Split(s, "\n") {
+			lines[strings.TrimSpace(line)]++
+		}
+		return lines
+	}
+	wantLines, gotLines := count(want), count(got)
+
+	var diff []string
+	for line, n := range wantLines {
+		if gotLines[line] < n {
+			diff = append(diff, "- "+line)
+		}
+	}
+	for line, n := range gotLines {
+		if wantLines[line] < n {
+			diff = append(diff, "+ "+line)
+		}
+	}
+	sort.Strings(diff)
+	return strings.Join(diff, "\n")
+}
+
+// newTestApplication returns an application on the demo store, with weeks
+// starting on Saturday.
+func newTestApplication(t *testing.T) *application {
+	t.Helper()
+
+	memoryDB := store.NewMemoryDB()
+	storage := store.NewMemoryStorage(memoryDB)
+	if err := seedDemo(context.Background(), memoryDB, storage); err != nil {
+		t.Fatal(err)
+	}
+
+	return &application{
+		config:    config{weekStart: time.Saturday},
+		logger:    log.New(io.Discard, "", 0),
+		store:     storage,
+		scheduler: scheduler.NewScheduler(storage),
+	}
+}
+
+// TestResponsesMatchSpec drives the handlers on the demo store and checks
+// every JSON response against the envelope apiRoutes documents for it, so
+// that a handler writing a key or field the spec does not have, or missing
+// one it does, fails here. Every documented envelope must be exercised.
+func TestResponsesMatchSpec(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.mount()
+	spec := app.openAPISpec()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	day := func(days int) string {
+		return today.AddDate(0, 0, days).Format("2006-01-02")
+	}
+	// The Saturday the current week starts on
+	week := -(int(today.Weekday()) + 1) % 7
+
+	steps := []struct {
+		method      string
+		url         string
+		contentType string
+		body        string
+	}{
+		{"GET", "/v1/healthcheck", "", ""},
+		{"GET", "/v1/grades?page=1&page_size=2&sort=-name", "", ""},
+		{"GET", "/v1/majors", "", ""},
+		{"GET", "/v1/curriculum/books?grade=1&major=1", "", ""},
+		{"GET", "/v1/books/2/lessons", "", ""},
+
+		{"POST", "/v1/exam-schedules", "", `{"title": "آزمون جامع", "exam_date": "` + day(40) + `", "organisation": "قلم‌چی", "target_grade_id": 1, "major_id": 1}`},
+		{"POST", "/v1/exam-schedules/1/scope", "", `{"lesson_id": 1}`},
+		{"POST", "/v1/exam-schedules/1/scope", "", `{"book_id": 2, "from_lesson_number": 1, "to_lesson_number": 2}`},
+		{"POST", "/v1/exam-schedules/import", "", `{
+			"series": {"title": "آزمون‌های ماهانه", "target_grade_id": 1, "major_id": 1, "first_exam_date": "` + day(20) + `", "interval_days": 14},
+			"exams": [
+				{"title": "ماهانه ۱", "scope": [{"book_title": "فارسی (۱)"}]},
+				{"title": "ماهانه ۲", "scope": [{"book_title": "ریاضی (۱)", "from_lesson": 1, "to_lesson": 2}]}
+			]}`},
+		{"GET", "/v1/exam-series/1", "", ""},
+		{"GET", "/v1/exam-schedules/1", "", ""},
+		{"GET", "/v1/exam-schedules/1/scope", "", ""},
+		{"GET", "/v1/exam-schedules/3/scope/diff", "", ""},
+
+		{"POST", "/v1/students", "", `{"first_name": "علی", "last_name": "رضایی", "email": "ali@example.com", "grade_id": 1, "major_id": 1, "timezone": "Asia/Tehran"}`},
+		{"GET", "/v1/students/2", "", ""},
+		{"PATCH", "/v1/students/2", "", `{"phone_number": "09120000000"}`},
+		{"DELETE", "/v1/students/2", "", ""},
+
+		{"GET", "/v1/students/1/available-exam-schedules", "", ""},
+		{"POST", "/v1/students/1/exam-schedules/1/enrollment", "", ""},
+		{"POST", "/v1/students/1/exam-schedules/2/enrollment", "", ""},
+		{"GET", "/v1/students/1/exam-schedules/1/enrollment", "", ""},
+		{"GET", "/v1/students/1/exam-schedules", "", ""},
+		{"POST", "/v1/students/1/exam-schedules/1/results", "", `{"results": [
+			{"book_id": 1, "raw_score": 12, "percentage": 40, "rank": 120, "num_correct": 10, "num_wrong": 2, "num_blank": 3},
+			{"book_id": 2, "raw_score": 20, "percentage": 66.7, "num_correct": 12, "num_wrong": 1, "num_blank": 2}]}`},
+		{"GET", "/v1/students/1/exam-schedules/1/results", "", ""},
+		{"GET", "/v1/students/1/exam-schedules/1/readiness", "", ""},
+		{"POST", "/v1/students/1/exam-schedules/1/roadmap", "", `{"daily_study_hours": 4, "dry_run": true}`},
+
+		{"POST", "/v1/students/1/unavailable-times", "", `{"title": "مدرسه", "day_of_week": 1, "start_time": "07:30", "end_time": "13:00", "is_recurring": true}`},
+		{"POST", "/v1/students/1/unavailable-times/import", "text/csv", "day,start_time,end_time,title\n3,15:00,17:00,کلاس زبان\n"},
+		{"GET", "/v1/students/1/unavailable-times", "", ""},
+
+		{"POST", "/v1/students/1/weekly-plans", "", `{"start_date_of_week": "` + day(week) + `", "daily_study_hours": 6}`},
+		{"POST", "/v1/students/1/weekly-plans/week-of", "", `{"date": "` + day(week+2) + `"}`},
+		{"POST", "/v1/students/1/weekly-plans/week-of", "", `{"date": "` + day(week+7) + `", "daily_study_hours": 4}`},
+		{"GET", "/v1/students/1/weekly-plans", "", ""},
+		{"POST", "/v1/students/1/weekly-plans/2/daily-plans", "", `{"plan_date": "` + day(week+8) + `"}`},
+		{"GET", "/v1/students/1/weekly-plans/2/daily-plans", "", ""},
+		{"GET", "/v1/daily-plans/1", "", ""},
+		{"POST", "/v1/daily-plans/1/study-sessions", "", `{"book_id": 1, "lesson_id": 1, "start_time": "08:00", "end_time": "09:40"}`},
+		{"GET", "/v1/daily-plans/1/study-sessions", "", ""},
+		{"PATCH", "/v1/study-sessions/1", "", `{"is_completed": true}`},
+		{"POST", "/v1/study-sessions/1/report", "", `{"num_tests": 20, "num_wrong_tests": 4, "session_score": 80, "notes": "مرور خوب"}`},
+		{"GET", "/v1/study-sessions/1/report", "", ""},
+		{"GET", "/v1/study-sessions/1", "", ""},
+
+		{"GET", "/v1/students/1/weekly-plans/1/recommended-template?selected_subjects=1,2,3", "", ""},
+		{"POST", "/v1/students/1/weekly-plans/1/calculate-frequencies", "", `{"selected_subjects": [1, 2, 3], "bias_exam_results": true}`},
+		{"POST", "/v1/students/1/weekly-plans/1/subject-frequencies", "", `{"book_id": 4, "frequency_per_week": 2}`},
+		{"GET", "/v1/students/1/weekly-plans/1/subject-frequencies", "", ""},
+		{"POST", "/v1/students/1/weekly-plans/1/generate", "", `{"subject_frequencies": {"1": 2, "2": 3, "3": 2}, "seed": 42}`},
+		{"GET", "/v1/students/1/weekly-plans/1/calendar", "", ""},
+		{"GET", "/v1/students/1/weekly-plans/1/quality?template_id=1", "", ""},
+		{"GET", "/v1/students/1/weekly-plans/1/calendar.ics", "", ""},
+		{"GET", "/v1/students/1/weekly-plans/1/planner.html", "", ""},
+
+		{"GET", "/v1/students/1/analytics", "", ""},
+		{"GET", "/v1/students/1/weak-topics", "", ""},
+		{"GET", "/v1/students/1/exam-results", "", ""},
+		{"POST", "/v1/students/1/calendar-feed", "", ""},
+		{"DELETE", "/v1/students/1/calendar-feed", "", ""},
+
+		{"DELETE", "/v1/study-sessions/1", "", ""},
+		{"DELETE", "/v1/students/1/exam-schedules/1/results", "", ""},
+		{"DELETE", "/v1/students/1/exam-schedules/2/enrollment", "", ""},
+	}
+
+	routes := handler.(chi.Routes)
+	checked := make(map[string]bool)
+	for _, step := range steps {
+		name := step.method + " " + step.url
+
+		var body io.Reader
+		if step.body != "" {
+			body = strings.NewReader(step.body)
+		}
+		req := httptest.NewRequest(step.method, step.url, body)
+		if step.contentType != "" {
+			req.Header.Set("Content-Type", step.contentType)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code < 200 || rr.Code > 299 {
+			// Later steps build on the earlier ones
+			t.Fatalf("%s: status %d, body %s", name, rr.Code, rr.Body)
+		}
+
+		rctx := chi.NewRouteContext()
+		if !routes.Match(rctx, step.method, req.URL.Path) {
+			t.Fatalf("%s: no route matches", name)
+		}
+		path := strings.TrimPrefix(rctx.RoutePattern(), "/v1")
+		op := spec.Paths[path][strings.ToLower(step.method)]
+		if op == nil {
+			t.Fatalf("%s: %s %s is not in the spec", name, step.method, path)
+		}
+		response := op.Responses[strconv.Itoa(rr.Code)]
+		if response == nil {
+			t.Errorf("%s: status %d is not documented", name, rr.Code)
+			continue
+		}
+		media, ok := response.Content["application/json"]
+		if !ok {
+			continue
+		}
+		checked[step.method+" "+path] = true
+
+		decoder := json.NewDecoder(rr.Body)
+		decoder.UseNumber()
+		var v any
+		if err := decoder.Decode(&v); err != nil {
+			t.Errorf("%s: response is not JSON: %v", name, err)
+			continue
+		}
+		for _, problem := range schemaProblems(spec.Components.Schemas, media.Schema, v, "response") {
+			t.Errorf("%s: %s", name, problem)
+		}
+	}
+
+	for _, route := range app.apiRoutes() {
+		if route.Response != nil && !checked[route.Method+" "+route.Path] {
+			t.Errorf("%s %s is documented with an envelope but not exercised", route.Method, route.Path)
+		}
+	}
+}
+
+// schemaProblems lists where v, decoded from JSON with UseNumber, does not
+// match s. Slices and maps may be null, as encoding/json writes nil ones.
+func schemaProblems(schemas map[string]*openapi.Schema, s *openapi.Schema, v any, path string) []string {
+	if s.Ref != "" {
+		s = schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	if v == nil {
+		if s.Type == "" || s.Nullable || s.Type == "array" || (s.Type == "object" && s.AdditionalProperties != nil) {
+			return nil
+		}
+		return []string{path + " is null"}
+	}
+	if len(s.OneOf) > 0 {
+		for _, option := range s.OneOf {
+			if len(schemaProblems(schemas, option, v, path)) == 0 {
+				return nil
+			}
+		}
+		return []string{path + " matches none of its schemas"}
+	}
+
+	var problems []string
+	mismatch := func() []string {
+		return []string{fmt.Sprintf("%s is %T, want %s", path, v, s.Type)}
+	}
+	switch s.Type {
+	case "object":
+		object, ok := v.(map[string]any)
+		if !ok {
+			return mismatch()
+		}
+		for _, key := range s.Required {
+			if _, ok := object[key]; !ok {
+				problems = append(problems, path+"."+key+" is missing")
+			}
+		}
+		for key, value := range object {
+			property := s.Properties[key]
+			if property == nil {
+				property = s.AdditionalProperties
+			}
+			if property == nil {
+				problems = append(problems, path+"."+key+" is not in the spec")
+				continue
+			}
+			problems = append(problems, schemaProblems(schemas, property, value, path+"."+key)...)
+		}
+	case "array":
+		array, ok := v.([]any)
+		if !ok {
+			return mismatch()
+		}
+		for i, item := range array {
+			problems = append(problems, schemaProblems(schemas, s.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return mismatch()
+		}
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, str) {
+			problems = append(problems, fmt.Sprintf("%s is %q, want one of %v", path, str, s.Enum))
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return mismatch()
+		}
+		if _, err := n.Int64(); err != nil {
+			problems = append(problems, fmt.Sprintf("%s is %s, want an integer", path, n))
+		}
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			return mismatch()
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return mismatch()
+		}
+	}
+	return problems
+}
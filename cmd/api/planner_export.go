@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/planner"
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+func (app *application) exportWeeklyPlannerHTMLHandler(w http.ResponseWriter, r *http.Request) {
+	p, _, ok := app.plannerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	err := planner.WriteHTML(&buf, p)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func (app *application) exportWeeklyPlannerPDFHandler(w http.ResponseWriter, r *http.Request) {
+	if app.plannerFont == nil {
+		app.errorResponse(w, r, http.StatusNotImplemented, "PDF planners are not available because no planner font is configured")
+		return
+	}
+
+	p, weeklyPlan, ok := app.plannerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	err := planner.WritePDF(&buf, p, app.plannerFont)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	filename := fmt.Sprintf("weekly-plan-%s.pdf", weeklyPlan.StartDateOfWeek.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// plannerFromContext builds the planner of the weekly plan in the request
+// context and returns it with the plan, writing an error response when it
+// cannot.
+func (app *application) plannerFromContext(w http.ResponseWriter, r *http.Request) (*planner.Planner, *store.WeeklyPlan, bool) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return nil, nil, false
+	}
+
+	weeklyPlan, ok := r.Context().Value(weeklyPlanContextKey).(*store.WeeklyPlan)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve weekly plan from context"))
+		return nil, nil, false
+	}
+
+	p, err := app.buildPlanner(r.Context(), student, weeklyPlan)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return nil, nil, false
+	}
+	return p, weeklyPlan, true
+}
+
+func (app *application) buildPlanner(ctx context.Context, student *store.Student, weeklyPlan *store.WeeklyPlan) (*planner.Planner, error) {
+	dailySchedules, err := app.loadWeeklyCalendar(ctx, weeklyPlan)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []planner.Session
+	for _, day := range dailySchedules {
+		for _, session := range day.StudySessions {
+			start, err := time.Parse("15:04:05", session.StartTime)
+			if err != nil {
+				return nil, err
+			}
+			end, err := time.Parse("15:04:05", session.EndTime)
+			if err != nil {
+				return nil, err
+			}
+
+			title := "مطالعه"
+			if session.Book != nil {
+				title = session.Book.Title
+			}
+
+			sessions = append(sessions, planner.Session{
+				Date:  day.DailyPlan.PlanDate,
+				Start: start,
+				End:   end,
+				Title: title,
+				Done:  session.IsCompleted,
+			})
+		}
+	}
+
+	unavailable, _, err := app.store.UnavailableTimes.GetAllForStudent(ctx, student.ID, store.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	exams, _, err := app.store.ExamSchedules.GetAllForStudent(ctx, student.ID, store.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(student.Location())
+
+	return planner.Build(planner.Input{
+		Student:      student.FirstName + " " + student.LastName,
+		WeekStart:    weeklyPlan.StartDateOfWeek,
+		DayStartTime: weeklyPlan.DayStartTime,
+		Sessions:     sessions,
+		Unavailable:  unavailable,
+		Exams:        exams,
+		Today:        time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+	}), nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Behehap/Alberta/internal/scheduler"
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+// getWeeklyPlanQualityHandler scores the sessions of a weekly plan against its
+// subject frequencies and, when template_id is given, the time preferences of
+// that template's rules.
+func (app *application) getWeeklyPlanQualityHandler(w http.ResponseWriter, r *http.Request) {
+	weeklyPlan, ok := r.Context().Value(weeklyPlanContextKey).(*store.WeeklyPlan)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve weekly plan from context"))
+		return
+	}
+
+	templateID, err := readIntParam(r.URL.Query(), "template_id", 0)
+	if err != nil {
+		app.failedValidationResponse(w, r, map[string]string{"template_id": err.Error()})
+		return
+	}
+	if templateID < 0 {
+		app.failedValidationResponse(w, r, map[string]string{"template_id": "must be a positive integer"})
+		return
+	}
+
+	var rules []*store.TemplateRule
+	if templateID > 0 {
+		if _, err := app.store.ScheduleTemplates.Get(r.Context(), int64(templateID)); err != nil {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+		rules, _, err = app.store.TemplateRules.GetAllForTemplate(r.Context(), int64(templateID), store.QueryOptions{})
+		if err != nil {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	frequencies, _, err := app.store.SubjectFrequencies.GetAllForWeeklyPlan(r.Context(), weeklyPlan.ID, store.QueryOptions{})
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	dailyPlans, _, err := app.store.DailyPlans.GetAllForWeeklyPlan(r.Context(), weeklyPlan.ID, store.QueryOptions{})
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	titles := make(map[int64]string)
+	for _, sf := range frequencies {
+		titles[sf.BookID] = ""
+	}
+	var days []scheduler.DaySessions
+	for _, dp := range dailyPlans {
+		sessions, _, err := app.store.StudySessions.GetAllForDailyPlan(r.Context(), dp.ID, store.QueryOptions{})
+		if err != nil {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+		for _, ss := range sessions {
+			titles[ss.BookID] = ""
+		}
+		days = append(days, scheduler.DaySessions{Date: dp.PlanDate, Sessions: sessions})
+	}
+
+	for bookID := range titles {
+		book, err := app.store.Books.Get(r.Context(), bookID)
+		if err != nil {
+			app.logger.Printf("Warning: Could not retrieve book %d for the quality report: %v", bookID, err)
+			continue
+		}
+		titles[bookID] = book.Title
+	}
+
+	report, err := scheduler.AssessWeek(weeklyPlan.StartDateOfWeek, days, frequencies, rules, titles)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"quality": report}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+// readIntParam returns the integer query parameter key, or defaultValue when
+// it is absent.
+func readIntParam(qs url.Values, key string, defaultValue int) (int, error) {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return defaultValue, fmt.Errorf("%s must be an integer value", key)
+	}
+
+	return i, nil
+}
+
+// readFloatParam returns the numeric query parameter key, or defaultValue when
+// it is absent.
+func readFloatParam(qs url.Values, key string, defaultValue float64) (float64, error) {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue, nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return defaultValue, fmt.Errorf("%s must be a numeric value", key)
+	}
+
+	return f, nil
+}
+
+// maxPageSize caps the page_size query parameter of list endpoints.
+const maxPageSize = 100
+
+// readQueryOptions reads the page, page_size, sort, from, to and completed
+// query parameters of a list endpoint. Without page_size every row is listed.
+// Problems are keyed by parameter for failedValidationResponse.
+func (app *application) readQueryOptions(r *http.Request) (store.QueryOptions, map[string]string) {
+	var opts store.QueryOptions
+	qs := r.URL.Query()
+	problems := make(map[string]string)
+
+	var err error
+	opts.Page, err = readIntParam(qs, "page", 1)
+	if err != nil {
+		problems["page"] = err.Error()
+	} else if opts.Page < 1 || opts.Page > 10_000_000 {
+		problems["page"] = "must be between 1 and 10000000"
+	}
+
+	opts.PageSize, err = readIntParam(qs, "page_size", 0)
+	if err != nil {
+		problems["page_size"] = err.Error()
+	} else if qs.Has("page_size") && (opts.PageSize < 1 || opts.PageSize > maxPageSize) {
+		problems["page_size"] = fmt.Sprintf("must be between 1 and %d", maxPageSize)
+	}
+
+	opts.Sort = qs.Get("sort")
+
+	if s := qs.Get("from"); s != "" {
+		opts.From, err = app.parseDate(r, s)
+		if err != nil {
+			problems["from"] = "must be a date in YYYY-MM-DD format"
+		}
+	}
+	if s := qs.Get("to"); s != "" {
+		opts.To, err = app.parseDate(r, s)
+		if err != nil {
+			problems["to"] = "must be a date in YYYY-MM-DD format"
+		}
+	}
+	if !opts.From.IsZero() && !opts.To.IsZero() && opts.To.Before(opts.From) {
+		problems["to"] = "cannot be before from"
+	}
+
+	if s := qs.Get("completed"); s != "" {
+		completed, err := strconv.ParseBool(s)
+		if err != nil {
+			problems["completed"] = "must be true or false"
+		} else {
+			opts.Completed = &completed
+		}
+	}
+
+	return opts, problems
+}
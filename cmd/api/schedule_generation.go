@@ -2,14 +2,25 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
 	"net/http"
+	"slices"
 
 	"github.com/Behehap/Alberta/internal/store"
 )
 
+// maxSeed bounds the seeds of generations. Seeds stay below 2^53 so that
+// clients reading JSON numbers as doubles, as JavaScript does, can send them
+// back unchanged.
+const maxSeed = 1 << 53
+
 type ScheduleGenerationRequest struct {
 	ScheduleTemplateID *int64        `json:"schedule_template_id,omitempty"`
 	SubjectFrequencies map[int64]int `json:"subject_frequencies" validate:"required,min=1"`
+	// Seed reproduces an earlier generation. Without it a new one is drawn.
+	Seed *int64 `json:"seed,omitempty" validate:"omitempty,gte=0,lt=9007199254740992"`
 }
 
 func (app *application) generateWeeklyScheduleHandler(w http.ResponseWriter, r *http.Request) {
@@ -34,7 +45,7 @@ func (app *application) generateWeeklyScheduleHandler(w http.ResponseWriter, r *
 
 	err = Validate.Struct(input)
 	if err != nil {
-		app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		app.validationErrorResponse(w, r, err)
 		return
 	}
 
@@ -44,49 +55,62 @@ func (app *application) generateWeeklyScheduleHandler(w http.ResponseWriter, r *
 		totalStudyBlocks += freq
 	}
 
-	// Convert frequency map to SubjectFrequency slice for the scheduler
+	// Convert frequency map to SubjectFrequency slice for the scheduler, in
+	// book order so that the request's map order does not matter
 	var subjectFrequencies []*store.SubjectFrequency
-	for bookID, frequency := range input.SubjectFrequencies {
+	for _, bookID := range slices.Sorted(maps.Keys(input.SubjectFrequencies)) {
 		sf := &store.SubjectFrequency{
 			WeeklyPlanID:     weeklyPlan.ID,
 			BookID:           bookID,
-			FrequencyPerWeek: frequency,
+			FrequencyPerWeek: input.SubjectFrequencies[bookID],
 		}
 		subjectFrequencies = append(subjectFrequencies, sf)
 	}
 
+	seed := rand.Int64N(maxSeed)
+	if input.Seed != nil {
+		seed = *input.Seed
+	}
+
 	// Get template rules if template ID is provided
 	var templateRules []*store.TemplateRule
 	if input.ScheduleTemplateID != nil {
-		templateRules, err = app.store.TemplateRules.GetAllForTemplate(r.Context(), *input.ScheduleTemplateID)
+		templateRules, _, err = app.store.TemplateRules.GetAllForTemplate(r.Context(), *input.ScheduleTemplateID, store.QueryOptions{})
 		if err != nil {
 			app.logger.Printf("Could not retrieve template rules for template %d: %v", *input.ScheduleTemplateID, err)
 			templateRules = []*store.TemplateRule{}
 		}
 	}
 
-	unavailableTimes, err := app.store.UnavailableTimes.GetAllForStudent(r.Context(), student.ID)
+	unavailableTimes, _, err := app.store.UnavailableTimes.GetAllForStudent(r.Context(), student.ID, store.QueryOptions{})
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.scheduler.GenerateWeeklyPlan(
+	result, err := app.scheduler.GenerateWeeklyPlan(
 		r.Context(),
 		student.ID,
 		weeklyPlan.ID,
 		weeklyPlan.StartDateOfWeek,
+		student.Location(),
 		totalStudyBlocks,
 		unavailableTimes,
 		subjectFrequencies,
 		templateRules,
+		seed,
 	)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"message": "weekly schedule generated successfully"}, nil)
+	message := "weekly schedule generated successfully"
+	if result.Unplaced > 0 {
+		message = fmt.Sprintf("weekly schedule generated with %d of %d blocks unplaced", result.Unplaced, result.Requested)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": message, "generation": result}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -23,13 +23,19 @@ func (app *application) listScheduleTemplatesHandler(w http.ResponseWriter, r *h
 		return
 	}
 
-	templates, err := app.store.ScheduleTemplates.GetAll(r.Context(), gradeID, majorID)
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	templates, metadata, err := app.store.ScheduleTemplates.GetAll(r.Context(), gradeID, majorID, opts)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"schedule_templates": templates}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"schedule_templates": templates, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
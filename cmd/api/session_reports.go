@@ -7,6 +7,14 @@ import (
 	"github.com/Behehap/Alberta/internal/store"
 )
 
+type CreateSessionReportRequest struct {
+	IsReview      bool    `json:"is_review"`
+	NumTests      int     `json:"num_tests"`
+	NumWrongTests int     `json:"num_wrong_tests"`
+	SessionScore  float64 `json:"session_score"`
+	Notes         string  `json:"notes"`
+}
+
 func (app *application) createSessionReportHandler(w http.ResponseWriter, r *http.Request) {
 
 	studySession, ok := r.Context().Value(studySessionContextKey).(*store.StudySession)
@@ -15,13 +23,7 @@ func (app *application) createSessionReportHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
-	var input struct {
-		IsReview      bool    `json:"is_review"`
-		NumTests      int     `json:"num_tests"`
-		NumWrongTests int     `json:"num_wrong_tests"`
-		SessionScore  float64 `json:"session_score"`
-		Notes         string  `json:"notes"`
-	}
+	var input CreateSessionReportRequest
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -31,7 +33,7 @@ func (app *application) createSessionReportHandler(w http.ResponseWriter, r *htt
 
 	err = Validate.Struct(input)
 	if err != nil {
-		app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		app.validationErrorResponse(w, r, err)
 		return
 	}
 
@@ -47,7 +49,7 @@ func (app *application) createSessionReportHandler(w http.ResponseWriter, r *htt
 
 	err = app.store.SessionReports.Insert(r.Context(), sr)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -67,11 +69,7 @@ func (app *application) getSessionReportHandler(w http.ResponseWriter, r *http.R
 
 	report, err := app.store.SessionReports.GetForStudySession(r.Context(), studySession.ID)
 	if err != nil {
-		if errors.Is(err, store.ErrorNotFound) {
-			app.notFoundResponse(w, r)
-			return
-		}
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
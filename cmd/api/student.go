@@ -9,16 +9,19 @@ import (
 	"github.com/Behehap/Alberta/internal/store"
 )
 
+type CreateStudentRequest struct {
+	FirstName   string `json:"first_name" validate:"required"`
+	LastName    string `json:"last_name" validate:"required"`
+	Email       string `json:"email" validate:"required,email"`
+	PhoneNumber string `json:"phone_number"`
+	GradeID     int64  `json:"grade_id" validate:"required,gt=0"`
+	MajorID     int64  `json:"major_id" validate:"required,gt=0"`
+	Timezone    string `json:"timezone" validate:"omitempty,timezone"`
+}
+
 func (app *application) createStudentHandler(w http.ResponseWriter, r *http.Request) {
 
-	var input struct {
-		FirstName   string `json:"first_name" validate:"required"`
-		LastName    string `json:"last_name" validate:"required"`
-		Email       string `json:"email" validate:"required,email"`
-		PhoneNumber string `json:"phone_number"`
-		GradeID     int64  `json:"grade_id" validate:"required,gt=0"`
-		MajorID     int64  `json:"major_id" validate:"required,gt=0"`
-	}
+	var input CreateStudentRequest
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -28,7 +31,7 @@ func (app *application) createStudentHandler(w http.ResponseWriter, r *http.Requ
 
 	err = Validate.Struct(input)
 	if err != nil {
-		app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		app.validationErrorResponse(w, r, err)
 		return
 	}
 
@@ -39,16 +42,13 @@ func (app *application) createStudentHandler(w http.ResponseWriter, r *http.Requ
 		PhoneNumber: input.PhoneNumber,
 		GradeID:     input.GradeID,
 		MajorID:     input.MajorID,
+		Timezone:    input.Timezone,
 	}
 
 	err = app.store.Students.Insert(r.Context(), student)
 	if err != nil {
 
-		if errors.Is(err, store.ErrorDuplicateEmail) {
-			app.failedValidationResponse(w, r, map[string]string{"email": "a user with this email address already exists"})
-			return
-		}
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -75,6 +75,16 @@ func (app *application) getStudentHandler(w http.ResponseWriter, r *http.Request
 	}
 }
 
+type UpdateStudentRequest struct {
+	FirstName   *string `json:"first_name"`
+	LastName    *string `json:"last_name"`
+	Email       *string `json:"email" validate:"omitempty,email"`
+	PhoneNumber *string `json:"phone_number"`
+	GradeID     *int64  `json:"grade_id" validate:"omitempty,gt=0"`
+	MajorID     *int64  `json:"major_id" validate:"omitempty,gt=0"`
+	Timezone    *string `json:"timezone" validate:"omitempty,timezone"`
+}
+
 func (app *application) updateStudentHandler(w http.ResponseWriter, r *http.Request) {
 
 	student, ok := r.Context().Value(studentContextKey).(*store.Student)
@@ -83,14 +93,7 @@ func (app *application) updateStudentHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	var input struct {
-		FirstName   *string `json:"first_name"`
-		LastName    *string `json:"last_name"`
-		Email       *string `json:"email" validate:"omitempty,email"`
-		PhoneNumber *string `json:"phone_number"`
-		GradeID     *int64  `json:"grade_id" validate:"omitempty,gt=0"`
-		MajorID     *int64  `json:"major_id" validate:"omitempty,gt=0"`
-	}
+	var input UpdateStudentRequest
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -116,20 +119,25 @@ func (app *application) updateStudentHandler(w http.ResponseWriter, r *http.Requ
 	if input.MajorID != nil {
 		student.MajorID = *input.MajorID
 	}
+	if input.Timezone != nil {
+		student.Timezone = *input.Timezone
+	}
+
+	err = Validate.Struct(input)
+	if err != nil {
+		app.validationErrorResponse(w, r, err)
+		return
+	}
 
 	err = Validate.Struct(student)
 	if err != nil {
-		app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		app.validationErrorResponse(w, r, err)
 		return
 	}
 
 	err = app.store.Students.Update(r.Context(), student)
 	if err != nil {
-		if errors.Is(err, store.ErrorDuplicateEmail) {
-			app.failedValidationResponse(w, r, map[string]string{"email": "a user with this email address already exists"})
-			return
-		}
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -149,7 +157,7 @@ func (app *application) deleteStudentHandler(w http.ResponseWriter, r *http.Requ
 
 	err := app.store.Students.Delete(r.Context(), student.ID)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
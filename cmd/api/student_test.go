@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+// serve runs one request through the routes of app.
+func serve(t *testing.T, app *application, method, url, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	rr := httptest.NewRecorder()
+	app.mount().ServeHTTP(rr, httptest.NewRequest(method, url, r))
+	return rr
+}
+
+func TestStudentHandlers(t *testing.T) {
+	app := newTestApplication(t)
+
+	rr := serve(t, app, http.MethodGet, "/v1/students/99", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown student: status %d, want 404", rr.Code)
+	}
+
+	rr = serve(t, app, http.MethodPost, "/v1/students", `{"first_name": "سارا", "last_name": "احمدی", "email": "sara@example.com", "grade_id": 1, "major_id": 1}`)
+	if rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), `"email"`) {
+		t.Errorf("taken email: status %d, body %s; want 422 for email", rr.Code, rr.Body)
+	}
+
+	rr = serve(t, app, http.MethodPost, "/v1/students", `{"first_name": "علی", "last_name": "رضایی", "email": "ali@example.com", "grade_id": 1, "major_id": 9}`)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("unknown major: status %d, want 422", rr.Code)
+	}
+
+	rr = serve(t, app, http.MethodPost, "/v1/students", `{"first_name": "علی", "last_name": "رضایی", "email": "ali@example.com", "grade_id": 1, "major_id": 1, "timezone": "Local"}`)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Local timezone: status %d, want 422", rr.Code)
+	}
+
+	rr = serve(t, app, http.MethodPost, "/v1/students", `{"first_name": "علی", "last_name": "رضایی", "email": "ali@example.com", "grade_id": 1, "major_id": 1}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body %s", rr.Code, rr.Body)
+	}
+	var created struct {
+		Student store.Student `json:"student"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Student.ID != 2 || created.Student.Timezone != store.DefaultTimezone {
+		t.Errorf("created %+v, want student 2 in %s", created.Student, store.DefaultTimezone)
+	}
+
+	rr = serve(t, app, http.MethodPatch, "/v1/students/2", `{"email": "sara@example.com"}`)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("update to a taken email: status %d, want 422", rr.Code)
+	}
+
+	date := time.Now().UTC().Format("2006-01-02")
+	rr = serve(t, app, http.MethodPost, "/v1/students/2/weekly-plans/week-of", `{"date": "`+date+`", "daily_study_hours": 5}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("week-of: status %d, body %s", rr.Code, rr.Body)
+	}
+	rr = serve(t, app, http.MethodPost, "/v1/students/2/weekly-plans/week-of", `{"date": "`+date+`"}`)
+	if rr.Code != http.StatusOK {
+		t.Errorf("week-of an existing week: status %d, want 200", rr.Code)
+	}
+
+	rr = serve(t, app, http.MethodDelete, "/v1/students/2", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("delete: status %d, body %s", rr.Code, rr.Body)
+	}
+
+	// Deleting a student deletes their weekly plans
+	rr = serve(t, app, http.MethodGet, "/v1/students/2", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("deleted student: status %d, want 404", rr.Code)
+	}
+	if _, err := app.store.WeeklyPlans.Get(context.Background(), 1); !errors.Is(err, store.ErrorNotFound) {
+		t.Errorf("weekly plan of a deleted student: got %v, want ErrorNotFound", err)
+	}
+}
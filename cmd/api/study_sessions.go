@@ -11,6 +11,13 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
+type CreateStudySessionRequest struct {
+	BookID    int64  `json:"book_id" validate:"required,gt=0"`
+	LessonID  *int64 `json:"lesson_id" validate:"omitempty,gt=0"`
+	StartTime string `json:"start_time" validate:"required"`
+	EndTime   string `json:"end_time" validate:"required"`
+}
+
 func (app *application) createStudySessionHandler(w http.ResponseWriter, r *http.Request) {
 	dailyPlanID, err := strconv.ParseInt(chi.URLParam(r, "dailyPlanID"), 10, 64)
 	if err != nil || dailyPlanID < 1 {
@@ -18,11 +25,7 @@ func (app *application) createStudySessionHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	var input struct {
-		BookID    int64  `json:"book_id" validate:"required,gt=0"`
-		StartTime string `json:"start_time" validate:"required"`
-		EndTime   string `json:"end_time" validate:"required"`
-	}
+	var input CreateStudySessionRequest
 
 	err = app.readJSON(w, r, &input)
 	if err != nil {
@@ -32,7 +35,7 @@ func (app *application) createStudySessionHandler(w http.ResponseWriter, r *http
 
 	err = Validate.Struct(input)
 	if err != nil {
-		app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		app.validationErrorResponse(w, r, err)
 		return
 	}
 
@@ -56,9 +59,22 @@ func (app *application) createStudySessionHandler(w http.ResponseWriter, r *http
 		CompletionDate: sql.NullTime{},
 	}
 
+	if input.LessonID != nil {
+		ss.LessonID = sql.NullInt64{Int64: *input.LessonID, Valid: true}
+		problems, err := app.validateSessionLesson(r, ss)
+		if err != nil {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+		if problems != nil {
+			app.failedValidationResponse(w, r, problems)
+			return
+		}
+	}
+
 	err = app.store.StudySessions.Insert(r.Context(), ss)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -75,13 +91,19 @@ func (app *application) listStudySessionsHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	sessions, err := app.store.StudySessions.GetAllForDailyPlan(r.Context(), dailyPlanID)
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	sessions, metadata, err := app.store.StudySessions.GetAllForDailyPlan(r.Context(), dailyPlanID, opts)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"study_sessions": sessions}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"study_sessions": sessions, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -100,6 +122,14 @@ func (app *application) getStudySessionHandler(w http.ResponseWriter, r *http.Re
 	}
 }
 
+type UpdateStudySessionRequest struct {
+	IsCompleted *bool   `json:"is_completed"`
+	BookID      *int64  `json:"book_id"`
+	LessonID    *int64  `json:"lesson_id"`
+	StartTime   *string `json:"start_time"`
+	EndTime     *string `json:"end_time"`
+}
+
 func (app *application) updateStudySessionHandler(w http.ResponseWriter, r *http.Request) {
 	sessionID, err := strconv.ParseInt(chi.URLParam(r, "sessionID"), 10, 64)
 	if err != nil || sessionID < 1 {
@@ -113,12 +143,7 @@ func (app *application) updateStudySessionHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	var input struct {
-		IsCompleted *bool   `json:"is_completed"`
-		BookID      *int64  `json:"book_id"`
-		StartTime   *string `json:"start_time"`
-		EndTime     *string `json:"end_time"`
-	}
+	var input UpdateStudySessionRequest
 
 	err = app.readJSON(w, r, &input)
 	if err != nil {
@@ -137,6 +162,24 @@ func (app *application) updateStudySessionHandler(w http.ResponseWriter, r *http
 	if input.BookID != nil {
 		session.BookID = *input.BookID
 	}
+	if input.LessonID != nil {
+		if *input.LessonID > 0 {
+			session.LessonID = sql.NullInt64{Int64: *input.LessonID, Valid: true}
+		} else {
+			session.LessonID = sql.NullInt64{}
+		}
+	}
+	if session.LessonID.Valid {
+		problems, err := app.validateSessionLesson(r, session)
+		if err != nil {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+		if problems != nil {
+			app.failedValidationResponse(w, r, problems)
+			return
+		}
+	}
 	if input.StartTime != nil {
 		parsedTime, err := time.Parse("15:04", *input.StartTime)
 		if err != nil {
@@ -156,7 +199,7 @@ func (app *application) updateStudySessionHandler(w http.ResponseWriter, r *http
 
 	err = app.store.StudySessions.Update(r.Context(), session)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -175,7 +218,7 @@ func (app *application) deleteStudySessionHandler(w http.ResponseWriter, r *http
 
 	err = app.store.StudySessions.Delete(r.Context(), sessionID)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -184,3 +227,19 @@ func (app *application) deleteStudySessionHandler(w http.ResponseWriter, r *http
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+// validateSessionLesson checks that the session's lesson exists and belongs
+// to the session's book. Problems are returned as field errors.
+func (app *application) validateSessionLesson(r *http.Request, ss *store.StudySession) (map[string]string, error) {
+	lesson, err := app.store.Lessons.Get(r.Context(), ss.LessonID.Int64)
+	if err != nil {
+		if errors.Is(err, store.ErrorNotFound) {
+			return map[string]string{"lesson_id": "lesson does not exist"}, nil
+		}
+		return nil, err
+	}
+	if lesson.BookID != ss.BookID {
+		return map[string]string{"lesson_id": "lesson does not belong to the selected book"}, nil
+	}
+	return nil, nil
+}
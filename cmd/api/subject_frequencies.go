@@ -7,6 +7,11 @@ import (
 	"github.com/Behehap/Alberta/internal/store"
 )
 
+type CreateSubjectFrequencyRequest struct {
+	BookID           int64 `json:"book_id" validate:"required,gt=0"`
+	FrequencyPerWeek int   `json:"frequency_per_week" validate:"required,gt=0"`
+}
+
 func (app *application) createSubjectFrequencyHandler(w http.ResponseWriter, r *http.Request) {
 
 	weeklyPlan, ok := r.Context().Value(weeklyPlanContextKey).(*store.WeeklyPlan)
@@ -15,10 +20,7 @@ func (app *application) createSubjectFrequencyHandler(w http.ResponseWriter, r *
 		return
 	}
 
-	var input struct {
-		BookID           int64 `json:"book_id" validate:"required,gt=0"`
-		FrequencyPerWeek int   `json:"frequency_per_week" validate:"required,gt=0"`
-	}
+	var input CreateSubjectFrequencyRequest
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -28,7 +30,7 @@ func (app *application) createSubjectFrequencyHandler(w http.ResponseWriter, r *
 
 	err = Validate.Struct(input)
 	if err != nil {
-		app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		app.validationErrorResponse(w, r, err)
 		return
 	}
 
@@ -40,7 +42,7 @@ func (app *application) createSubjectFrequencyHandler(w http.ResponseWriter, r *
 
 	err = app.store.SubjectFrequencies.Insert(r.Context(), sf)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -58,13 +60,19 @@ func (app *application) listSubjectFrequenciesHandler(w http.ResponseWriter, r *
 		return
 	}
 
-	frequencies, err := app.store.SubjectFrequencies.GetAllForWeeklyPlan(r.Context(), weeklyPlan.ID)
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	frequencies, metadata, err := app.store.SubjectFrequencies.GetAllForWeeklyPlan(r.Context(), weeklyPlan.ID, opts)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"subject_frequencies": frequencies}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"subject_frequencies": frequencies, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -34,7 +34,7 @@ func (app *application) createTemplateRuleHandler(w http.ResponseWriter, r *http
 
 	err = Validate.Struct(input)
 	if err != nil {
-		app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		app.validationErrorResponse(w, r, err)
 		return
 	}
 
@@ -59,7 +59,7 @@ func (app *application) createTemplateRuleHandler(w http.ResponseWriter, r *http
 
 	err = app.store.TemplateRules.Insert(r.Context(), tr)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -76,13 +76,19 @@ func (app *application) listTemplateRulesHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	rules, err := app.store.TemplateRules.GetAllForTemplate(r.Context(), templateID)
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	rules, metadata, err := app.store.TemplateRules.GetAllForTemplate(r.Context(), templateID, opts)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"template_rules": rules}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"template_rules": rules, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -156,13 +162,13 @@ func (app *application) updateTemplateRuleHandler(w http.ResponseWriter, r *http
 
 	err = Validate.Struct(rule)
 	if err != nil {
-		app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		app.validationErrorResponse(w, r, err)
 		return
 	}
 
 	err = app.store.TemplateRules.Update(r.Context(), rule)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -198,7 +204,7 @@ func (app *application) deleteTemplateRuleHandler(w http.ResponseWriter, r *http
 
 	err = app.store.TemplateRules.Delete(r.Context(), ruleID)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/calendar"
+	"github.com/Behehap/Alberta/internal/ical"
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+const defaultTimetableTitle = "School"
+
+type UnavailableImportReport struct {
+	DryRun           bool                      `json:"dry_run"`
+	UnavailableTimes []*UnavailableTimeDisplay `json:"unavailable_times"`
+	Skipped          int                       `json:"skipped"`
+	Warnings         []string                  `json:"warnings,omitempty"`
+}
+
+// timetableBlock is one imported block of unavailability. Blocks without a
+// date recur every week on day, which is counted from Saturday like
+// UnavailableTime.DayOfWeek.
+type timetableBlock struct {
+	day    int
+	date   time.Time
+	start  time.Time
+	end    time.Time
+	titles []string
+}
+
+func (b *timetableBlock) recurring() bool {
+	return b.date.IsZero()
+}
+
+func (app *application) importUnavailableTimesHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	report := &UnavailableImportReport{DryRun: r.URL.Query().Get("dry_run") == "true"}
+
+	maxBytes := 1_048_576
+	body := http.MaxBytesReader(w, r.Body, int64(maxBytes))
+
+	var blocks []*timetableBlock
+	var err error
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/calendar":
+		blocks, err = readTimetableICS(body, student.Location(), report)
+	case "text/csv":
+		blocks, err = app.readTimetableCSV(r, body)
+	default:
+		app.errorResponse(w, r, http.StatusUnsupportedMediaType, "the timetable must be sent as text/calendar or text/csv")
+		return
+	}
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	existing, _, err := app.store.UnavailableTimes.GetAllForStudent(r.Context(), student.ID, store.QueryOptions{})
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	var times []*store.UnavailableTime
+	for _, block := range mergeTimetableBlocks(blocks) {
+		if coveredByExisting(block, existing) {
+			report.Skipped++
+			continue
+		}
+
+		ut := &store.UnavailableTime{
+			StudentID:   student.ID,
+			Title:       joinTimetableTitles(block.titles),
+			DayOfWeek:   block.day,
+			StartTime:   block.start,
+			EndTime:     block.end,
+			IsRecurring: block.recurring(),
+		}
+		if !block.recurring() {
+			ut.SpecificDate = sql.NullTime{Time: block.date, Valid: true}
+		}
+		times = append(times, ut)
+	}
+
+	status := http.StatusOK
+	if !report.DryRun && len(times) > 0 {
+		err = app.store.UnavailableTimes.InsertBatch(r.Context(), times)
+		if err != nil {
+			app.storeErrorResponse(w, r, err)
+			return
+		}
+		status = http.StatusCreated
+	}
+
+	report.UnavailableTimes = make([]*UnavailableTimeDisplay, len(times))
+	for i, ut := range times {
+		report.UnavailableTimes[i] = mapUnavailableTimeToDisplay(ut)
+	}
+
+	err = app.writeJSON(w, status, envelope{"import": report}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readTimetableICS turns the events of an iCalendar file into blocks. Weekly
+// and daily events become recurring blocks and single events date-specific
+// ones. Events that cannot be represented are left out with a warning.
+func readTimetableICS(body io.Reader, loc *time.Location, report *UnavailableImportReport) ([]*timetableBlock, error) {
+	events, err := ical.Parse(body, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var blocks []*timetableBlock
+	for _, event := range events {
+		title := strings.TrimSpace(event.Summary)
+		if title == "" {
+			title = defaultTimetableTitle
+		}
+
+		// All-day dates are midnight UTC and already the dates to block
+		start, end := event.Start, event.End
+		if !event.AllDay {
+			start, end = start.In(loc), end.In(loc)
+		}
+		startClock := clockTime(start.Hour(), start.Minute(), start.Second())
+		endClock := clockTime(end.Hour(), end.Minute(), end.Second())
+		days := 1
+
+		switch {
+		case event.AllDay:
+			startClock = clockTime(0, 0, 0)
+			endClock = clockTime(23, 59, 59)
+			days = max(1, int(event.End.Sub(event.Start).Hours()/24))
+		case !sameDay(start, end):
+			// Unavailable times cannot cross midnight
+			endClock = clockTime(23, 59, 59)
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%q runs past midnight and was cut at the end of the day", title))
+		}
+
+		if !endClock.After(startClock) {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%q has no duration and was skipped", title))
+			continue
+		}
+
+		if event.RRule != "" {
+			recurrence, err := ical.ParseRRule(event.RRule, start)
+			if err != nil {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%q was skipped: %v", title, err))
+				continue
+			}
+			if recurrence.Frequency != "WEEKLY" && recurrence.Frequency != "DAILY" {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%q repeats %s and was skipped; only weekly and daily events are imported", title, strings.ToLower(recurrence.Frequency)))
+				continue
+			}
+			// Recurring unavailable times apply every week until deleted
+			if recurrence.Interval > 1 {
+				unit := "weeks"
+				if recurrence.Frequency == "DAILY" {
+					unit = "days"
+				}
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%q repeats every %d %s and was skipped; only events that repeat every day or week are imported", title, recurrence.Interval, unit))
+				continue
+			}
+			if !recurrence.Until.IsZero() {
+				until := recurrence.Until.In(loc).Format("2006-01-02")
+				if recurrence.Until.Before(now) {
+					report.Warnings = append(report.Warnings, fmt.Sprintf("%q stopped repeating on %s and was skipped", title, until))
+					continue
+				}
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%q stops repeating on %s but is imported as unavailable every week", title, until))
+			}
+			// Recurring unavailable times have no exceptions
+			upcoming := 0
+			for _, exdate := range event.ExDates {
+				if !exdate.Before(today) {
+					upcoming++
+				}
+			}
+			if upcoming > 0 {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%q skips %d upcoming occurrences that are still imported as unavailable", title, upcoming))
+			}
+			for _, weekday := range recurrence.Days {
+				blocks = append(blocks, &timetableBlock{
+					day:    schedulerDayOfWeek(weekday),
+					start:  startClock,
+					end:    endClock,
+					titles: []string{title},
+				})
+			}
+			continue
+		}
+
+		if excluded(event) {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%q is excluded by its own EXDATE and was skipped", title))
+			continue
+		}
+
+		for i := 0; i < days; i++ {
+			date := time.Date(start.Year(), start.Month(), start.Day()+i, 0, 0, 0, 0, time.UTC)
+			if date.Before(today) {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%q on %s is in the past and was skipped", title, date.Format("2006-01-02")))
+				continue
+			}
+			blocks = append(blocks, &timetableBlock{
+				day:    schedulerDayOfWeek(date.Weekday()),
+				date:   date,
+				start:  startClock,
+				end:    endClock,
+				titles: []string{title},
+			})
+		}
+	}
+
+	return blocks, nil
+}
+
+// excluded reports whether an event without recurrence lists its own start as
+// an EXDATE, which leaves it with no occurrence.
+func excluded(event *ical.Event) bool {
+	for _, exdate := range event.ExDates {
+		if exdate.Equal(event.Start) {
+			return true
+		}
+	}
+	return false
+}
+
+// readTimetableCSV reads a timetable with the header day,start_time,end_time,title.
+// The day is 0-6 counted from Saturday, a weekday name in English or Persian
+// for a weekly block, or a date for a single one. The title column is optional.
+func (app *application) readTimetableCSV(r *http.Request, body io.Reader) ([]*timetableBlock, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("body must not be empty")
+		}
+		return nil, fmt.Errorf("body contains badly-formed CSV: %v", err)
+	}
+	expected := []string{"day", "start_time", "end_time", "title"}
+	if len(header) < 3 || len(header) > len(expected) {
+		return nil, fmt.Errorf("CSV header must be %s", strings.Join(expected, ","))
+	}
+	for i, column := range header {
+		if strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")) != expected[i] {
+			return nil, fmt.Errorf("CSV header must be %s", strings.Join(expected, ","))
+		}
+	}
+
+	var blocks []*timetableBlock
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("body contains badly-formed CSV: %v", err)
+		}
+		if len(record) != len(header) {
+			return nil, fmt.Errorf("line %d: expected %d fields", line, len(header))
+		}
+
+		block := &timetableBlock{titles: []string{defaultTimetableTitle}}
+		if len(record) > 3 && strings.TrimSpace(record[3]) != "" {
+			block.titles = []string{strings.TrimSpace(record[3])}
+		}
+
+		day := strings.TrimSpace(record[0])
+		if n, err := strconv.Atoi(day); err == nil {
+			if n < 0 || n > 6 {
+				return nil, fmt.Errorf("line %d: day must be between 0 and 6", line)
+			}
+			block.day = n
+		} else if weekday, ok := parseWeekdayName(day); ok {
+			block.day = schedulerDayOfWeek(weekday)
+		} else {
+			date, err := app.parseDate(r, day)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: day must be 0-6, a weekday name or a date", line)
+			}
+			block.date = date
+			block.day = schedulerDayOfWeek(date.Weekday())
+		}
+
+		for i, dst := range []*time.Time{&block.start, &block.end} {
+			*dst, err = time.Parse("15:04", strings.TrimSpace(record[1+i]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s format, please use HH:MM", line, expected[1+i])
+			}
+		}
+		if !block.end.After(block.start) {
+			return nil, fmt.Errorf("line %d: end_time must be after start_time", line)
+		}
+
+		blocks = append(blocks, block)
+	}
+
+	return blocks, nil
+}
+
+// mergeTimetableBlocks merges overlapping and touching blocks that fall on
+// the same weekly day or the same date, keeping every title.
+func mergeTimetableBlocks(blocks []*timetableBlock) []*timetableBlock {
+	sort.SliceStable(blocks, func(i, j int) bool {
+		a, b := blocks[i], blocks[j]
+		if a.recurring() != b.recurring() {
+			return a.recurring()
+		}
+		if !a.date.Equal(b.date) {
+			return a.date.Before(b.date)
+		}
+		if a.day != b.day {
+			return a.day < b.day
+		}
+		return a.start.Before(b.start)
+	})
+
+	var merged []*timetableBlock
+	for _, block := range blocks {
+		last := len(merged) - 1
+		if last >= 0 && merged[last].day == block.day && merged[last].date.Equal(block.date) && !block.start.After(merged[last].end) {
+			if block.end.After(merged[last].end) {
+				merged[last].end = block.end
+			}
+			for _, title := range block.titles {
+				if !containsString(merged[last].titles, title) {
+					merged[last].titles = append(merged[last].titles, title)
+				}
+			}
+			continue
+		}
+		merged = append(merged, block)
+	}
+
+	return merged
+}
+
+// coveredByExisting reports whether an existing entry of the same kind
+// already spans the whole block, so that importing twice adds nothing.
+func coveredByExisting(block *timetableBlock, existing []*store.UnavailableTime) bool {
+	for _, ut := range existing {
+		if ut.DayOfWeek != block.day || ut.SpecificDate.Valid == block.recurring() {
+			continue
+		}
+		if ut.SpecificDate.Valid && !ut.SpecificDate.Time.Equal(block.date) {
+			continue
+		}
+		if block.recurring() && !ut.IsRecurring {
+			continue
+		}
+		if !ut.StartTime.After(block.start) && !ut.EndTime.Before(block.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinTimetableTitles joins the titles of a merged block, cut to fit the
+// title column.
+func joinTimetableTitles(titles []string) string {
+	title := []rune(strings.Join(titles, " / "))
+	if len(title) > 255 {
+		title = title[:255]
+	}
+	return string(title)
+}
+
+func parseWeekdayName(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(name, calendar.Gregorian.WeekdayName(day)) || name == calendar.Jalali.WeekdayName(day) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func clockTime(hour, min, sec int) time.Time {
+	return time.Date(0, 1, 1, hour, min, sec, 0, time.UTC)
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
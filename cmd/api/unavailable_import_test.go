@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadTimetableICS(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		// an all-day event stays on its date west of UTC
+		"BEGIN:VEVENT",
+		"SUMMARY:Field trip",
+		"DTSTART;VALUE=DATE:20301019",
+		"END:VEVENT",
+		// a weekly all-day event repeats on the weekday of its date
+		"BEGIN:VEVENT",
+		"SUMMARY:Sports day",
+		"DTSTART;VALUE=DATE:20301021",
+		"RRULE:FREQ=WEEKLY",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Math",
+		"DTSTART;TZID=America/Los_Angeles:20301022T080000",
+		"DTEND;TZID=America/Los_Angeles:20301022T093000",
+		"RRULE:FREQ=WEEKLY",
+		"EXDATE;TZID=America/Los_Angeles:20301029T080000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Lab",
+		"DTSTART;TZID=America/Los_Angeles:20301023T100000",
+		"DTEND;TZID=America/Los_Angeles:20301023T120000",
+		"RRULE:FREQ=WEEKLY;INTERVAL=2",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Term",
+		"DTSTART;TZID=America/Los_Angeles:20301024T100000",
+		"DTEND;TZID=America/Los_Angeles:20301024T110000",
+		"RRULE:FREQ=WEEKLY;COUNT=3",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Old",
+		"DTSTART:20201020T100000Z",
+		"DTEND:20201020T110000Z",
+		"RRULE:FREQ=DAILY;COUNT=2",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Cancelled",
+		"DTSTART:20301023T150000Z",
+		"DTEND:20301023T160000Z",
+		"EXDATE:20301023T150000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	report := &UnavailableImportReport{}
+	blocks, err := readTimetableICS(strings.NewReader(doc), losAngeles, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 4 {
+		t.Fatalf("got %d blocks, want 4", len(blocks))
+	}
+
+	trip := blocks[0]
+	if want := time.Date(2030, time.October, 19, 0, 0, 0, 0, time.UTC); !trip.date.Equal(want) {
+		t.Errorf("all-day event on %s, want %s", trip.date.Format("2006-01-02"), want.Format("2006-01-02"))
+	}
+	if trip.day != schedulerDayOfWeek(time.Saturday) || !trip.start.Equal(clockTime(0, 0, 0)) || !trip.end.Equal(clockTime(23, 59, 59)) {
+		t.Errorf("all-day event on day %d from %s to %s", trip.day, trip.start.Format("15:04"), trip.end.Format("15:04"))
+	}
+
+	// 2030-10-21 is a Monday
+	if sports := blocks[1]; !sports.recurring() || sports.day != schedulerDayOfWeek(time.Monday) {
+		t.Errorf("weekly all-day event on day %d, recurring %v; want Monday", sports.day, sports.recurring())
+	}
+
+	math := blocks[2]
+	if !math.recurring() || math.day != schedulerDayOfWeek(time.Tuesday) || !math.start.Equal(clockTime(8, 0, 0)) {
+		t.Errorf("weekly event on day %d at %s", math.day, math.start.Format("15:04"))
+	}
+
+	// 2030-10-24 is a Thursday
+	if term := blocks[3]; !term.recurring() || term.day != schedulerDayOfWeek(time.Thursday) {
+		t.Errorf("counted weekly event on day %d, recurring %v; want Thursday", term.day, term.recurring())
+	}
+
+	want := []string{
+		`"Math" skips 1 upcoming occurrences that are still imported as unavailable`,
+		`"Lab" repeats every 2 weeks and was skipped; only events that repeat every day or week are imported`,
+		`"Term" stops repeating on 2030-11-07 but is imported as unavailable every week`,
+		`"Old" stopped repeating on 2020-10-21 and was skipped`,
+		`"Cancelled" is excluded by its own EXDATE and was skipped`,
+	}
+	if strings.Join(report.Warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got warnings %q, want %q", report.Warnings, want)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"database/sql"
 	"errors"
 	"net/http"
 	"strconv"
@@ -11,13 +12,14 @@ import (
 )
 
 type UnavailableTimeDisplay struct {
-	ID          int64  `json:"id"`
-	StudentID   int64  `json:"student_id"`
-	Title       string `json:"title"`
-	DayOfWeek   int    `json:"day_of_week"`
-	StartTime   string `json:"start_time"`
-	EndTime     string `json:"end_time"`
-	IsRecurring bool   `json:"is_recurring"`
+	ID           int64      `json:"id"`
+	StudentID    int64      `json:"student_id"`
+	Title        string     `json:"title"`
+	DayOfWeek    int        `json:"day_of_week"`
+	StartTime    string     `json:"start_time"`
+	EndTime      string     `json:"end_time"`
+	IsRecurring  bool       `json:"is_recurring"`
+	SpecificDate *time.Time `json:"specific_date,omitempty"`
 }
 
 func mapUnavailableTimeToDisplay(ut *store.UnavailableTime) *UnavailableTimeDisplay {
@@ -30,10 +32,22 @@ func mapUnavailableTimeToDisplay(ut *store.UnavailableTime) *UnavailableTimeDisp
 		EndTime:     ut.EndTime.Format("15:04:05"),
 		IsRecurring: ut.IsRecurring,
 	}
+	if ut.SpecificDate.Valid {
+		displayUt.SpecificDate = &ut.SpecificDate.Time
+	}
 
 	return displayUt
 }
 
+type CreateUnavailableTimeRequest struct {
+	Title        string `json:"title" validate:"required"`
+	DayOfWeek    int    `json:"day_of_week" validate:"gte=0,lte=6"`
+	StartTime    string `json:"start_time" validate:"required"`
+	EndTime      string `json:"end_time" validate:"required"`
+	IsRecurring  bool   `json:"is_recurring"`
+	SpecificDate string `json:"specific_date"`
+}
+
 func (app *application) createUnavailableTimeHandler(w http.ResponseWriter, r *http.Request) {
 	student, ok := r.Context().Value(studentContextKey).(*store.Student)
 	if !ok {
@@ -41,13 +55,7 @@ func (app *application) createUnavailableTimeHandler(w http.ResponseWriter, r *h
 		return
 	}
 
-	var input struct {
-		Title       string `json:"title" validate:"required"`
-		DayOfWeek   int    `json:"day_of_week" validate:"gte=0,lte=6"`
-		StartTime   string `json:"start_time" validate:"required"`
-		EndTime     string `json:"end_time" validate:"required"`
-		IsRecurring bool   `json:"is_recurring"`
-	}
+	var input CreateUnavailableTimeRequest
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -57,7 +65,7 @@ func (app *application) createUnavailableTimeHandler(w http.ResponseWriter, r *h
 
 	err = Validate.Struct(input)
 	if err != nil {
-		app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		app.validationErrorResponse(w, r, err)
 		return
 	}
 
@@ -87,9 +95,21 @@ func (app *application) createUnavailableTimeHandler(w http.ResponseWriter, r *h
 		IsRecurring: input.IsRecurring,
 	}
 
+	// A date-specific entry never recurs and falls on its date's weekday
+	if input.SpecificDate != "" {
+		date, err := app.parseDate(r, input.SpecificDate)
+		if err != nil {
+			app.badRequestResponse(w, r, errors.New("invalid date format for specific_date, please use YYYY-MM-DD"))
+			return
+		}
+		ut.SpecificDate = sql.NullTime{Time: date, Valid: true}
+		ut.DayOfWeek = schedulerDayOfWeek(date.Weekday())
+		ut.IsRecurring = false
+	}
+
 	err = app.store.UnavailableTimes.Insert(r.Context(), ut)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -106,9 +126,15 @@ func (app *application) listUnavailableTimesHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
-	times, err := app.store.UnavailableTimes.GetAllForStudent(r.Context(), student.ID)
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	times, metadata, err := app.store.UnavailableTimes.GetAllForStudent(r.Context(), student.ID, opts)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -117,7 +143,7 @@ func (app *application) listUnavailableTimesHandler(w http.ResponseWriter, r *ht
 		displayTimes[i] = mapUnavailableTimeToDisplay(t)
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"unavailable_times": displayTimes}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"unavailable_times": displayTimes, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -138,11 +164,7 @@ func (app *application) updateUnavailableTimeHandler(w http.ResponseWriter, r *h
 
 	ut, err := app.store.UnavailableTimes.Get(r.Context(), utID)
 	if err != nil {
-		if errors.Is(err, store.ErrorNotFound) {
-			app.notFoundResponse(w, r)
-			return
-		}
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -201,7 +223,7 @@ func (app *application) updateUnavailableTimeHandler(w http.ResponseWriter, r *h
 
 	err = app.store.UnavailableTimes.Update(r.Context(), ut)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -226,11 +248,7 @@ func (app *application) deleteUnavailableTimeHandler(w http.ResponseWriter, r *h
 
 	ut, err := app.store.UnavailableTimes.Get(r.Context(), utID)
 	if err != nil {
-		if errors.Is(err, store.ErrorNotFound) {
-			app.notFoundResponse(w, r)
-			return
-		}
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -241,9 +259,15 @@ func (app *application) deleteUnavailableTimeHandler(w http.ResponseWriter, r *h
 
 	err = app.store.UnavailableTimes.Delete(r.Context(), utID)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
 	app.writeJSON(w, http.StatusOK, envelope{"message": "unavailable time deleted successfully"}, nil)
 }
+
+// schedulerDayOfWeek converts a weekday to the Saturday-based day_of_week
+// stored on unavailable times.
+func schedulerDayOfWeek(day time.Weekday) int {
+	return (int(day) + 1) % 7
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/fa"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	faTranslations "github.com/go-playground/validator/v10/translations/fa"
+)
+
+var (
+	Validate    *validator.Validate
+	translators *ut.UniversalTranslator
+)
+
+// Field error codes are stable values clients can switch on, unlike the
+// messages, which are translated and may be reworded.
+const (
+	codeFailedValidation = "failed_validation"
+
+	fieldCodeRequired        = "required"
+	fieldCodeNotAllowed      = "not_allowed"
+	fieldCodeTooSmall        = "too_small"
+	fieldCodeTooLarge        = "too_large"
+	fieldCodeTooShort        = "too_short"
+	fieldCodeTooLong         = "too_long"
+	fieldCodeInvalidEmail    = "invalid_email"
+	fieldCodeInvalidTimezone = "invalid_timezone"
+	fieldCodeInvalidChoice   = "invalid_choice"
+	fieldCodeInvalid         = "invalid"
+)
+
+// extraTranslations cover the tags the validator has no translation for, or
+// whose translation names a Go field.
+var extraTranslations = map[string]map[string]string{
+	"en": {
+		"required_with":    "{0} is a required field",
+		"required_without": "{0} is a required field",
+		"excluded_with":    "{0} must not be given together with the other fields",
+		"excluded_without": "{0} can only be given together with the other fields",
+		"timezone":         "{0} must be an IANA time zone such as Asia/Tehran",
+		"gtefield":         "{0} must be greater than or equal to {1}",
+	},
+	"fa": {
+		"required_with":    "فیلد {0} اجباری میباشد",
+		"required_without": "فیلد {0} اجباری میباشد",
+		"excluded_with":    "فیلد {0} نباید همراه با فیلدهای دیگر ارسال شود",
+		"excluded_without": "فیلد {0} فقط همراه با فیلدهای دیگر قابل ارسال است",
+		"timezone":         "فیلد {0} باید یک منطقه زمانی IANA مانند Asia/Tehran باشد",
+		"gtefield":         "{0} باید بزرگتر یا مساوی {1} باشد",
+	},
+}
+
+func init() {
+	Validate = validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON names
+	Validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	if err := Validate.RegisterValidation("timezone", validTimezone); err != nil {
+		panic(err)
+	}
+
+	translators = ut.New(en.New(), en.New(), fa.New())
+	english, _ := translators.GetTranslator("en")
+	persian, _ := translators.GetTranslator("fa")
+	if err := enTranslations.RegisterDefaultTranslations(Validate, english); err != nil {
+		panic(err)
+	}
+	if err := faTranslations.RegisterDefaultTranslations(Validate, persian); err != nil {
+		panic(err)
+	}
+
+	for locale, messages := range extraTranslations {
+		trans, _ := translators.GetTranslator(locale)
+		for tag, message := range messages {
+			err := Validate.RegisterTranslation(tag, trans,
+				func(trans ut.Translator) error {
+					return trans.Add(tag, message, true)
+				},
+				func(trans ut.Translator, fe validator.FieldError) string {
+					// Field parameters such as gtefield's are Go field names
+					t, err := trans.T(fe.Tag(), fe.Field(), snakeCase(fe.Param()))
+					if err != nil {
+						return fe.Error()
+					}
+					return t
+				})
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// validTimezone accepts the name of an IANA time zone. time.LoadLocation also
+// accepts "" for UTC and "Local" for the server's own zone, which are not
+// zones a student lives in.
+func validTimezone(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	if name == "" || strings.EqualFold(name, "Local") {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// translatorFor returns the translator of the first language in the
+// Accept-Language header that there are messages in, English by default.
+func translatorFor(r *http.Request) ut.Translator {
+	for _, language := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(language), ";")
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if trans, found := translators.FindTranslator(primary); found && primary != "" {
+			return trans
+		}
+	}
+	trans, _ := translators.GetTranslator("en")
+	return trans
+}
+
+// fieldErrors translates validator errors into messages and codes keyed by
+// the JSON path of each field, such as results[0].book_id.
+func fieldErrors(errs validator.ValidationErrors, trans ut.Translator) (messages, codes map[string]string) {
+	messages = make(map[string]string, len(errs))
+	codes = make(map[string]string, len(errs))
+	for _, fe := range errs {
+		field := fe.Namespace()
+		if _, path, ok := strings.Cut(field, "."); ok {
+			field = path
+		}
+		if _, exists := messages[field]; exists {
+			continue
+		}
+		messages[field] = fe.Translate(trans)
+		codes[field] = fieldCode(fe)
+	}
+	return messages, codes
+}
+
+// fieldCode returns the code of the rule a field failed.
+func fieldCode(fe validator.FieldError) string {
+	sized := false
+	switch fe.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		sized = true
+	}
+
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_with_all", "required_without", "required_without_all":
+		return fieldCodeRequired
+	case "excluded_with", "excluded_with_all", "excluded_without", "excluded_without_all", "excluded_if", "excluded_unless":
+		return fieldCodeNotAllowed
+	case "gt", "gte", "min", "gtfield", "gtefield":
+		if sized {
+			return fieldCodeTooShort
+		}
+		return fieldCodeTooSmall
+	case "lt", "lte", "max", "ltfield", "ltefield":
+		if sized {
+			return fieldCodeTooLong
+		}
+		return fieldCodeTooLarge
+	case "email":
+		return fieldCodeInvalidEmail
+	case "timezone":
+		return fieldCodeInvalidTimezone
+	case "oneof":
+		return fieldCodeInvalidChoice
+	default:
+		return fieldCodeInvalid
+	}
+}
+
+// snakeCase turns a Go field name such as FromLessonNumber into its JSON name.
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// validationErrorResponse reports the errors of Validate.Struct field by
+// field, in the language the client accepts.
+func (app *application) validationErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	trans := translatorFor(r)
+	messages, codes := fieldErrors(errs, trans)
+	w.Header().Set("Content-Language", trans.Locale())
+	app.fieldErrorResponse(w, r, messages, codes)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidationErrorResponse(t *testing.T) {
+	input := RecordExamResultsRequest{
+		Results: []ExamResultInput{{BookID: 0, Percentage: 120}},
+	}
+	err := Validate.Struct(input)
+	if err == nil {
+		t.Fatal("expected the input to fail validation")
+	}
+
+	tests := []struct {
+		language string
+		locale   string
+		// contains is part of the message for results[0].book_id
+		contains string
+	}{
+		{"", "en", "is a required field"},
+		{"en-US,en;q=0.9", "en", "is a required field"},
+		{"fa-IR, en;q=0.8", "fa", "اجباری"},
+		{"de, fa;q=0.5", "fa", "اجباری"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			app := &application{}
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.language != "" {
+				r.Header.Set("Accept-Language", tt.language)
+			}
+			rr := httptest.NewRecorder()
+			app.validationErrorResponse(rr, r, err)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status %d, want %d", rr.Code, http.StatusUnprocessableEntity)
+			}
+			if got := rr.Header().Get("Content-Language"); got != tt.locale {
+				t.Errorf("Content-Language %q, want %q", got, tt.locale)
+			}
+
+			var body struct {
+				Error      map[string]string `json:"error"`
+				Code       string            `json:"code"`
+				FieldCodes map[string]string `json:"field_codes"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+
+			if body.Code != codeFailedValidation {
+				t.Errorf("code %q, want %q", body.Code, codeFailedValidation)
+			}
+			wantCodes := map[string]string{
+				"results[0].book_id":    fieldCodeRequired,
+				"results[0].percentage": fieldCodeTooLarge,
+			}
+			for field, code := range wantCodes {
+				if body.FieldCodes[field] != code {
+					t.Errorf("field code of %s is %q, want %q", field, body.FieldCodes[field], code)
+				}
+			}
+			if len(body.Error) != len(wantCodes) {
+				t.Errorf("errors %v, want one for each of %v", body.Error, wantCodes)
+			}
+			if msg := body.Error["results[0].book_id"]; !strings.Contains(msg, tt.contains) || !strings.Contains(msg, "book_id") {
+				t.Errorf("message %q should contain %q and the JSON field name", msg, tt.contains)
+			}
+		})
+	}
+}
+
+func TestFieldParametersUseJSONNames(t *testing.T) {
+	input := CreateExamScopeItemRequest{BookID: 1, FromLessonNumber: 5, ToLessonNumber: 3}
+	errs := Validate.Struct(input)
+	if errs == nil {
+		t.Fatal("expected the input to fail validation")
+	}
+
+	app := &application{}
+	rr := httptest.NewRecorder()
+	app.validationErrorResponse(rr, httptest.NewRequest(http.MethodPost, "/", nil), errs)
+
+	var body struct {
+		Error      map[string]string `json:"error"`
+		FieldCodes map[string]string `json:"field_codes"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "to_lesson_number must be greater than or equal to from_lesson_number"
+	if got := body.Error["to_lesson_number"]; got != want {
+		t.Errorf("message %q, want %q", got, want)
+	}
+	if got := body.FieldCodes["to_lesson_number"]; got != fieldCodeTooSmall {
+		t.Errorf("field code %q, want %q", got, fieldCodeTooSmall)
+	}
+}
+
+func TestTimezoneValidation(t *testing.T) {
+	tests := []struct {
+		timezone string
+		valid    bool
+	}{
+		{"Asia/Tehran", true},
+		{"UTC", true},
+		{"America/Los_Angeles", true},
+		{"", false},
+		{"Local", false},
+		{"local", false},
+		{"Asia/Atlantis", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			timezone := tt.timezone
+			err := Validate.Struct(UpdateStudentRequest{Timezone: &timezone})
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("valid %v, want %v (%v)", valid, tt.valid, err)
+			}
+		})
+	}
+}
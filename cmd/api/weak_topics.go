@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+var defaultWeakTopicParams = store.WeakTopicParams{
+	RecentSessions: 5,
+	MaxWrongRatio:  0.4,
+	MinTests:       5,
+	MinScore:       50,
+}
+
+func readWeakTopicParams(r *http.Request) (store.WeakTopicParams, map[string]string) {
+	params := defaultWeakTopicParams
+	qs := r.URL.Query()
+	problems := make(map[string]string)
+
+	var err error
+	params.RecentSessions, err = readIntParam(qs, "recent_sessions", params.RecentSessions)
+	if err != nil {
+		problems["recent_sessions"] = err.Error()
+	} else if params.RecentSessions < 1 || params.RecentSessions > 50 {
+		problems["recent_sessions"] = "must be between 1 and 50"
+	}
+
+	params.MaxWrongRatio, err = readFloatParam(qs, "max_wrong_ratio", params.MaxWrongRatio)
+	if err != nil {
+		problems["max_wrong_ratio"] = err.Error()
+	} else if params.MaxWrongRatio < 0 || params.MaxWrongRatio > 1 {
+		problems["max_wrong_ratio"] = "must be between 0 and 1"
+	}
+
+	params.MinTests, err = readIntParam(qs, "min_tests", params.MinTests)
+	if err != nil {
+		problems["min_tests"] = err.Error()
+	} else if params.MinTests < 0 {
+		problems["min_tests"] = "must not be negative"
+	}
+
+	params.MinScore, err = readFloatParam(qs, "min_score", params.MinScore)
+	if err != nil {
+		problems["min_score"] = err.Error()
+	} else if params.MinScore < 0 {
+		problems["min_score"] = "must not be negative"
+	}
+
+	if len(problems) > 0 {
+		return params, problems
+	}
+	return params, nil
+}
+
+func (app *application) listWeakTopicsHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	params, problems := readWeakTopicParams(r)
+	if problems != nil {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	topics, err := app.store.Analytics.GetWeakTopics(r.Context(), student.ID, params)
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"weak_topics": topics}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
 	"sort"
 	"strconv"
 	"time"
 
+	"github.com/Behehap/Alberta/internal/scheduler"
 	"github.com/Behehap/Alberta/internal/store"
 	"github.com/go-chi/chi/v5"
 )
@@ -23,6 +26,37 @@ type WeeklyPlanDisplay struct {
 	StartDateOfWeek          time.Time `json:"start_date_of_week"`
 	DayStartTime             string    `json:"day_start_time,omitempty"`
 	MaxStudyTimeHoursPerWeek int       `json:"max_study_time_hours_per_week,omitempty"`
+	GenerationSeed           *int64    `json:"generation_seed,omitempty"`
+	WeeklyPlanLimits
+}
+
+// WeeklyPlanLimits are the optional daily limits of a weekly plan, as read
+// from requests and written in responses.
+type WeeklyPlanLimits struct {
+	MaxBlocksPerDay            *int64 `json:"max_blocks_per_day,omitempty" validate:"omitempty,gt=0,lte=20"`
+	MaxBookBlocksPerDay        *int64 `json:"max_book_blocks_per_day,omitempty" validate:"omitempty,gt=0,lte=20"`
+	MinDaysBetweenBookSessions *int64 `json:"min_days_between_book_sessions,omitempty" validate:"omitempty,gte=0,lte=5"`
+}
+
+func nullInt64(v *int64) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *v, Valid: true}
+}
+
+func int64Ptr(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
+
+// apply sets the limits on wp.
+func (l WeeklyPlanLimits) apply(wp *store.WeeklyPlan) {
+	wp.MaxBlocksPerDay = nullInt64(l.MaxBlocksPerDay)
+	wp.MaxBookBlocksPerDay = nullInt64(l.MaxBookBlocksPerDay)
+	wp.MinDaysBetweenBookSessions = nullInt64(l.MinDaysBetweenBookSessions)
 }
 
 type DailyCalendarEntry struct {
@@ -34,6 +68,7 @@ type StudySessionDetail struct {
 	ID             int64       `json:"id"`
 	DailyPlanID    int64       `json:"daily_plan_id"`
 	Book           *store.Book `json:"book"`
+	LessonID       *int64      `json:"lesson_id,omitempty"`
 	IsCompleted    bool        `json:"is_completed"`
 	CompletionDate *time.Time  `json:"completion_date,omitempty"`
 	StartTime      string      `json:"start_time"`
@@ -46,10 +81,16 @@ func mapStudySessionToDetail(ss *store.StudySession, book *store.Book) StudySess
 		completionDate = &ss.CompletionDate.Time
 	}
 
+	var lessonID *int64
+	if ss.LessonID.Valid {
+		lessonID = &ss.LessonID.Int64
+	}
+
 	return StudySessionDetail{
 		ID:             ss.ID,
 		DailyPlanID:    ss.DailyPlanID,
 		Book:           book,
+		LessonID:       lessonID,
 		IsCompleted:    ss.IsCompleted,
 		CompletionDate: completionDate,
 		StartTime:      ss.StartTime,
@@ -67,9 +108,24 @@ func mapWeeklyPlanToDisplay(wp *store.WeeklyPlan) *WeeklyPlanDisplay {
 	if wp.DayStartTime.Valid {
 		displayWp.DayStartTime = wp.DayStartTime.Time.Format("15:04:05")
 	}
+	if wp.GenerationSeed.Valid {
+		displayWp.GenerationSeed = &wp.GenerationSeed.Int64
+	}
+	displayWp.WeeklyPlanLimits = WeeklyPlanLimits{
+		MaxBlocksPerDay:            int64Ptr(wp.MaxBlocksPerDay),
+		MaxBookBlocksPerDay:        int64Ptr(wp.MaxBookBlocksPerDay),
+		MinDaysBetweenBookSessions: int64Ptr(wp.MinDaysBetweenBookSessions),
+	}
 	return displayWp
 }
 
+type CreateWeeklyPlanRequest struct {
+	StartDateOfWeek string `json:"start_date_of_week" validate:"required"`
+	DayStartTime    string `json:"day_start_time"`
+	DailyStudyHours int    `json:"daily_study_hours" validate:"required,gt=0"`
+	WeeklyPlanLimits
+}
+
 func (app *application) createWeeklyPlanHandler(w http.ResponseWriter, r *http.Request) {
 	student, ok := r.Context().Value(studentContextKey).(*store.Student)
 	if !ok {
@@ -77,11 +133,7 @@ func (app *application) createWeeklyPlanHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	var input struct {
-		StartDateOfWeek string `json:"start_date_of_week" validate:"required"`
-		DayStartTime    string `json:"day_start_time"`
-		DailyStudyHours int    `json:"daily_study_hours" validate:"required,gt=0"`
-	}
+	var input CreateWeeklyPlanRequest
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
@@ -89,46 +141,181 @@ func (app *application) createWeeklyPlanHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	startDate, err := time.Parse("2006-01-02", input.StartDateOfWeek)
+	err = Validate.Struct(input)
+	if err != nil {
+		app.validationErrorResponse(w, r, err)
+		return
+	}
+
+	startDate, err := app.parseDate(r, input.StartDateOfWeek)
 	if err != nil {
 		app.badRequestResponse(w, r, errors.New("invalid date format for start_date_of_week, please use YYYY-MM-DD"))
 		return
 	}
 
-	var dayStartTime sql.NullTime
-	if input.DayStartTime != "" {
-		parsedTime, err := time.Parse("15:04", input.DayStartTime)
+	// Any date is accepted and anchored to the start of the week containing it
+	startDate = scheduler.WeekStart(startDate, app.config.weekStart)
+
+	_, err = app.store.WeeklyPlans.GetByStudentAndStartDate(r.Context(), student.ID, startDate)
+	if err == nil {
+		app.weekTakenResponse(w, r, startDate)
+		return
+	}
+	if !errors.Is(err, store.ErrorNotFound) {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	wp, err := newWeeklyPlan(student.ID, startDate, input.DayStartTime, input.DailyStudyHours)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	input.WeeklyPlanLimits.apply(wp)
+
+	err = app.store.WeeklyPlans.Insert(r.Context(), wp)
+	if errors.Is(err, store.ErrorConflict) {
+		// Another request created the plan of this week since the lookup
+		app.weekTakenResponse(w, r, startDate)
+		return
+	}
+	if err != nil {
+		app.storeErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"weekly_plan": mapWeeklyPlanToDisplay(wp)}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// weekTakenResponse reports that the student already has a plan for the week
+// starting on startDate.
+func (app *application) weekTakenResponse(w http.ResponseWriter, r *http.Request, startDate time.Time) {
+	app.conflictResponse(w, r, "a weekly plan already exists for the week starting "+app.calendarFor(r).FormatDate(startDate))
+}
+
+type WeekOfRequest struct {
+	Date            string `json:"date" validate:"required"`
+	DayStartTime    string `json:"day_start_time"`
+	DailyStudyHours int    `json:"daily_study_hours" validate:"omitempty,gt=0"`
+	WeeklyPlanLimits
+}
+
+// getOrCreateWeekPlanHandler returns the student's plan for the week that
+// contains the given date, creating it when it does not exist yet.
+func (app *application) getOrCreateWeekPlanHandler(w http.ResponseWriter, r *http.Request) {
+	student, ok := r.Context().Value(studentContextKey).(*store.Student)
+	if !ok {
+		app.serverErrorResponse(w, r, errors.New("could not retrieve student from context"))
+		return
+	}
+
+	var input WeekOfRequest
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	err = Validate.Struct(input)
+	if err != nil {
+		app.validationErrorResponse(w, r, err)
+		return
+	}
+
+	date, err := app.parseDate(r, input.Date)
+	if err != nil {
+		app.badRequestResponse(w, r, errors.New("invalid date format for date, please use YYYY-MM-DD"))
+		return
+	}
+	startDate := scheduler.WeekStart(date, app.config.weekStart)
+
+	wp, err := app.store.WeeklyPlans.GetByStudentAndStartDate(r.Context(), student.ID, startDate)
+	if err == nil {
+		err = app.writeJSON(w, http.StatusOK, envelope{"weekly_plan": mapWeeklyPlanToDisplay(wp)}, nil)
 		if err != nil {
-			app.badRequestResponse(w, r, errors.New("invalid day_start_time format, please use HH:MM"))
-			return
+			app.serverErrorResponse(w, r, err)
 		}
-		dayStartTime.Time = parsedTime
-		dayStartTime.Valid = true
+		return
+	}
+	if !errors.Is(err, store.ErrorNotFound) {
+		app.storeErrorResponse(w, r, err)
+		return
 	}
 
-	// Calculate total weekly blocks: daily_hours * 6 days (excluding Friday) * 60 minutes / 100-minute blocks
-	totalWeeklyMinutes := input.DailyStudyHours * 6 * 60
-	totalWeeklyBlocks := totalWeeklyMinutes / 100
+	if input.DailyStudyHours == 0 {
+		app.failedValidationResponse(w, r, map[string]string{"daily_study_hours": "must be provided to create the weekly plan"})
+		return
+	}
 
-	wp := &store.WeeklyPlan{
-		StudentID:                student.ID,
-		StartDateOfWeek:          startDate,
-		DayStartTime:             dayStartTime,
-		MaxStudyTimeHoursPerWeek: totalWeeklyBlocks, // This now stores calculated blocks
+	wp, err = newWeeklyPlan(student.ID, startDate, input.DayStartTime, input.DailyStudyHours)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
 	}
+	input.WeeklyPlanLimits.apply(wp)
 
 	err = app.store.WeeklyPlans.Insert(r.Context(), wp)
+	if errors.Is(err, store.ErrorConflict) {
+		// Another request created the plan of this week since the lookup
+		app.weekTakenResponse(w, r, startDate)
+		return
+	}
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"weekly_plan": mapWeeklyPlanToDisplay(wp)}, nil)
+	err = app.writeJSON(w, http.StatusCreated, envelope{"weekly_plan": mapWeeklyPlanToDisplay(wp)}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
 
+// Day start times leave room for at least one block before the scheduler's
+// 22:00 day end, and for at most ten blocks so that days stay cheap to order.
+var (
+	earliestDayStart = time.Date(0, 1, 1, 5, 0, 0, 0, time.UTC)
+	latestDayStart   = time.Date(0, 1, 1, 20, 20, 0, 0, time.UTC)
+)
+
+// parseDayStartTime reads an HH:MM day start time, which is unset when empty.
+func parseDayStartTime(dayStart string) (sql.NullTime, error) {
+	if dayStart == "" {
+		return sql.NullTime{}, nil
+	}
+
+	parsedTime, err := time.Parse("15:04", dayStart)
+	if err != nil {
+		return sql.NullTime{}, errors.New("invalid day_start_time format, please use HH:MM")
+	}
+	if parsedTime.Before(earliestDayStart) || parsedTime.After(latestDayStart) {
+		return sql.NullTime{}, fmt.Errorf("day_start_time must be between %s and %s", earliestDayStart.Format("15:04"), latestDayStart.Format("15:04"))
+	}
+	return sql.NullTime{Time: parsedTime, Valid: true}, nil
+}
+
+func newWeeklyPlan(studentID int64, startDate time.Time, dayStart string, dailyStudyHours int) (*store.WeeklyPlan, error) {
+	dayStartTime, err := parseDayStartTime(dayStart)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate total weekly blocks: daily_hours * 6 days (excluding Friday) * 60 minutes / 100-minute blocks
+	totalWeeklyMinutes := dailyStudyHours * 6 * 60
+	totalWeeklyBlocks := totalWeeklyMinutes / 100
+
+	return &store.WeeklyPlan{
+		StudentID:                studentID,
+		StartDateOfWeek:          startDate,
+		DayStartTime:             dayStartTime,
+		MaxStudyTimeHoursPerWeek: totalWeeklyBlocks, // This now stores calculated blocks
+	}, nil
+}
+
 func (app *application) listWeeklyPlansHandler(w http.ResponseWriter, r *http.Request) {
 	student, ok := r.Context().Value(studentContextKey).(*store.Student)
 	if !ok {
@@ -136,9 +323,15 @@ func (app *application) listWeeklyPlansHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	plans, err := app.store.WeeklyPlans.GetAllForStudent(r.Context(), student.ID)
+	opts, problems := app.readQueryOptions(r)
+	if len(problems) > 0 {
+		app.failedValidationResponse(w, r, problems)
+		return
+	}
+
+	plans, metadata, err := app.store.WeeklyPlans.GetAllForStudent(r.Context(), student.ID, opts)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -147,7 +340,7 @@ func (app *application) listWeeklyPlansHandler(w http.ResponseWriter, r *http.Re
 		displayPlans[i] = mapWeeklyPlanToDisplay(p)
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"weekly_plans": displayPlans}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"weekly_plans": displayPlans, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -168,11 +361,7 @@ func (app *application) getFullWeeklyCalendarHandler(w http.ResponseWriter, r *h
 
 	weeklyPlan, err := app.store.WeeklyPlans.Get(r.Context(), weeklyPlanID)
 	if err != nil {
-		if errors.Is(err, store.ErrorNotFound) {
-			app.notFoundResponse(w, r)
-			return
-		}
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
@@ -181,27 +370,45 @@ func (app *application) getFullWeeklyCalendarHandler(w http.ResponseWriter, r *h
 		return
 	}
 
-	dailyPlans, err := app.store.DailyPlans.GetAllForWeeklyPlan(r.Context(), weeklyPlan.ID)
+	dailySchedules, err := app.loadWeeklyCalendar(r.Context(), weeklyPlan)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.storeErrorResponse(w, r, err)
 		return
 	}
 
+	response := WeeklyCalendarResponse{
+		WeeklyPlan:     mapWeeklyPlanToDisplay(weeklyPlan),
+		DailySchedules: dailySchedules,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"weekly_calendar": response}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// loadWeeklyCalendar returns the daily plans of a weekly plan in date order,
+// each with its study sessions and their books.
+func (app *application) loadWeeklyCalendar(ctx context.Context, weeklyPlan *store.WeeklyPlan) ([]DailyCalendarEntry, error) {
+	dailyPlans, _, err := app.store.DailyPlans.GetAllForWeeklyPlan(ctx, weeklyPlan.ID, store.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
 	sort.Slice(dailyPlans, func(i, j int) bool {
 		return dailyPlans[i].PlanDate.Before(dailyPlans[j].PlanDate)
 	})
 
 	var dailySchedules []DailyCalendarEntry
 	for _, dp := range dailyPlans {
-		studySessions, err := app.store.StudySessions.GetAllForDailyPlan(r.Context(), dp.ID)
+		studySessions, _, err := app.store.StudySessions.GetAllForDailyPlan(ctx, dp.ID, store.QueryOptions{})
 		if err != nil {
-			app.serverErrorResponse(w, r, err)
-			return
+			return nil, err
 		}
 
 		var detailedSessions []StudySessionDetail
 		for _, ss := range studySessions {
-			book, err := app.store.Books.Get(r.Context(), ss.BookID)
+			book, err := app.store.Books.Get(ctx, ss.BookID)
 			if err != nil {
 				app.logger.Printf("Warning: Could not retrieve book %d for study session %d: %v", ss.BookID, ss.ID, err)
 				detailedSessions = append(detailedSessions, mapStudySessionToDetail(ss, nil))
@@ -215,13 +422,5 @@ func (app *application) getFullWeeklyCalendarHandler(w http.ResponseWriter, r *h
 		})
 	}
 
-	response := WeeklyCalendarResponse{
-		WeeklyPlan:     mapWeeklyPlanToDisplay(weeklyPlan),
-		DailySchedules: dailySchedules,
-	}
-
-	err = app.writeJSON(w, http.StatusOK, envelope{"weekly_calendar": response}, nil)
-	if err != nil {
-		app.serverErrorResponse(w, r, err)
-	}
+	return dailySchedules, nil
 }
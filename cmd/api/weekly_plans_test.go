@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/scheduler"
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+func TestParseDayStartTime(t *testing.T) {
+	tests := []struct {
+		dayStart string
+		valid    bool
+		blocks   int
+	}{
+		{"", true, 8},
+		{"05:00", true, 10},
+		{"08:30", true, 8},
+		{"20:20", true, 1},
+		{"04:59", false, 0},
+		{"00:00", false, 0},
+		{"20:21", false, 0},
+		{"23:00", false, 0},
+		{"8am", false, 0},
+	}
+
+	for _, tt := range tests {
+		dayStartTime, err := parseDayStartTime(tt.dayStart)
+		if (err == nil) != tt.valid {
+			t.Errorf("%q: got error %v, want valid %v", tt.dayStart, err, tt.valid)
+			continue
+		}
+		if blocks := len(scheduler.DayBlocks(dayStartTime)); tt.valid && blocks != tt.blocks {
+			t.Errorf("%q: %d blocks a day, want %d", tt.dayStart, blocks, tt.blocks)
+		}
+	}
+}
+
+func TestWeeklyPlanDayStartTimeIsBounded(t *testing.T) {
+	app := newTestApplication(t)
+	date := time.Now().UTC().Format("2006-01-02")
+
+	rr := serve(t, app, http.MethodPost, "/v1/students/1/weekly-plans", `{"start_date_of_week": "`+date+`", "day_start_time": "00:00", "daily_study_hours": 8}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("create at 00:00: status %d, want 400", rr.Code)
+	}
+	rr = serve(t, app, http.MethodPost, "/v1/students/1/weekly-plans/week-of", `{"date": "`+date+`", "day_start_time": "21:00", "daily_study_hours": 8}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("week-of at 21:00: status %d, want 400", rr.Code)
+	}
+	rr = serve(t, app, http.MethodPost, "/v1/students/1/weekly-plans/week-of", `{"date": "`+date+`", "day_start_time": "06:00", "daily_study_hours": 8}`)
+	if rr.Code != http.StatusCreated {
+		t.Errorf("week-of at 06:00: status %d, body %s", rr.Code, rr.Body)
+	}
+}
+
+func TestCreateWeeklyPlanValidatesInput(t *testing.T) {
+	app := newTestApplication(t)
+	date := time.Now().UTC().Format("2006-01-02")
+
+	for _, body := range []string{
+		`{"start_date_of_week": "` + date + `"}`,
+		`{"start_date_of_week": "` + date + `", "daily_study_hours": 0}`,
+		`{"start_date_of_week": "` + date + `", "daily_study_hours": -3}`,
+		`{"daily_study_hours": 4}`,
+		`{"start_date_of_week": "` + date + `", "daily_study_hours": 4, "max_blocks_per_day": 0}`,
+	} {
+		rr := serve(t, app, http.MethodPost, "/v1/students/1/weekly-plans", body)
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: status %d, want 422", body, rr.Code)
+		}
+	}
+}
+
+// staleWeeklyPlans misses every plan when looked up by week, as a request
+// does that another one beats to creating the plan.
+type staleWeeklyPlans struct {
+	store.WeeklyPlanStore
+}
+
+func (staleWeeklyPlans) GetByStudentAndStartDate(context.Context, int64, time.Time) (*store.WeeklyPlan, error) {
+	return nil, store.ErrorNotFound
+}
+
+func TestWeeklyPlanCreatedConcurrentlyConflicts(t *testing.T) {
+	app := newTestApplication(t)
+	date := time.Now().UTC().Format("2006-01-02")
+
+	rr := serve(t, app, http.MethodPost, "/v1/students/1/weekly-plans/week-of", `{"date": "`+date+`", "daily_study_hours": 4}`)
+	if rr.Code != http.StatusCreated && rr.Code != http.StatusOK {
+		t.Fatalf("week-of: status %d, body %s", rr.Code, rr.Body)
+	}
+
+	app.store.WeeklyPlans = staleWeeklyPlans{app.store.WeeklyPlans}
+	rr = serve(t, app, http.MethodPost, "/v1/students/1/weekly-plans", `{"start_date_of_week": "`+date+`", "daily_study_hours": 4}`)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("create: status %d, want 409", rr.Code)
+	}
+	rr = serve(t, app, http.MethodPost, "/v1/students/1/weekly-plans/week-of", `{"date": "`+date+`", "daily_study_hours": 4}`)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("week-of: status %d, want 409", rr.Code)
+	}
+}
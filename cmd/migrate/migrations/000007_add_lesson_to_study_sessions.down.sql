@@ -0,0 +1,4 @@
+-- 000007_add_lesson_to_study_sessions.down.sql
+
+DROP INDEX IF EXISTS idx_study_sessions_lesson_id;
+ALTER TABLE study_sessions DROP COLUMN IF EXISTS lesson_id;
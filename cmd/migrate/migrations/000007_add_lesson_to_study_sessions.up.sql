@@ -0,0 +1,6 @@
+-- 000007_add_lesson_to_study_sessions.up.sql
+
+ALTER TABLE study_sessions
+    ADD COLUMN lesson_id INT REFERENCES lessons(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_study_sessions_lesson_id ON study_sessions(lesson_id);
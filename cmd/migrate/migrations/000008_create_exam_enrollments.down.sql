@@ -0,0 +1,3 @@
+-- 000008_create_exam_enrollments.down.sql
+
+DROP TABLE IF EXISTS exam_enrollments;
@@ -0,0 +1,11 @@
+-- 000008_create_exam_enrollments.up.sql
+
+CREATE TABLE exam_enrollments (
+    id SERIAL PRIMARY KEY,
+    student_id INT NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    exam_id INT NOT NULL REFERENCES exam_schedules(id) ON DELETE CASCADE,
+    enrolled_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(student_id, exam_id)
+);
+
+CREATE INDEX idx_exam_enrollments_exam_id ON exam_enrollments(exam_id);
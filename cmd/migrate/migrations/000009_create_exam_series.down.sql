@@ -0,0 +1,5 @@
+-- 000009_create_exam_series.down.sql
+
+DROP INDEX IF EXISTS idx_exam_schedules_series_id;
+ALTER TABLE exam_schedules DROP COLUMN IF EXISTS series_id;
+DROP TABLE IF EXISTS exam_series;
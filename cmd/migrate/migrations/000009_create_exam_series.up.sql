@@ -0,0 +1,15 @@
+-- 000009_create_exam_series.up.sql
+
+CREATE TABLE exam_series (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    organisation VARCHAR(255),
+    target_grade_id INT NOT NULL REFERENCES grades(id),
+    major_id INT NOT NULL REFERENCES majors(id),
+    interval_days INT CHECK (interval_days > 0)
+);
+
+ALTER TABLE exam_schedules
+    ADD COLUMN series_id INT REFERENCES exam_series(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_exam_schedules_series_id ON exam_schedules(series_id);
@@ -0,0 +1,13 @@
+-- 000010_add_scope_item_ranges.down.sql
+
+DROP INDEX IF EXISTS idx_exam_scope_items_exam_id;
+
+-- Range items cannot be represented by the old schema
+DELETE FROM exam_scope_items WHERE lesson_id IS NULL;
+
+ALTER TABLE exam_scope_items
+    DROP CONSTRAINT IF EXISTS exam_scope_items_target_check,
+    DROP COLUMN IF EXISTS to_lesson_number,
+    DROP COLUMN IF EXISTS from_lesson_number,
+    DROP COLUMN IF EXISTS book_id,
+    ALTER COLUMN lesson_id SET NOT NULL;
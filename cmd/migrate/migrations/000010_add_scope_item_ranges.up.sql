@@ -0,0 +1,16 @@
+-- 000010_add_scope_item_ranges.up.sql
+
+-- A scope item now targets either a single lesson, a whole book, or a range
+-- of a book's lessons numbered from 1 in lesson order.
+ALTER TABLE exam_scope_items
+    ALTER COLUMN lesson_id DROP NOT NULL,
+    ADD COLUMN book_id INT REFERENCES books(id) ON DELETE CASCADE,
+    ADD COLUMN from_lesson_number INT,
+    ADD COLUMN to_lesson_number INT,
+    ADD CONSTRAINT exam_scope_items_target_check CHECK (
+        (lesson_id IS NOT NULL AND book_id IS NULL AND from_lesson_number IS NULL AND to_lesson_number IS NULL)
+        OR (lesson_id IS NULL AND book_id IS NOT NULL AND from_lesson_number IS NULL AND to_lesson_number IS NULL)
+        OR (lesson_id IS NULL AND book_id IS NOT NULL AND from_lesson_number >= 1 AND to_lesson_number >= from_lesson_number)
+    );
+
+CREATE INDEX idx_exam_scope_items_exam_id ON exam_scope_items(exam_id);
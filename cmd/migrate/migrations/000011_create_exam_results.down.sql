@@ -0,0 +1,3 @@
+-- 000011_create_exam_results.down.sql
+
+DROP TABLE IF EXISTS exam_results;
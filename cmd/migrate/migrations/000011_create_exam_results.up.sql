@@ -0,0 +1,20 @@
+-- 000011_create_exam_results.up.sql
+
+-- One row per subject (book) a student answered in an exam. Konkur-style
+-- negative marking allows percentages below zero.
+CREATE TABLE exam_results (
+    id SERIAL PRIMARY KEY,
+    student_id INT NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    exam_id INT NOT NULL REFERENCES exam_schedules(id) ON DELETE CASCADE,
+    book_id INT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    raw_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    percentage DOUBLE PRECISION NOT NULL CHECK (percentage BETWEEN -100 AND 100),
+    rank INT CHECK (rank > 0),
+    num_correct INT NOT NULL DEFAULT 0 CHECK (num_correct >= 0),
+    num_wrong INT NOT NULL DEFAULT 0 CHECK (num_wrong >= 0),
+    num_blank INT NOT NULL DEFAULT 0 CHECK (num_blank >= 0),
+    recorded_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(student_id, exam_id, book_id)
+);
+
+CREATE INDEX idx_exam_results_exam_id ON exam_results(exam_id);
@@ -0,0 +1,4 @@
+-- 000012_normalize_weekly_plan_week_start.down.sql
+
+-- The original start dates and merged plans are not kept, so there is nothing
+-- to restore. The unique indexes stay, as they back the constraints of 000003.
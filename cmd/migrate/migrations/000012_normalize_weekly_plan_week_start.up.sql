@@ -0,0 +1,156 @@
+-- 000012_normalize_weekly_plan_week_start.up.sql
+
+-- Anchor every weekly plan to the first day of the week on or before its
+-- start date, as the API does with its -week-start flag. The first day is read
+-- from the alberta.week_start setting and defaults to saturday, the default of
+-- the flag. Deployments started with another -week-start should run the
+-- migration with the same day, e.g. with options=-c alberta.week_start=sunday
+-- in the database URL.
+--
+-- Plans of one student that fall into the same week are merged into the
+-- oldest one, and every daily plan moves to the plan of the week its date
+-- falls in, which is created when the student has none. A daily plan whose
+-- date the target plan already has is only dropped when it has no study
+-- sessions; otherwise the migration fails and names the plans to resolve.
+DO $$
+DECLARE
+    first_day_name text := lower(coalesce(nullif(current_setting('alberta.week_start', true), ''), 'saturday'));
+    first_day int;
+    conflicts text;
+    dropped int;
+BEGIN
+    -- EXTRACT(DOW) is 0 for Sunday and 6 for Saturday
+    first_day := array_position(
+        ARRAY['sunday', 'monday', 'tuesday', 'wednesday', 'thursday', 'friday', 'saturday'],
+        first_day_name) - 1;
+    IF first_day IS NULL THEN
+        RAISE EXCEPTION 'alberta.week_start % is not the English name of a weekday', first_day_name;
+    END IF;
+
+    CREATE TEMPORARY TABLE weekly_plan_weeks ON COMMIT DROP AS
+    SELECT id,
+           student_id,
+           start_date_of_week - ((EXTRACT(DOW FROM start_date_of_week)::int - first_day + 7) % 7) AS week_start
+    FROM weekly_plans;
+
+    CREATE TEMPORARY TABLE daily_plan_weeks ON COMMIT DROP AS
+    SELECT dp.id,
+           dp.weekly_plan_id,
+           dp.plan_date,
+           wp.student_id,
+           dp.plan_date - ((EXTRACT(DOW FROM dp.plan_date)::int - first_day + 7) % 7) AS week_start
+    FROM daily_plans dp
+    INNER JOIN weekly_plans wp ON wp.id = dp.weekly_plan_id;
+
+    -- A daily plan outside every plan of the student gets a plan for its
+    -- week, with the settings of the plan it was on.
+    WITH missing AS (
+        SELECT DISTINCT ON (d.student_id, d.week_start)
+               d.student_id, d.week_start, d.weekly_plan_id
+        FROM daily_plan_weeks d
+        WHERE NOT EXISTS (
+            SELECT 1 FROM weekly_plan_weeks w
+            WHERE w.student_id = d.student_id AND w.week_start = d.week_start
+        )
+        ORDER BY d.student_id, d.week_start, d.id
+    ), inserted AS (
+        INSERT INTO weekly_plans (student_id, start_date_of_week, day_start_time, max_study_time_hours_per_week)
+        SELECT m.student_id, m.week_start, wp.day_start_time, wp.max_study_time_hours_per_week
+        FROM missing m
+        INNER JOIN weekly_plans wp ON wp.id = m.weekly_plan_id
+        RETURNING id, student_id, start_date_of_week
+    )
+    INSERT INTO weekly_plan_weeks (id, student_id, week_start)
+    SELECT id, student_id, start_date_of_week FROM inserted;
+
+    ALTER TABLE weekly_plan_weeks ADD COLUMN keeper_id INT;
+    UPDATE weekly_plan_weeks w
+    SET keeper_id = k.keeper_id
+    FROM (
+        SELECT id, MIN(id) OVER (PARTITION BY student_id, week_start) AS keeper_id
+        FROM weekly_plan_weeks
+    ) k
+    WHERE k.id = w.id;
+
+    -- Of the daily plans that end up on one plan with the same date, the one
+    -- already on that plan is kept, or else the oldest.
+    ALTER TABLE daily_plan_weeks ADD COLUMN target_id INT, ADD COLUMN keep BOOLEAN;
+    UPDATE daily_plan_weeks d
+    SET target_id = w.keeper_id
+    FROM weekly_plan_weeks w
+    WHERE w.student_id = d.student_id AND w.week_start = d.week_start;
+    UPDATE daily_plan_weeks d
+    SET keep = r.place = 1
+    FROM (
+        SELECT id, ROW_NUMBER() OVER (
+            PARTITION BY target_id, plan_date
+            ORDER BY weekly_plan_id = target_id DESC, id
+        ) AS place
+        FROM daily_plan_weeks
+    ) r
+    WHERE r.id = d.id;
+
+    SELECT string_agg(format('daily plan %s (%s) of weekly plan %s', d.id, d.plan_date, d.weekly_plan_id), ', ' ORDER BY d.id)
+    INTO conflicts
+    FROM daily_plan_weeks d
+    WHERE NOT d.keep
+      AND EXISTS (SELECT 1 FROM study_sessions ss WHERE ss.daily_plan_id = d.id);
+    IF conflicts IS NOT NULL THEN
+        RAISE EXCEPTION 'daily plans with study sessions collide with another plan for the same date: %', conflicts
+            USING HINT = 'Move or delete their study sessions, then run the migration again.';
+    END IF;
+
+    DELETE FROM daily_plans dp
+    USING daily_plan_weeks d
+    WHERE dp.id = d.id AND NOT d.keep;
+    GET DIAGNOSTICS dropped = ROW_COUNT;
+    IF dropped > 0 THEN
+        RAISE NOTICE 'dropped % daily plans without study sessions whose date another plan of the week already had', dropped;
+    END IF;
+
+    UPDATE daily_plans dp
+    SET weekly_plan_id = d.target_id
+    FROM daily_plan_weeks d
+    WHERE dp.id = d.id AND dp.weekly_plan_id <> d.target_id;
+
+    -- The frequencies of merged plans move over for the books the kept plan
+    -- has none for.
+    UPDATE subject_frequencies sf
+    SET weekly_plan_id = w.keeper_id
+    FROM weekly_plan_weeks w
+    WHERE sf.weekly_plan_id = w.id
+      AND w.id <> w.keeper_id
+      AND NOT EXISTS (
+          SELECT 1 FROM subject_frequencies kept
+          WHERE kept.weekly_plan_id = w.keeper_id AND kept.book_id = sf.book_id
+      )
+      AND sf.id = (
+          SELECT MIN(other.id)
+          FROM subject_frequencies other
+          INNER JOIN weekly_plan_weeks ow ON ow.id = other.weekly_plan_id
+          WHERE ow.keeper_id = w.keeper_id AND other.book_id = sf.book_id
+      );
+
+    DELETE FROM weekly_plans wp
+    USING weekly_plan_weeks w
+    WHERE wp.id = w.id AND w.id <> w.keeper_id;
+    GET DIAGNOSTICS dropped = ROW_COUNT;
+    IF dropped > 0 THEN
+        RAISE NOTICE 'merged % weekly plans into the plan of the same week', dropped;
+    END IF;
+
+    UPDATE weekly_plans wp
+    SET start_date_of_week = w.week_start
+    FROM weekly_plan_weeks w
+    WHERE wp.id = w.id AND wp.start_date_of_week <> w.week_start;
+END
+$$;
+
+-- With the weeks merged, a student has one plan per week and a plan one daily
+-- plan per date. The indexes carry the names of the UNIQUE constraints of
+-- 000003, so they are only built where those constraints were dropped, and
+-- they keep concurrent requests from creating the duplicates again.
+CREATE UNIQUE INDEX IF NOT EXISTS weekly_plans_student_id_start_date_of_week_key
+    ON weekly_plans (student_id, start_date_of_week);
+CREATE UNIQUE INDEX IF NOT EXISTS daily_plans_weekly_plan_id_plan_date_key
+    ON daily_plans (weekly_plan_id, plan_date);
@@ -0,0 +1,3 @@
+-- 000013_add_timezone_to_students.down.sql
+
+ALTER TABLE students DROP COLUMN IF EXISTS timezone;
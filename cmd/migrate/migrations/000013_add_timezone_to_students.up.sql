@@ -0,0 +1,5 @@
+-- 000013_add_timezone_to_students.up.sql
+
+-- IANA time zone that the student's study, unavailable and calendar times are
+-- expressed in.
+ALTER TABLE students ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Tehran';
@@ -0,0 +1,3 @@
+-- 000014_add_calendar_feed_token.down.sql
+
+ALTER TABLE students DROP COLUMN IF EXISTS calendar_token_hash;
@@ -0,0 +1,4 @@
+-- 000014_add_calendar_feed_token.up.sql
+
+-- SHA-256 hash of the secret token in the student's calendar subscription URL
+ALTER TABLE students ADD COLUMN calendar_token_hash BYTEA UNIQUE;
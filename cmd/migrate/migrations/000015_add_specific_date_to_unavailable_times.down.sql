@@ -0,0 +1,5 @@
+-- 000015_add_specific_date_to_unavailable_times.down.sql
+
+DROP INDEX IF EXISTS idx_unavailable_times_student_id;
+
+ALTER TABLE unavailable_times DROP COLUMN IF EXISTS specific_date;
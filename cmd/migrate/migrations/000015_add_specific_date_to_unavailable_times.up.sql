@@ -0,0 +1,6 @@
+-- 000015_add_specific_date_to_unavailable_times.up.sql
+
+-- One-off unavailable times apply on a single date instead of every week
+ALTER TABLE unavailable_times ADD COLUMN specific_date DATE;
+
+CREATE INDEX idx_unavailable_times_student_id ON unavailable_times(student_id);
@@ -0,0 +1,3 @@
+-- 000016_add_generation_seed_to_weekly_plans.down.sql
+
+ALTER TABLE weekly_plans DROP COLUMN IF EXISTS generation_seed;
@@ -0,0 +1,4 @@
+-- 000016_add_generation_seed_to_weekly_plans.up.sql
+
+-- Seed of the last schedule generation, so that the week can be reproduced
+ALTER TABLE weekly_plans ADD COLUMN generation_seed BIGINT;
@@ -0,0 +1,6 @@
+-- 000017_add_daily_limits_to_weekly_plans.down.sql
+
+ALTER TABLE weekly_plans
+    DROP COLUMN IF EXISTS max_blocks_per_day,
+    DROP COLUMN IF EXISTS max_book_blocks_per_day,
+    DROP COLUMN IF EXISTS min_days_between_book_sessions;
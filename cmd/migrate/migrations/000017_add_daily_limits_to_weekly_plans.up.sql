@@ -0,0 +1,7 @@
+-- 000017_add_daily_limits_to_weekly_plans.up.sql
+
+-- Optional limits on how the scheduler fills each day of the week
+ALTER TABLE weekly_plans
+    ADD COLUMN max_blocks_per_day INTEGER CHECK (max_blocks_per_day > 0),
+    ADD COLUMN max_book_blocks_per_day INTEGER CHECK (max_book_blocks_per_day > 0),
+    ADD COLUMN min_days_between_book_sessions INTEGER CHECK (min_days_between_book_sessions >= 0);
@@ -0,0 +1,3 @@
+-- 000018_add_difficulty_to_books.down.sql
+
+ALTER TABLE books DROP COLUMN IF EXISTS difficulty;
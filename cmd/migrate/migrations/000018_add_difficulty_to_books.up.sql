@@ -0,0 +1,4 @@
+-- 000018_add_difficulty_to_books.up.sql
+
+-- Cognitive load of studying a book: 1 light, 2 medium, 3 heavy
+ALTER TABLE books ADD COLUMN difficulty SMALLINT NOT NULL DEFAULT 2 CHECK (difficulty BETWEEN 1 AND 3);
@@ -0,0 +1,223 @@
+// Package calendar converts dates between the Gregorian and the Iranian
+// (Jalali, Solar Hijri) calendars and names weekdays in both.
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Calendar string
+
+const (
+	Gregorian Calendar = "gregorian"
+	Jalali    Calendar = "jalali"
+)
+
+var ErrInvalidDate = errors.New("invalid date")
+
+// Parse returns the calendar called name. Besides the canonical names it
+// accepts the common aliases of the Jalali calendar.
+func Parse(name string) (Calendar, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "gregorian", "gregory":
+		return Gregorian, true
+	case "jalali", "persian", "shamsi", "solar-hijri":
+		return Jalali, true
+	}
+	return "", false
+}
+
+// ParseDate parses a YYYY-MM-DD date written in the calendar and returns it
+// as a Gregorian time at midnight UTC.
+func (c Calendar) ParseDate(s string) (time.Time, error) {
+	if c != Jalali {
+		return time.Parse("2006-01-02", s)
+	}
+	return ParseJalali(s)
+}
+
+// FormatDate formats t as YYYY-MM-DD in the calendar.
+func (c Calendar) FormatDate(t time.Time) string {
+	if c != Jalali {
+		return t.Format("2006-01-02")
+	}
+	return ToJalali(t).String()
+}
+
+// WeekdayName returns the weekday name used with the calendar: Persian for
+// Jalali and English otherwise.
+func (c Calendar) WeekdayName(day time.Weekday) string {
+	if c != Jalali {
+		return day.String()
+	}
+	return persianWeekdays[day]
+}
+
+var persianWeekdays = [...]string{
+	time.Sunday:    "یکشنبه",
+	time.Monday:    "دوشنبه",
+	time.Tuesday:   "سه‌شنبه",
+	time.Wednesday: "چهارشنبه",
+	time.Thursday:  "پنجشنبه",
+	time.Friday:    "جمعه",
+	time.Saturday:  "شنبه",
+}
+
+var jalaliMonths = [...]string{
+	"فروردین", "اردیبهشت", "خرداد", "تیر", "مرداد", "شهریور",
+	"مهر", "آبان", "آذر", "دی", "بهمن", "اسفند",
+}
+
+// Date is a day in the Jalali calendar. Month runs from 1 (Farvardin) to 12
+// (Esfand).
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// MonthName returns the Persian name of the month.
+func (d Date) MonthName() string {
+	if d.Month < 1 || d.Month > 12 {
+		return ""
+	}
+	return jalaliMonths[d.Month-1]
+}
+
+// PersianDigits replaces the ASCII digits of s with Persian ones.
+func PersianDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return '۰' + r - '0'
+		}
+		return r
+	}, s)
+}
+
+// Jalali years are computed from the break years of the 2820-year cycle, as
+// in the widely used jalaali algorithm. Years outside this table are rejected.
+var breaks = [...]int{
+	-61, 9, 38, 199, 426, 686, 756, 818, 1111, 1181, 1210,
+	1635, 2060, 2097, 2192, 2262, 2324, 2394, 2456, 3178,
+}
+
+// jalaliYearInfo returns whether the Jalali year jy is leap and the day of
+// March in Gregorian year jy+621 on which it starts.
+func jalaliYearInfo(jy int) (leap bool, march int, err error) {
+	if jy < breaks[0] || jy >= breaks[len(breaks)-1] {
+		return false, 0, fmt.Errorf("%w: year %d is out of range", ErrInvalidDate, jy)
+	}
+
+	gy := jy + 621
+	leapJ := -14
+	jp := breaks[0]
+	jump := 0
+	for _, jm := range breaks[1:] {
+		jump = jm - jp
+		if jy < jm {
+			break
+		}
+		leapJ += jump/33*8 + jump%33/4
+		jp = jm
+	}
+
+	n := jy - jp
+	leapJ += n/33*8 + (n%33+3)/4
+	if jump%33 == 4 && jump-n == 4 {
+		leapJ++
+	}
+
+	leapG := gy/4 - (gy/100+1)*3/4 - 150
+	march = 20 + leapJ - leapG
+
+	if jump-n < 6 {
+		n = n - jump + (jump+4)/33*33
+	}
+	remainder := ((n+1)%33 - 1) % 4
+	return remainder == 0, march, nil
+}
+
+// IsLeapYear reports whether the Jalali year has 366 days.
+func IsLeapYear(year int) bool {
+	leap, _, err := jalaliYearInfo(year)
+	return err == nil && leap
+}
+
+// DaysInMonth returns the number of days of a Jalali month.
+func DaysInMonth(year, month int) int {
+	switch {
+	case month >= 1 && month <= 6:
+		return 31
+	case month >= 7 && month <= 11:
+		return 30
+	case month == 12 && IsLeapYear(year):
+		return 30
+	case month == 12:
+		return 29
+	}
+	return 0
+}
+
+// ToJalali returns the Jalali date of t in t's location.
+func ToJalali(t time.Time) Date {
+	gregorian := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	jy := t.Year() - 621
+	_, march, err := jalaliYearInfo(jy)
+	if err != nil {
+		return Date{}
+	}
+
+	days := int(gregorian.Sub(time.Date(t.Year(), time.March, march, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	if days < 0 {
+		// Still in the last months of the previous Jalali year
+		jy--
+		days += 179
+		if IsLeapYear(jy) {
+			days++
+		}
+		return Date{Year: jy, Month: 7 + days/30, Day: days%30 + 1}
+	}
+
+	if days < 186 {
+		return Date{Year: jy, Month: 1 + days/31, Day: days%31 + 1}
+	}
+	days -= 186
+	return Date{Year: jy, Month: 7 + days/30, Day: days%30 + 1}
+}
+
+// FromJalali returns midnight of the Jalali date in loc.
+func FromJalali(d Date, loc *time.Location) (time.Time, error) {
+	_, march, err := jalaliYearInfo(d.Year)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if d.Month < 1 || d.Month > 12 || d.Day < 1 || d.Day > DaysInMonth(d.Year, d.Month) {
+		return time.Time{}, fmt.Errorf("%w: %s does not exist in the Jalali calendar", ErrInvalidDate, d)
+	}
+
+	days := (d.Month-1)*31 + d.Day - 1
+	if d.Month > 7 {
+		days -= d.Month - 7
+	}
+	return time.Date(d.Year+621, time.March, march+days, 0, 0, 0, 0, loc), nil
+}
+
+// ParseJalali parses a YYYY-MM-DD Jalali date and returns the Gregorian day
+// at midnight UTC.
+func ParseJalali(s string) (time.Time, error) {
+	var d Date
+	var rest string
+	n, _ := fmt.Sscanf(s, "%4d-%2d-%2d%s", &d.Year, &d.Month, &d.Day, &rest)
+	if n != 3 || len(s) != len("1403-01-01") {
+		return time.Time{}, fmt.Errorf("%w: %q is not a YYYY-MM-DD date", ErrInvalidDate, s)
+	}
+	return FromJalali(d, time.UTC)
+}
@@ -0,0 +1,153 @@
+package calendar
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func gregorian(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestToJalali(t *testing.T) {
+	tests := []struct {
+		date time.Time
+		want Date
+	}{
+		// Nowruz falls on March 20 or 21
+		{gregorian(2020, time.March, 20), Date{1399, 1, 1}},
+		{gregorian(2021, time.March, 21), Date{1400, 1, 1}},
+		{gregorian(2024, time.March, 20), Date{1403, 1, 1}},
+		{gregorian(2025, time.March, 21), Date{1404, 1, 1}},
+		{gregorian(2026, time.March, 21), Date{1405, 1, 1}},
+		// the last day of a leap year and of a common one
+		{gregorian(2025, time.March, 20), Date{1403, 12, 30}},
+		{gregorian(2024, time.March, 19), Date{1402, 12, 29}},
+		// the first day of Mehr, where months turn from 31 days to 30
+		{gregorian(2026, time.September, 23), Date{1405, 7, 1}},
+		{gregorian(2026, time.October, 19), Date{1405, 7, 27}},
+		{gregorian(1979, time.February, 11), Date{1357, 11, 22}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date.Format("2006-01-02"), func(t *testing.T) {
+			if got := ToJalali(tt.date); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			got, err := FromJalali(tt.want, time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.date) {
+				t.Errorf("FromJalali(%s) = %s, want %s", tt.want, got.Format("2006-01-02"), tt.date.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestJalaliRoundTrip(t *testing.T) {
+	previous := ToJalali(gregorian(1999, time.December, 31))
+	for date := gregorian(2000, time.January, 1); date.Year() < 2050; date = date.AddDate(0, 0, 1) {
+		d := ToJalali(date)
+		back, err := FromJalali(d, time.UTC)
+		if err != nil {
+			t.Fatalf("%s: %v", date.Format("2006-01-02"), err)
+		}
+		if !back.Equal(date) {
+			t.Fatalf("%s became %s and then %s", date.Format("2006-01-02"), d, back.Format("2006-01-02"))
+		}
+
+		// each day follows the one before it
+		switch {
+		case d.Year == previous.Year && d.Month == previous.Month && d.Day == previous.Day+1:
+		case d.Year == previous.Year && d.Month == previous.Month+1 && d.Day == 1 &&
+			previous.Day == DaysInMonth(previous.Year, previous.Month):
+		case d == Date{previous.Year + 1, 1, 1} && previous.Month == 12 &&
+			previous.Day == DaysInMonth(previous.Year, 12):
+		default:
+			t.Fatalf("%s follows %s", d, previous)
+		}
+		previous = d
+	}
+}
+
+func TestIsLeapYear(t *testing.T) {
+	leap := map[int]bool{
+		1395: true, 1396: false, 1399: true, 1400: false, 1402: false,
+		1403: true, 1404: false, 1407: false, 1408: true, 1412: true,
+	}
+	for year, want := range leap {
+		if got := IsLeapYear(year); got != want {
+			t.Errorf("IsLeapYear(%d) = %v, want %v", year, got, want)
+		}
+	}
+	if IsLeapYear(4000) {
+		t.Error("IsLeapYear(4000): a year out of range is not leap")
+	}
+}
+
+func TestParseJalali(t *testing.T) {
+	got, err := ParseJalali("1403-12-30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := gregorian(2025, time.March, 20); !got.Equal(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	for _, s := range []string{"1404-12-30", "1402-07-31", "1403-13-01", "1403-00-10", "1403-1-1", "1403-01-01x", "1403/01/01", ""} {
+		if _, err := ParseJalali(s); !errors.Is(err, ErrInvalidDate) {
+			t.Errorf("ParseJalali(%q): got %v, want ErrInvalidDate", s, err)
+		}
+	}
+}
+
+func TestWeekAcrossNowruz(t *testing.T) {
+	// The Persian week runs from Saturday to Friday; this one starts in
+	// Esfand 1402 and ends in Farvardin 1403.
+	saturday := gregorian(2024, time.March, 16)
+	want := []struct {
+		date    string
+		weekday string
+	}{
+		{"1402-12-26", "شنبه"},
+		{"1402-12-27", "یکشنبه"},
+		{"1402-12-28", "دوشنبه"},
+		{"1402-12-29", "سه‌شنبه"},
+		{"1403-01-01", "چهارشنبه"},
+		{"1403-01-02", "پنجشنبه"},
+		{"1403-01-03", "جمعه"},
+	}
+
+	for i, w := range want {
+		day := saturday.AddDate(0, 0, i)
+		if got := Jalali.FormatDate(day); got != w.date {
+			t.Errorf("day %d: got %s, want %s", i, got, w.date)
+		}
+		if got := Jalali.WeekdayName(day.Weekday()); got != w.weekday {
+			t.Errorf("day %d: got weekday %s, want %s", i, got, w.weekday)
+		}
+		if got := Gregorian.WeekdayName(day.Weekday()); got != day.Weekday().String() {
+			t.Errorf("day %d: got Gregorian weekday %s", i, got)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := map[string]Calendar{
+		"":         Gregorian,
+		"gregory":  Gregorian,
+		"Jalali":   Jalali,
+		" persian": Jalali,
+		"shamsi":   Jalali,
+	}
+	for name, want := range tests {
+		if got, ok := Parse(name); !ok || got != want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+	if _, ok := Parse("hijri"); ok {
+		t.Error(`Parse("hijri"): got ok`)
+	}
+}
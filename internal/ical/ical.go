@@ -0,0 +1,144 @@
+// Package ical writes iCalendar (RFC 5545) documents with the subset of
+// properties needed to publish study plans to calendar applications.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Calendar struct {
+	ProdID string
+	Name   string
+	// RefreshInterval, when set, tells subscribing clients how often to
+	// reload the calendar.
+	RefreshInterval time.Duration
+	Events          []*Event
+}
+
+// Event is a VEVENT. Timed events are written in UTC; all-day events cover
+// the calendar dates from Start up to, but not including, End, as midnight
+// UTC. RRule holds the raw recurrence rule, if any, and ExDates the starts of
+// the occurrences it excludes. ExDates are read by Parse but not written.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	RRule       string
+	ExDates     []time.Time
+	Categories  []string
+}
+
+// Encode writes the calendar to w. stamp is used as the DTSTAMP of every
+// event.
+func (c *Calendar) Encode(w io.Writer, stamp time.Time) error {
+	bw := bufio.NewWriter(w)
+	lw := &lineWriter{w: bw}
+
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:" + c.ProdID)
+	lw.line("CALSCALE:GREGORIAN")
+	lw.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME:" + escapeText(c.Name))
+	}
+	if c.RefreshInterval > 0 {
+		lw.line("REFRESH-INTERVAL;VALUE=DURATION:" + formatDuration(c.RefreshInterval))
+		lw.line("X-PUBLISHED-TTL:" + formatDuration(c.RefreshInterval))
+	}
+
+	for _, event := range c.Events {
+		lw.line("BEGIN:VEVENT")
+		lw.line("UID:" + event.UID)
+		lw.line("DTSTAMP:" + formatUTC(stamp))
+		if event.AllDay {
+			lw.line("DTSTART;VALUE=DATE:" + event.Start.Format("20060102"))
+			lw.line("DTEND;VALUE=DATE:" + event.End.Format("20060102"))
+		} else {
+			lw.line("DTSTART:" + formatUTC(event.Start))
+			lw.line("DTEND:" + formatUTC(event.End))
+		}
+		if event.RRule != "" {
+			lw.line("RRULE:" + event.RRule)
+		}
+		lw.line("SUMMARY:" + escapeText(event.Summary))
+		if event.Description != "" {
+			lw.line("DESCRIPTION:" + escapeText(event.Description))
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escapeText(category)
+			}
+			lw.line("CATEGORIES:" + strings.Join(categories, ","))
+		}
+		lw.line("END:VEVENT")
+	}
+
+	lw.line("END:VCALENDAR")
+
+	if lw.err != nil {
+		return lw.err
+	}
+	return bw.Flush()
+}
+
+// lineWriter writes content lines terminated by CRLF, folding them at 75
+// octets without splitting UTF-8 sequences. It keeps the first error.
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (lw *lineWriter) line(s string) {
+	if lw.err != nil {
+		return
+	}
+
+	const maxOctets = 75
+	limit := maxOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		_, lw.err = lw.w.WriteString(s[:cut] + "\r\n ")
+		if lw.err != nil {
+			return
+		}
+		s = s[cut:]
+		// Continuation lines start with a space, which counts toward the limit
+		limit = maxOctets - 1
+	}
+	_, lw.err = lw.w.WriteString(s + "\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// formatDuration formats d as an RFC 5545 duration in whole minutes.
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Minutes())
+	if minutes%60 == 0 {
+		return "PT" + strconv.Itoa(minutes/60) + "H"
+	}
+	return "PT" + strconv.Itoa(minutes) + "M"
+}
@@ -0,0 +1,180 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEncodeFoldsLines(t *testing.T) {
+	summary := strings.Repeat("ریاضی ۱ - فصل دوم: معادله‌ها و نامعادله‌ها ", 4)
+	stamp := time.Date(2026, time.October, 19, 6, 0, 0, 0, time.UTC)
+	cal := &Calendar{
+		ProdID: "-//Alberta//Study Plan//FA",
+		Events: []*Event{{
+			UID:     "session-1@alberta",
+			Summary: summary,
+			Start:   stamp,
+			End:     stamp.Add(100 * time.Minute),
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf, stamp); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.HasSuffix(out, "\r\n") {
+		t.Error("output does not end with CRLF")
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+	folded := 0
+	for i, line := range lines {
+		if len(line) > 75 {
+			t.Errorf("line %d has %d octets: %q", i, len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d splits a UTF-8 sequence: %q", i, line)
+		}
+		if strings.HasPrefix(line, " ") {
+			folded++
+		}
+	}
+	if folded == 0 {
+		t.Fatal("the long summary was not folded")
+	}
+
+	var unfolded string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "SUMMARY:") || (unfolded != "" && strings.HasPrefix(line, " ")) {
+			unfolded += strings.TrimPrefix(line, " ")
+		} else if unfolded != "" {
+			break
+		}
+	}
+	if want := "SUMMARY:" + summary; unfolded != want {
+		t.Errorf("unfolded summary %q, want %q", unfolded, want)
+	}
+}
+
+func TestLineWriterFoldsAtRuneBoundaries(t *testing.T) {
+	// After the name, the two-octet Persian letters start on even octets, so
+	// the fold at 75 has to step back one.
+	line := "SUMMARY:" + strings.Repeat("ب", 100)
+
+	var buf bytes.Buffer
+	lw := &lineWriter{w: bufio.NewWriter(&buf)}
+	lw.line(line)
+	if err := lw.w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	want := []int{74, 75, 61}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %q", len(lines), len(want), lines)
+	}
+	for i, l := range lines {
+		if len(l) != want[i] {
+			t.Errorf("line %d has %d octets, want %d", i, len(l), want[i])
+		}
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"فیزیک ۲", "فیزیک ۲"},
+		// the Persian comma and semicolon are not iCalendar separators
+		{"فیزیک، شیمی؛ زیست", "فیزیک، شیمی؛ زیست"},
+		{"فیزیک, شیمی; زیست", `فیزیک\, شیمی\; زیست`},
+		{"مرور\nتست", `مرور\nتست`},
+		{"مرور\r\nتست", `مرور\nتست`},
+		{`C:\درس`, `C:\\درس`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeText(tt.text); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		if tt.text != "مرور\r\nتست" {
+			if got := unescapeText(escapeText(tt.text)); got != tt.text {
+				t.Errorf("unescapeText(escapeText(%q)) = %q", tt.text, got)
+			}
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	stamp := time.Date(2026, time.October, 19, 6, 0, 0, 0, time.UTC)
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal := &Calendar{
+		ProdID:          "-//Alberta//Study Plan//FA",
+		Name:            "برنامه مطالعه, سارا",
+		RefreshInterval: 90 * time.Minute,
+		Events: []*Event{
+			{
+				UID:         "session-1@alberta",
+				Summary:     "ریاضی",
+				Description: "فصل ۱",
+				Start:       time.Date(2026, time.October, 19, 8, 0, 0, 0, tehran),
+				End:         time.Date(2026, time.October, 19, 9, 40, 0, 0, tehran),
+				RRule:       "FREQ=WEEKLY;COUNT=2",
+				Categories:  []string{"مطالعه", "a,b"},
+			},
+			{
+				UID:     "exam-1@alberta",
+				Summary: "آزمون",
+				Start:   time.Date(2026, time.October, 24, 0, 0, 0, 0, time.UTC),
+				End:     time.Date(2026, time.October, 25, 0, 0, 0, 0, time.UTC),
+				AllDay:  true,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf, stamp); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Alberta//Study Plan//FA",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		`X-WR-CALNAME:برنامه مطالعه\, سارا`,
+		"REFRESH-INTERVAL;VALUE=DURATION:PT90M",
+		"X-PUBLISHED-TTL:PT90M",
+		"BEGIN:VEVENT",
+		"UID:session-1@alberta",
+		"DTSTAMP:20261019T060000Z",
+		"DTSTART:20261019T043000Z",
+		"DTEND:20261019T061000Z",
+		"RRULE:FREQ=WEEKLY;COUNT=2",
+		"SUMMARY:ریاضی",
+		"DESCRIPTION:فصل ۱",
+		`CATEGORIES:مطالعه,a\,b`,
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:exam-1@alberta",
+		"DTSTAMP:20261019T060000Z",
+		"DTSTART;VALUE=DATE:20261024",
+		"DTEND;VALUE=DATE:20261025",
+		"SUMMARY:آزمون",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n") + "\r\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
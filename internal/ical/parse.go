@@ -0,0 +1,399 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCalendar = errors.New("invalid iCalendar data")
+
+// Recurrence is the part of an RRULE that matters for weekly timetables.
+type Recurrence struct {
+	Frequency string
+	// Interval is the number of days or weeks between repetitions, 1 when
+	// the rule does not set it.
+	Interval int
+	Days     []time.Weekday
+	// Until is the last time the rule repeats, or zero when it repeats
+	// forever.
+	Until time.Time
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Parse reads the VEVENTs of an iCalendar document. Times with a TZID that
+// cannot be loaded, and floating times, are read in defaultLoc. Sub-components
+// of events such as VALARM are skipped.
+func Parse(r io.Reader, defaultLoc *time.Location) ([]*Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*Event
+	var current *Event
+	var duration time.Duration
+	nested := 0
+
+	for i, line := range lines {
+		name, params, value, ok := splitContentLine(line)
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d has no value", ErrInvalidCalendar, i+1)
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &Event{}
+			duration = 0
+			continue
+		case name == "END" && value == "VEVENT":
+			if current == nil {
+				return nil, fmt.Errorf("%w: END:VEVENT without BEGIN", ErrInvalidCalendar)
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("%w: event %q has no DTSTART", ErrInvalidCalendar, current.Summary)
+			}
+			if current.End.IsZero() {
+				switch {
+				case duration > 0:
+					current.End = current.Start.Add(duration)
+				case current.AllDay:
+					current.End = current.Start.AddDate(0, 0, 1)
+				default:
+					current.End = current.Start
+				}
+			}
+			events = append(events, current)
+			current = nil
+			continue
+		case current == nil:
+			continue
+		case name == "BEGIN":
+			nested++
+			continue
+		case name == "END":
+			nested--
+			continue
+		case nested > 0:
+			continue
+		}
+
+		switch name {
+		case "UID":
+			current.UID = value
+		case "SUMMARY":
+			current.Summary = unescapeText(value)
+		case "DESCRIPTION":
+			current.Description = unescapeText(value)
+		case "RRULE":
+			current.RRule = value
+		case "EXDATE":
+			for _, date := range strings.Split(value, ",") {
+				exdate, _, err := parseDateTime(date, params, defaultLoc)
+				if err != nil {
+					return nil, err
+				}
+				current.ExDates = append(current.ExDates, exdate)
+			}
+		case "DTSTART":
+			current.Start, current.AllDay, err = parseDateTime(value, params, defaultLoc)
+			if err != nil {
+				return nil, err
+			}
+		case "DTEND":
+			current.End, _, err = parseDateTime(value, params, defaultLoc)
+			if err != nil {
+				return nil, err
+			}
+		case "DURATION":
+			duration, err = parseDuration(value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("%w: unterminated VEVENT", ErrInvalidCalendar)
+	}
+
+	return events, nil
+}
+
+// ParseRRule reads the frequency, interval, weekdays and end of a recurrence
+// rule. A weekly rule without BYDAY repeats on the weekday of start, and a
+// daily rule without BYDAY repeats on every day. The COUNT of a daily or
+// weekly rule becomes the Until of its last occurrence.
+func ParseRRule(rule string, start time.Time) (*Recurrence, error) {
+	recurrence := &Recurrence{Interval: 1}
+	count := 0
+
+	for _, part := range strings.Split(rule, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			recurrence.Frequency = strings.ToUpper(value)
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				// Strip ordinal prefixes such as the 1 of 1MO
+				code = strings.TrimLeft(strings.ToUpper(code), "+-0123456789")
+				day, ok := weekdayCodes[code]
+				if !ok {
+					return nil, fmt.Errorf("%w: unknown BYDAY value %q", ErrInvalidCalendar, code)
+				}
+				recurrence.Days = append(recurrence.Days, day)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("%w: invalid INTERVAL %q", ErrInvalidCalendar, value)
+			}
+			recurrence.Interval = interval
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: invalid COUNT %q", ErrInvalidCalendar, value)
+			}
+			count = n
+		case "UNTIL":
+			until, _, err := parseDateTime(value, nil, start.Location())
+			if err != nil {
+				return nil, err
+			}
+			recurrence.Until = until
+		}
+	}
+
+	if recurrence.Frequency == "" {
+		return nil, fmt.Errorf("%w: RRULE %q has no FREQ", ErrInvalidCalendar, rule)
+	}
+
+	if len(recurrence.Days) == 0 {
+		switch recurrence.Frequency {
+		case "WEEKLY":
+			recurrence.Days = []time.Weekday{start.Weekday()}
+		case "DAILY":
+			for day := time.Sunday; day <= time.Saturday; day++ {
+				recurrence.Days = append(recurrence.Days, day)
+			}
+		}
+	}
+
+	if count > 0 && recurrence.Until.IsZero() {
+		// DTSTART counts as the first occurrence even when the rule does not
+		// select its weekday
+		if !slices.Contains(recurrence.Days, start.Weekday()) {
+			count--
+		}
+		switch {
+		case count == 0:
+			recurrence.Until = start
+		case recurrence.Frequency == "DAILY":
+			recurrence.Until = lastDailyOccurrence(start, recurrence.Interval, recurrence.Days, count)
+		case recurrence.Frequency == "WEEKLY":
+			recurrence.Until = lastWeeklyOccurrence(start, recurrence.Interval, recurrence.Days, count)
+		}
+	}
+
+	return recurrence, nil
+}
+
+// lastDailyOccurrence returns the count-th occurrence of a rule that repeats
+// every interval days on the given weekdays, counting start as the first.
+// The weekdays of the repetitions cycle every seven of them.
+func lastDailyOccurrence(start time.Time, interval int, days []time.Weekday, count int) time.Time {
+	var steps []int
+	for step := 0; step < 7; step++ {
+		if slices.Contains(days, start.AddDate(0, 0, step*interval).Weekday()) {
+			steps = append(steps, step)
+		}
+	}
+	if len(steps) == 0 {
+		return start
+	}
+
+	n := count - 1
+	step := n/len(steps)*7 + steps[n%len(steps)]
+	return start.AddDate(0, 0, step*interval)
+}
+
+// lastWeeklyOccurrence returns the count-th occurrence of a rule that repeats
+// every interval weeks on the given weekdays, counting start as the first.
+// Weeks begin on Monday, the default WKST.
+func lastWeeklyOccurrence(start time.Time, interval int, days []time.Weekday, count int) time.Time {
+	sinceMonday := func(day time.Weekday) int { return (int(day) + 6) % 7 }
+
+	var offsets []int
+	for _, day := range days {
+		if offset := sinceMonday(day); !slices.Contains(offsets, offset) {
+			offsets = append(offsets, offset)
+		}
+	}
+	slices.Sort(offsets)
+
+	monday := start.AddDate(0, 0, -sinceMonday(start.Weekday()))
+	var first []int
+	for _, offset := range offsets {
+		if offset >= sinceMonday(start.Weekday()) {
+			first = append(first, offset)
+		}
+	}
+	if count <= len(first) {
+		return monday.AddDate(0, 0, first[count-1])
+	}
+
+	n := count - len(first) - 1
+	week := n/len(offsets) + 1
+	return monday.AddDate(0, 0, week*interval*7+offsets[n%len(offsets)])
+}
+
+// unfold joins folded content lines and drops empty ones.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, strings.TrimPrefix(line, "\ufeff"))
+	}
+
+	return lines, scanner.Err()
+}
+
+// splitContentLine splits "NAME;PARAM=VALUE:value" into its parts. Colons
+// inside quoted parameter values do not end the name.
+func splitContentLine(line string) (name string, params map[string]string, value string, ok bool) {
+	inQuotes := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		}
+		if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	name = strings.ToUpper(parts[0])
+	params = make(map[string]string)
+	for _, param := range parts[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+	}
+
+	return name, params, line[colon+1:], true
+}
+
+func parseDateTime(value string, params map[string]string, defaultLoc *time.Location) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, time.UTC)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("%w: bad date %q", ErrInvalidCalendar, value)
+		}
+		return t, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("%w: bad date-time %q", ErrInvalidCalendar, value)
+		}
+		return t, false, nil
+	}
+
+	loc := defaultLoc
+	if tzid := params["TZID"]; tzid != "" {
+		if tzLoc, err := time.LoadLocation(tzid); err == nil {
+			loc = tzLoc
+		}
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: bad date-time %q", ErrInvalidCalendar, value)
+	}
+	return t, false, nil
+}
+
+// parseDuration reads the day and time parts of an RFC 5545 duration such as
+// PT1H30M or P1D.
+func parseDuration(value string) (time.Duration, error) {
+	s := strings.TrimPrefix(strings.TrimPrefix(value, "+"), "P")
+	if s == value || strings.HasPrefix(value, "-") {
+		return 0, fmt.Errorf("%w: bad duration %q", ErrInvalidCalendar, value)
+	}
+
+	var d time.Duration
+	inTime := false
+	number := ""
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+			continue
+		case c == 'T':
+			inTime = true
+			continue
+		}
+
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, fmt.Errorf("%w: bad duration %q", ErrInvalidCalendar, value)
+		}
+		number = ""
+
+		switch {
+		case c == 'W':
+			d += time.Duration(n) * 7 * 24 * time.Hour
+		case c == 'D':
+			d += time.Duration(n) * 24 * time.Hour
+		case c == 'H' && inTime:
+			d += time.Duration(n) * time.Hour
+		case c == 'M' && inTime:
+			d += time.Duration(n) * time.Minute
+		case c == 'S' && inTime:
+			d += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("%w: bad duration %q", ErrInvalidCalendar, value)
+		}
+	}
+	if number != "" {
+		// a number without its unit
+		return 0, fmt.Errorf("%w: bad duration %q", ErrInvalidCalendar, value)
+	}
+
+	return d, nil
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
@@ -0,0 +1,254 @@
+package ical
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := strings.Join([]string{
+		"\ufeffBEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:math@school",
+		"SUMMARY:ریاضی\\, کلاس",
+		"  ۱",
+		"DTSTART;TZID=America/New_York:20261017T080000",
+		"DTEND;TZID=America/New_York:20261017T093000",
+		"RRULE:FREQ=WEEKLY;BYDAY=SA,MO",
+		"EXDATE;TZID=America/New_York:20261019T080000,20261024T080000",
+		"BEGIN:VALARM",
+		"DESCRIPTION:reminder",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Trip",
+		"DTSTART;VALUE=DATE:20261020",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Lab",
+		"DTSTART:20261021T103000Z",
+		"DURATION:PT1H30M",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Floating",
+		"DESCRIPTION:line one\\nline two",
+		"DTSTART;TZID=Mars/Olympus:20261022T140000",
+		"DTEND:20261022T150000",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, err := Parse(strings.NewReader(doc), tehran)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4", len(events))
+	}
+
+	math := events[0]
+	if math.UID != "math@school" || math.Summary != "ریاضی, کلاس ۱" {
+		t.Errorf("got UID %q and summary %q", math.UID, math.Summary)
+	}
+	if want := time.Date(2026, time.October, 17, 8, 0, 0, 0, newYork); !math.Start.Equal(want) || math.Start.Location().String() != "America/New_York" {
+		t.Errorf("start %s, want %s in its TZID", math.Start, want)
+	}
+	if math.End.Sub(math.Start) != 90*time.Minute || math.AllDay {
+		t.Errorf("got %s to %s, all day %v", math.Start, math.End, math.AllDay)
+	}
+	if math.RRule != "FREQ=WEEKLY;BYDAY=SA,MO" {
+		t.Errorf("RRULE %q", math.RRule)
+	}
+	wantExDates := []time.Time{
+		time.Date(2026, time.October, 19, 8, 0, 0, 0, newYork),
+		time.Date(2026, time.October, 24, 8, 0, 0, 0, newYork),
+	}
+	if !slices.EqualFunc(math.ExDates, wantExDates, time.Time.Equal) {
+		t.Errorf("EXDATEs %v, want %v", math.ExDates, wantExDates)
+	}
+	if math.Description != "" {
+		t.Errorf("the alarm's description %q was read as the event's", math.Description)
+	}
+
+	trip := events[1]
+	if !trip.AllDay || !trip.Start.Equal(time.Date(2026, time.October, 20, 0, 0, 0, 0, time.UTC)) ||
+		!trip.End.Equal(time.Date(2026, time.October, 21, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("all-day event from %s to %s, all day %v; want one UTC date", trip.Start, trip.End, trip.AllDay)
+	}
+
+	lab := events[2]
+	if want := time.Date(2026, time.October, 21, 12, 0, 0, 0, time.UTC); !lab.End.Equal(want) {
+		t.Errorf("end from DURATION %s, want %s", lab.End, want)
+	}
+
+	floating := events[3]
+	if floating.Start.Location().String() != "Asia/Tehran" || floating.Start.Hour() != 14 {
+		t.Errorf("unknown TZID: start %s, want 14:00 in the default location", floating.Start)
+	}
+	if floating.Description != "line one\nline two" {
+		t.Errorf("description %q", floating.Description)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"no DTSTART":        "BEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT",
+		"unterminated":      "BEGIN:VEVENT\nDTSTART:20261017T080000Z",
+		"END without BEGIN": "END:VEVENT",
+		"no value":          "BEGIN:VEVENT\nSUMMARY",
+		"bad date":          "BEGIN:VEVENT\nDTSTART;VALUE=DATE:2026-10-17\nEND:VEVENT",
+		"bad date-time":     "BEGIN:VEVENT\nDTSTART:20261017T25Z\nEND:VEVENT",
+		"bad duration":      "BEGIN:VEVENT\nDTSTART:20261017T080000Z\nDURATION:1H\nEND:VEVENT",
+		"bad EXDATE":        "BEGIN:VEVENT\nDTSTART:20261017T080000Z\nEXDATE:tomorrow\nEND:VEVENT",
+	}
+	for name, doc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(doc), time.UTC); !errors.Is(err, ErrInvalidCalendar) {
+				t.Errorf("got %v, want ErrInvalidCalendar", err)
+			}
+		})
+	}
+}
+
+func TestParseRRule(t *testing.T) {
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a Monday
+	start := time.Date(2026, time.October, 19, 8, 0, 0, 0, tehran)
+	everyDay := []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+
+	tests := []struct {
+		rule      string
+		frequency string
+		days      []time.Weekday
+		until     time.Time
+	}{
+		{"FREQ=WEEKLY", "WEEKLY", []time.Weekday{time.Monday}, time.Time{}},
+		{"FREQ=WEEKLY;BYDAY=SA,TU", "WEEKLY", []time.Weekday{time.Saturday, time.Tuesday}, time.Time{}},
+		{"freq=weekly;byday=1mo,-1fr", "WEEKLY", []time.Weekday{time.Monday, time.Friday}, time.Time{}},
+		{"FREQ=DAILY", "DAILY", everyDay, time.Time{}},
+		{"FREQ=WEEKLY;UNTIL=20261231T203000Z", "WEEKLY", []time.Weekday{time.Monday}, time.Date(2026, time.December, 31, 20, 30, 0, 0, time.UTC)},
+		{"FREQ=WEEKLY;UNTIL=20261231", "WEEKLY", []time.Weekday{time.Monday}, time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC)},
+		{"FREQ=MONTHLY;BYMONTHDAY=1", "MONTHLY", nil, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			recurrence, err := ParseRRule(tt.rule, start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recurrence.Frequency != tt.frequency || !slices.Equal(recurrence.Days, tt.days) || !recurrence.Until.Equal(tt.until) {
+				t.Errorf("got %+v, want %s on %v until %s", recurrence, tt.frequency, tt.days, tt.until)
+			}
+		})
+	}
+
+	for _, rule := range []string{"BYDAY=MO", "FREQ=WEEKLY;BYDAY=XX", "FREQ=WEEKLY;UNTIL=soon", "FREQ=WEEKLY;INTERVAL=0", "FREQ=DAILY;COUNT=x"} {
+		if _, err := ParseRRule(rule, start); !errors.Is(err, ErrInvalidCalendar) {
+			t.Errorf("ParseRRule(%q): got %v, want ErrInvalidCalendar", rule, err)
+		}
+	}
+}
+
+func TestUnfold(t *testing.T) {
+	doc := "\ufeffBEGIN:VCALENDAR\r\nSUMMARY:فیز\r\n یک\r\n\tدو\r\n\r\nDESCRIPTION: starts with a space\nEND:VCALENDAR"
+
+	lines, err := unfold(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"BEGIN:VCALENDAR",
+		"SUMMARY:فیزیکدو",
+		"DESCRIPTION: starts with a space",
+		"END:VCALENDAR",
+	}
+	if !slices.Equal(lines, want) {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"PT1H30M", 90 * time.Minute},
+		{"PT45M", 45 * time.Minute},
+		{"PT90S", 90 * time.Second},
+		{"P1D", 24 * time.Hour},
+		{"P1DT2H", 26 * time.Hour},
+		{"+P2W", 14 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := parseDuration(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("parseDuration(%q) = %s, %v; want %s", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "1H", "-PT1H", "PT1X", "P1H", "PTH", "PT1H30"} {
+		if _, err := parseDuration(value); !errors.Is(err, ErrInvalidCalendar) {
+			t.Errorf("parseDuration(%q): got %v, want ErrInvalidCalendar", value, err)
+		}
+	}
+}
+
+func TestParseRRuleIntervalAndCount(t *testing.T) {
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a Monday
+	start := time.Date(2026, time.October, 19, 8, 0, 0, 0, tehran)
+	on := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 8, 0, 0, 0, tehran)
+	}
+
+	tests := []struct {
+		rule     string
+		interval int
+		until    time.Time
+	}{
+		{"FREQ=WEEKLY", 1, time.Time{}},
+		{"FREQ=WEEKLY;INTERVAL=2", 2, time.Time{}},
+		{"FREQ=WEEKLY;COUNT=1", 1, start},
+		{"FREQ=WEEKLY;COUNT=3", 1, on(time.November, 2)},
+		// Mondays and Wednesdays of every other week: 19, 21, 2, 4, 16
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=5", 2, on(time.November, 16)},
+		// The Monday start comes first, then Tuesdays and Saturdays: 20, 24
+		{"FREQ=WEEKLY;BYDAY=TU,SA;COUNT=3", 1, on(time.October, 24)},
+		{"FREQ=DAILY;COUNT=10", 1, on(time.October, 28)},
+		// Every third day that is a Monday or Tuesday: 19, 3, 9
+		{"FREQ=DAILY;INTERVAL=3;BYDAY=MO,TU;COUNT=3", 3, on(time.November, 9)},
+		{"FREQ=WEEKLY;COUNT=3;UNTIL=20261231T203000Z", 1, time.Date(2026, time.December, 31, 20, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			recurrence, err := ParseRRule(tt.rule, start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recurrence.Interval != tt.interval || !recurrence.Until.Equal(tt.until) {
+				t.Errorf("got every %d until %s, want every %d until %s", recurrence.Interval, recurrence.Until, tt.interval, tt.until)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Generator derives schemas from Go types the way encoding/json marshals
+// them. Named struct types become component schemas that are referred to by
+// name; everything else is described inline.
+type Generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Schemas returns the component schemas of every named struct type seen so
+// far.
+func (g *Generator) Schemas() map[string]*Schema {
+	return g.schemas
+}
+
+// SchemaOf returns the schema of the type of v.
+func (g *Generator) SchemaOf(v any) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return g.Schema(reflect.TypeOf(v))
+}
+
+// Schema returns the schema of t.
+func (g *Generator) Schema(t reflect.Type) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t.Kind() != reflect.Pointer {
+		switch {
+		case t.Implements(jsonMarshalerType):
+			return &Schema{}
+		case t.Implements(textMarshalerType):
+			return &Schema{Type: "string"}
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.Schema(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.component(t)
+	default:
+		// Interfaces and anything else encoding/json does not describe by
+		// type.
+		return &Schema{}
+	}
+}
+
+// component registers the named struct type t and returns a reference to it.
+func (g *Generator) component(t reflect.Type) *Schema {
+	if name, ok := g.names[t]; ok {
+		return Ref(name)
+	}
+
+	name := t.Name()
+	if _, taken := g.schemas[name]; taken {
+		pkg := []rune(path.Base(t.PkgPath()))
+		pkg[0] = unicode.ToUpper(pkg[0])
+		name = string(pkg) + name
+	}
+
+	// Register the name first so that recursive types refer to themselves
+	g.names[t] = name
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.structSchema(t)
+	return Ref(name)
+}
+
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(s, t)
+	return s
+}
+
+// addFields adds the fields of struct type t to s, following the encoding/json
+// rules for names and embedded structs.
+func (g *Generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(s, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fs := g.Schema(field.Type)
+		if rules := field.Tag.Get("validate"); rules != "" {
+			if applyValidate(fs, field.Type, rules) {
+				s.Required = append(s.Required, name)
+			}
+		}
+		s.Properties[name] = fs
+	}
+}
+
+// applyValidate adds the constraints of a validate tag to the schema of a
+// field of type t, and reports whether the tag makes the field required.
+// Rules after dive apply to the elements of a slice or map.
+func applyValidate(s *Schema, t reflect.Type, rules string) bool {
+	if s.Ref == "" {
+		s.Validate = rules
+	}
+
+	required := false
+	target, targetType := s, t
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "required" && target == s {
+			required = true
+		}
+		if name == "dive" {
+			for targetType.Kind() == reflect.Pointer {
+				targetType = targetType.Elem()
+			}
+			if target.Items != nil {
+				target, targetType = target.Items, targetType.Elem()
+			} else if target.AdditionalProperties != nil {
+				target, targetType = target.AdditionalProperties, targetType.Elem()
+			}
+			continue
+		}
+		if target.Ref == "" {
+			constrain(target, targetType, name, param)
+		}
+	}
+	return required
+}
+
+// constrain applies a single validate rule to s.
+func constrain(s *Schema, t reflect.Type, rule, param string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch rule {
+	case "email":
+		s.Format = "email"
+		return
+	case "timezone":
+		s.Format = "timezone"
+		return
+	case "oneof":
+		s.Enum = strings.Fields(param)
+		return
+	}
+
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	count := int(n)
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		switch rule {
+		case "gt":
+			s.Minimum, s.ExclusiveMinimum = &n, true
+		case "gte", "min":
+			s.Minimum = &n
+		case "lt":
+			s.Maximum, s.ExclusiveMaximum = &n, true
+		case "lte", "max":
+			s.Maximum = &n
+		case "eq", "len":
+			s.Minimum, s.Maximum = &n, &n
+		}
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		var lower, upper *int
+		switch rule {
+		case "gt":
+			lower = intPtr(count + 1)
+		case "gte", "min":
+			lower = intPtr(count)
+		case "lt":
+			upper = intPtr(count - 1)
+		case "lte", "max":
+			upper = intPtr(count)
+		case "len":
+			lower, upper = intPtr(count), intPtr(count)
+		}
+		switch t.Kind() {
+		case reflect.String:
+			s.MinLength, s.MaxLength = pick(lower, s.MinLength), pick(upper, s.MaxLength)
+		case reflect.Map:
+			s.MinProperties, s.MaxProperties = pick(lower, s.MinProperties), pick(upper, s.MaxProperties)
+		default:
+			s.MinItems, s.MaxItems = pick(lower, s.MinItems), pick(upper, s.MaxItems)
+		}
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func pick(v, fallback *int) *int {
+	if v != nil {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,280 @@
+// Package pdf writes simple PDF documents of filled rectangles, lines and
+// text in a single embedded TrueType font.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// A4 landscape page size in points.
+const (
+	A4LandscapeWidth  = 841.89
+	A4LandscapeHeight = 595.28
+)
+
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// Color is an RGB color with components between 0 and 1.
+type Color struct {
+	R, G, B float64
+}
+
+func RGB(r, g, b uint8) Color {
+	return Color{float64(r) / 255, float64(g) / 255, float64(b) / 255}
+}
+
+type Document struct {
+	font  *Font
+	pages []*Page
+	// used maps each drawn glyph to the character it was drawn for, so that
+	// text can be copied out of the document.
+	used map[uint16]rune
+}
+
+// Page is one page of a document. Coordinates are in points from the
+// top-left corner of the page.
+type Page struct {
+	doc     *Document
+	width   float64
+	height  float64
+	content bytes.Buffer
+}
+
+func New(font *Font) *Document {
+	return &Document{font: font, used: make(map[uint16]rune)}
+}
+
+func (d *Document) AddPage(width, height float64) *Page {
+	p := &Page{doc: d, width: width, height: height}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// TextWidth returns the width of s drawn at size.
+func (d *Document) TextWidth(s string, size float64) float64 {
+	var width float64
+	for _, r := range shape([]rune(s)) {
+		width += d.font.width(d.font.glyph(r))
+	}
+	return width * size / 1000
+}
+
+func (p *Page) FillRect(x, y, w, h float64, c Color) {
+	fmt.Fprintf(&p.content, "%s rg %s %s %s %s re f\n", colorOps(c), num(x), num(p.height-y-h), num(w), num(h))
+}
+
+func (p *Page) StrokeRect(x, y, w, h, lineWidth float64, c Color) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s %s %s re S\n", colorOps(c), num(lineWidth), num(x), num(p.height-y-h), num(w), num(h))
+}
+
+func (p *Page) Line(x1, y1, x2, y2, lineWidth float64, c Color) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s m %s %s l S\n", colorOps(c), num(lineWidth), num(x1), num(p.height-y1), num(x2), num(p.height-y2))
+}
+
+// Text draws s with its baseline at y. x is the left edge, centre or right
+// edge of the text depending on align. Right-to-left text is shaped and
+// reordered before it is drawn.
+func (p *Page) Text(x, y, size float64, c Color, align Align, s string) {
+	font := p.doc.font
+	shaped := shape([]rune(s))
+
+	var hex bytes.Buffer
+	var width float64
+	for _, r := range visualOrder(shaped) {
+		g := font.glyph(r)
+		if _, ok := p.doc.used[g]; !ok && g != 0 {
+			p.doc.used[g] = r
+		}
+		width += font.width(g)
+		fmt.Fprintf(&hex, "%04X", g)
+	}
+	width = width * size / 1000
+
+	switch align {
+	case AlignCenter:
+		x -= width / 2
+	case AlignRight:
+		x -= width
+	}
+
+	fmt.Fprintf(&p.content, "BT %s rg /F1 %s Tf %s %s Td <%s> Tj ET\n", colorOps(c), num(size), num(x), num(p.height-y), hex.String())
+}
+
+// Write writes the document to w.
+func (d *Document) Write(w io.Writer) error {
+	out := &objectWriter{w: bufio.NewWriter(w)}
+	out.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-7 are the catalog, the page tree and the font; each page
+	// is then followed by its content stream.
+	const firstPage = 8
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	out.object("<< /Type /Catalog /Pages 2 0 R >>")
+	out.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	font := d.font
+	out.object(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [4 0 R] /ToUnicode 7 0 R >>", font.name))
+	out.object(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor 5 0 R /W %s /CIDToGIDMap /Identity >>", font.name, d.widths()))
+	out.object(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 6 0 R >>",
+		font.name, font.scale(font.bbox[0]), font.scale(font.bbox[1]), font.scale(font.bbox[2]), font.scale(font.bbox[3]),
+		font.scale(font.ascent), font.scale(font.descent), font.scale(font.capHeight)))
+	if err := out.stream(fmt.Sprintf("/Length1 %d", len(font.data)), font.data); err != nil {
+		return err
+	}
+	if err := out.stream("", d.toUnicode()); err != nil {
+		return err
+	}
+
+	for i, p := range d.pages {
+		out.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			num(p.width), num(p.height), firstPage+2*i+1))
+		if err := out.stream("", p.content.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	out.trailer()
+	return out.err()
+}
+
+// widths returns the W array of the glyphs that were drawn.
+func (d *Document) widths() string {
+	glyphs := d.usedGlyphs()
+	var b bytes.Buffer
+	b.WriteString("[")
+	for _, g := range glyphs {
+		fmt.Fprintf(&b, " %d [%s]", g, num(d.font.width(g)))
+	}
+	b.WriteString(" ]")
+	return b.String()
+}
+
+// toUnicode returns a CMap from the drawn glyphs back to characters.
+func (d *Document) toUnicode() []byte {
+	glyphs := d.usedGlyphs()
+
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(glyphs); start += 100 {
+		end := min(start+100, len(glyphs))
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, g := range glyphs[start:end] {
+			fmt.Fprintf(&b, "<%04X> <", g)
+			for _, unit := range utf16.Encode([]rune{d.used[g]}) {
+				fmt.Fprintf(&b, "%04X", unit)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+func (d *Document) usedGlyphs() []uint16 {
+	glyphs := make([]uint16, 0, len(d.used))
+	for g := range d.used {
+		glyphs = append(glyphs, g)
+	}
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i] < glyphs[j] })
+	return glyphs
+}
+
+// objectWriter numbers objects from 1 in the order they are written and
+// records their offsets for the cross-reference table. The first write error
+// is kept and later writes are dropped.
+type objectWriter struct {
+	w       *bufio.Writer
+	offset  int
+	offsets []int
+	failed  error
+}
+
+func (o *objectWriter) printf(format string, args ...any) {
+	if o.failed != nil {
+		return
+	}
+	n, err := fmt.Fprintf(o.w, format, args...)
+	o.offset += n
+	o.failed = err
+}
+
+func (o *objectWriter) write(b []byte) {
+	if o.failed != nil {
+		return
+	}
+	n, err := o.w.Write(b)
+	o.offset += n
+	o.failed = err
+}
+
+func (o *objectWriter) object(body string) {
+	o.offsets = append(o.offsets, o.offset)
+	o.printf("%d 0 obj\n%s\nendobj\n", len(o.offsets), body)
+}
+
+// stream writes data compressed, with extra entries added to its dictionary.
+func (o *objectWriter) stream(extra string, data []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	o.offsets = append(o.offsets, o.offset)
+	o.printf("%d 0 obj\n<< /Length %d /Filter /FlateDecode %s>>\nstream\n", len(o.offsets), compressed.Len(), extra)
+	o.write(compressed.Bytes())
+	o.printf("\nendstream\nendobj\n")
+	return nil
+}
+
+func (o *objectWriter) trailer() {
+	xref := o.offset
+	o.printf("xref\n0 %d\n0000000000 65535 f \n", len(o.offsets)+1)
+	for _, offset := range o.offsets {
+		o.printf("%010d 00000 n \n", offset)
+	}
+	o.printf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(o.offsets)+1, xref)
+}
+
+func (o *objectWriter) err() error {
+	if o.failed != nil {
+		return o.failed
+	}
+	return o.w.Flush()
+}
+
+func colorOps(c Color) string {
+	return num(c.R) + " " + num(c.G) + " " + num(c.B)
+}
+
+// num formats a number the way PDF expects it, without exponents.
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 3, 64)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
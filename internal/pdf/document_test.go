@@ -0,0 +1,70 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDocumentEmbedsDrawnGlyphs(t *testing.T) {
+	font, err := ParseFont(testFont(t, 6, map[rune]uint16{0xFE90: 1, 0xFE91: 2, 'A': 3, 0x0628: 4}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := New(font)
+	page := doc.AddPage(300, 200)
+	// The question mark has no glyph and draws .notdef, which is left out
+	// of the widths and the ToUnicode CMap
+	page.Text(100, 20, 10, RGB(0, 0, 0), AlignRight, "بب?")
+
+	want := "BT 0 0 0 rg /F1 10 Tf 97 180 Td <000000010002> Tj ET\n"
+	if got := page.content.String(); got != want {
+		t.Errorf("content = %q; want %q", got, want)
+	}
+
+	if got, want := doc.widths(), "[ 1 [100] 2 [150] ]"; got != want {
+		t.Errorf("widths = %q; want %q", got, want)
+	}
+
+	toUnicode := string(doc.toUnicode())
+	if !strings.Contains(toUnicode, "2 beginbfchar\n<0001> <FE90>\n<0002> <FE91>\nendbfchar\n") {
+		t.Errorf("ToUnicode CMap does not map exactly the drawn glyphs:\n%s", toUnicode)
+	}
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, part := range []string{
+		"%PDF-1.4\n",
+		"/BaseFont /TestFont",
+		"/W [ 1 [100] 2 [150] ]",
+		"/FontBBox [-50 -250 950 900] /ItalicAngle 0 /Ascent 800 /Descent -200",
+		"/Count 1",
+		"startxref\n",
+	} {
+		if !strings.Contains(out, part) {
+			t.Errorf("document does not contain %q", part)
+		}
+	}
+	if !strings.HasSuffix(out, "%%EOF\n") {
+		t.Error("document does not end with the EOF marker")
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	font, err := ParseFont(testFont(t, 4, map[rune]uint16{'A': 1, 'B': 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := New(font)
+
+	if got := doc.TextWidth("AB", 20); got != 6 {
+		t.Errorf("TextWidth = %v; want 6", got)
+	}
+	if len(doc.used) != 0 {
+		t.Errorf("TextWidth marked glyphs as used: %v", doc.used)
+	}
+}
@@ -0,0 +1,255 @@
+package pdf
+
+import "unicode"
+
+// Text is drawn glyph by glyph, so Arabic script has to be shaped into its
+// contextual presentation forms and put into visual order before layout.
+// This covers the Arabic and Persian alphabets and treats each text as a
+// right-to-left paragraph, which is all the planner needs.
+
+// arabicForms holds the isolated, final, initial and medial forms of each
+// letter. Letters that only join to the previous letter have no initial or
+// medial form.
+var arabicForms = map[rune][4]rune{
+	0x0621: {0xFE80, 0, 0, 0},
+	0x0622: {0xFE81, 0xFE82, 0, 0},
+	0x0623: {0xFE83, 0xFE84, 0, 0},
+	0x0624: {0xFE85, 0xFE86, 0, 0},
+	0x0625: {0xFE87, 0xFE88, 0, 0},
+	0x0626: {0xFE89, 0xFE8A, 0xFE8B, 0xFE8C},
+	0x0627: {0xFE8D, 0xFE8E, 0, 0},
+	0x0628: {0xFE8F, 0xFE90, 0xFE91, 0xFE92},
+	0x0629: {0xFE93, 0xFE94, 0, 0},
+	0x062A: {0xFE95, 0xFE96, 0xFE97, 0xFE98},
+	0x062B: {0xFE99, 0xFE9A, 0xFE9B, 0xFE9C},
+	0x062C: {0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0},
+	0x062D: {0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4},
+	0x062E: {0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8},
+	0x062F: {0xFEA9, 0xFEAA, 0, 0},
+	0x0630: {0xFEAB, 0xFEAC, 0, 0},
+	0x0631: {0xFEAD, 0xFEAE, 0, 0},
+	0x0632: {0xFEAF, 0xFEB0, 0, 0},
+	0x0633: {0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4},
+	0x0634: {0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8},
+	0x0635: {0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC},
+	0x0636: {0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0},
+	0x0637: {0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4},
+	0x0638: {0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8},
+	0x0639: {0xFEC9, 0xFECA, 0xFECB, 0xFECC},
+	0x063A: {0xFECD, 0xFECE, 0xFECF, 0xFED0},
+	0x0640: {0x0640, 0x0640, 0x0640, 0x0640},
+	0x0641: {0xFED1, 0xFED2, 0xFED3, 0xFED4},
+	0x0642: {0xFED5, 0xFED6, 0xFED7, 0xFED8},
+	0x0643: {0xFED9, 0xFEDA, 0xFEDB, 0xFEDC},
+	0x0644: {0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0},
+	0x0645: {0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4},
+	0x0646: {0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8},
+	0x0647: {0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC},
+	0x0648: {0xFEED, 0xFEEE, 0, 0},
+	0x0649: {0xFEEF, 0xFEF0, 0, 0},
+	0x064A: {0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4},
+	0x067E: {0xFB56, 0xFB57, 0xFB58, 0xFB59},
+	0x0686: {0xFB7A, 0xFB7B, 0xFB7C, 0xFB7D},
+	0x0698: {0xFB8A, 0xFB8B, 0, 0},
+	0x06A9: {0xFB8E, 0xFB8F, 0xFB90, 0xFB91},
+	0x06AF: {0xFB92, 0xFB93, 0xFB94, 0xFB95},
+	0x06CC: {0xFBFC, 0xFBFD, 0xFBFE, 0xFBFF},
+}
+
+// lamAlef holds the isolated and final forms of the ligature of lam with
+// each kind of alef.
+var lamAlef = map[rune][2]rune{
+	0x0622: {0xFEF5, 0xFEF6},
+	0x0623: {0xFEF7, 0xFEF8},
+	0x0625: {0xFEF9, 0xFEFA},
+	0x0627: {0xFEFB, 0xFEFC},
+}
+
+const (
+	zeroWidthNonJoiner = 0x200C
+	zeroWidthJoiner    = 0x200D
+	lam                = 0x0644
+)
+
+var mirrored = map[rune]rune{
+	'(': ')', ')': '(',
+	'[': ']', ']': '[',
+	'{': '}', '}': '{',
+	'<': '>', '>': '<',
+	'«': '»', '»': '«',
+}
+
+// transparent reports whether r is a combining mark that joining skips over.
+func transparent(r rune) bool {
+	return unicode.Is(unicode.Mn, r)
+}
+
+// joinsBefore reports whether r connects to the letter that follows it.
+func joinsBefore(r rune) bool {
+	if r == zeroWidthJoiner {
+		return true
+	}
+	forms, ok := arabicForms[r]
+	return ok && forms[2] != 0
+}
+
+// joinsAfter reports whether r connects to the letter that precedes it.
+func joinsAfter(r rune) bool {
+	if r == zeroWidthJoiner {
+		return true
+	}
+	forms, ok := arabicForms[r]
+	return ok && forms[1] != 0
+}
+
+// shape replaces Arabic letters with their contextual forms, in logical order.
+func shape(text []rune) []rune {
+	neighbour := func(i, step int) rune {
+		for j := i + step; j >= 0 && j < len(text); j += step {
+			if !transparent(text[j]) {
+				return text[j]
+			}
+		}
+		return 0
+	}
+
+	shaped := make([]rune, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		r := text[i]
+		forms, ok := arabicForms[r]
+		if !ok {
+			if r != zeroWidthNonJoiner && r != zeroWidthJoiner {
+				shaped = append(shaped, r)
+			}
+			continue
+		}
+
+		prev, next := neighbour(i, -1), neighbour(i, 1)
+		joinPrev := joinsBefore(prev) && joinsAfter(r)
+
+		if r == lam {
+			if ligature, ok := lamAlef[next]; ok {
+				if joinPrev {
+					shaped = append(shaped, ligature[1])
+				} else {
+					shaped = append(shaped, ligature[0])
+				}
+				// Skip the alef, keeping any marks between the two letters
+				for i++; i < len(text) && transparent(text[i]); i++ {
+					shaped = append(shaped, text[i])
+				}
+				continue
+			}
+		}
+
+		joinNext := joinsBefore(r) && joinsAfter(next)
+		var form rune
+		switch {
+		case joinPrev && joinNext:
+			form = forms[3]
+		case joinPrev:
+			form = forms[1]
+		case joinNext:
+			form = forms[2]
+		default:
+			form = forms[0]
+		}
+		if form == 0 {
+			form = r
+		}
+		shaped = append(shaped, form)
+	}
+
+	return shaped
+}
+
+type direction int
+
+const (
+	neutral direction = iota
+	leftToRight
+	rightToLeft
+)
+
+func directionOf(r rune) direction {
+	switch {
+	case unicode.IsDigit(r):
+		return leftToRight
+	case r >= 0x0590 && r <= 0x08FF, r >= 0xFB1D && r <= 0xFDFF, r >= 0xFE70 && r <= 0xFEFF:
+		return rightToLeft
+	case unicode.IsLetter(r):
+		return leftToRight
+	default:
+		return neutral
+	}
+}
+
+// visualOrder reorders a right-to-left paragraph for drawing from left to
+// right. Runs of left-to-right text, including numbers, keep their order;
+// neutral characters take the direction of the text around them, or the
+// paragraph's when that is mixed. Text without any right-to-left letters is
+// left as it is.
+func visualOrder(text []rune) []rune {
+	dirs := make([]direction, len(text))
+	hasRightToLeft := false
+	for i, r := range text {
+		dirs[i] = directionOf(r)
+		if transparent(r) && i > 0 {
+			dirs[i] = dirs[i-1]
+		}
+		hasRightToLeft = hasRightToLeft || dirs[i] == rightToLeft
+	}
+	if !hasRightToLeft {
+		return text
+	}
+
+	for i := 0; i < len(dirs); {
+		if dirs[i] != neutral {
+			i++
+			continue
+		}
+		j := i
+		for j < len(dirs) && dirs[j] == neutral {
+			j++
+		}
+		resolved := rightToLeft
+		if i > 0 && j < len(dirs) && dirs[i-1] == leftToRight && dirs[j] == leftToRight {
+			resolved = leftToRight
+		}
+		for k := i; k < j; k++ {
+			dirs[k] = resolved
+		}
+		i = j
+	}
+
+	// Runs are laid out from right to left. Within right-to-left runs
+	// each letter is kept ahead of the marks that belong to it.
+	visual := make([]rune, 0, len(text))
+	for end := len(text); end > 0; {
+		start := end - 1
+		for start > 0 && dirs[start-1] == dirs[end-1] {
+			start--
+		}
+
+		if dirs[start] == leftToRight {
+			visual = append(visual, text[start:end]...)
+		} else {
+			for clusterEnd := end; clusterEnd > start; {
+				clusterStart := clusterEnd - 1
+				for clusterStart > start && transparent(text[clusterStart]) {
+					clusterStart--
+				}
+				for _, r := range text[clusterStart:clusterEnd] {
+					if m, ok := mirrored[r]; ok {
+						r = m
+					}
+					visual = append(visual, r)
+				}
+				clusterEnd = clusterStart
+			}
+		}
+		end = start
+	}
+
+	return visual
+}
@@ -0,0 +1,63 @@
+package pdf
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestShape(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []rune
+	}{
+		{"isolated", "ب", []rune{0xFE8F}},
+		{"initial and final", "بب", []rune{0xFE91, 0xFE90}},
+		{"medial", "ببب", []rune{0xFE91, 0xFE92, 0xFE90}},
+		{"right-joining letter ends the join", "بدب", []rune{0xFE91, 0xFEAA, 0xFE8F}},
+		{"right-joining letter at the start", "دب", []rune{0xFEA9, 0xFE8F}},
+		{"marks are skipped", "بَب", []rune{0xFE91, 0x064E, 0xFE90}},
+		{"lam-alef isolated", "لا", []rune{0xFEFB}},
+		{"lam-alef final", "بلا", []rune{0xFE91, 0xFEFC}},
+		{"lam-alef with madda", "لآ", []rune{0xFEF5}},
+		{"lam-alef keeps marks", "لَا", []rune{0xFEFB, 0x064E}},
+		{"lam without alef", "لب", []rune{0xFEDF, 0xFE90}},
+		{"zero width non-joiner breaks the join", "می\u200cشود", []rune{0xFEE3, 0xFBFD, 0xFEB7, 0xFEEE, 0xFEA9}},
+		{"zero width joiner joins", "ب\u200d", []rune{0xFE91}},
+		{"latin is untouched", "Math 2", []rune("Math 2")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shape([]rune(tt.text))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("shape(%q) = %U; want %U", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVisualOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"left-to-right text is untouched", "Week (2)", "Week (2)"},
+		{"right-to-left text is reversed", "فصل", "لصف"},
+		{"numbers keep their order", "فصل ۱۲", "۱۲ لصف"},
+		{"latin runs keep their order", "درس Math 2", "Math 2 سرد"},
+		{"brackets are mirrored", "(فصل)", "(لصف)"},
+		{"numbers in brackets", "فصل (۱۲)", "(۱۲) لصف"},
+		{"guillemets are mirrored", "«درس»", "«سرد»"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(visualOrder([]rune(tt.text)))
+			if got != tt.want {
+				t.Errorf("visualOrder(%q) = %q; want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+var ErrInvalidFont = errors.New("invalid TrueType font")
+
+// Font is a TrueType font embedded whole into the documents that use it.
+type Font struct {
+	name       string
+	data       []byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	capHeight  int
+	bbox       [4]int
+	advances   []uint16
+	cmap       map[rune]uint16
+}
+
+// LoadFont reads a TrueType font file.
+func LoadFont(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFont(data)
+}
+
+// ParseFont reads the tables of a TrueType font that are needed to lay out
+// text and embed it. Fonts with CFF outlines are not supported.
+func ParseFont(data []byte) (*Font, error) {
+	if len(data) < 12 {
+		return nil, ErrInvalidFont
+	}
+	switch string(data[:4]) {
+	case "\x00\x01\x00\x00", "true":
+	case "OTTO":
+		return nil, fmt.Errorf("%w: CFF outlines are not supported", ErrInvalidFont)
+	default:
+		return nil, ErrInvalidFont
+	}
+
+	tables := make(map[string][]byte)
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		entry := 12 + 16*i
+		if entry+16 > len(data) {
+			return nil, ErrInvalidFont
+		}
+		offset := int(binary.BigEndian.Uint32(data[entry+8:]))
+		length := int(binary.BigEndian.Uint32(data[entry+12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, fmt.Errorf("%w: table %q is out of range", ErrInvalidFont, data[entry:entry+4])
+		}
+		tables[string(data[entry:entry+4])] = data[offset : offset+length]
+	}
+
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "cmap", "glyf"} {
+		if _, ok := tables[tag]; !ok {
+			return nil, fmt.Errorf("%w: missing %s table", ErrInvalidFont, tag)
+		}
+	}
+
+	f := &Font{name: "Font", data: data}
+
+	head := tables["head"]
+	if len(head) < 54 {
+		return nil, fmt.Errorf("%w: short head table", ErrInvalidFont)
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return nil, fmt.Errorf("%w: zero unitsPerEm", ErrInvalidFont)
+	}
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+
+	hhea := tables["hhea"]
+	if len(hhea) < 36 {
+		return nil, fmt.Errorf("%w: short hhea table", ErrInvalidFont)
+	}
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	f.capHeight = f.ascent
+	numberOfHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+
+	maxp := tables["maxp"]
+	if len(maxp) < 6 {
+		return nil, fmt.Errorf("%w: short maxp table", ErrInvalidFont)
+	}
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+
+	hmtx := tables["hmtx"]
+	if numberOfHMetrics == 0 || numberOfHMetrics > numGlyphs || len(hmtx) < 4*numberOfHMetrics {
+		return nil, fmt.Errorf("%w: bad hmtx table", ErrInvalidFont)
+	}
+	f.advances = make([]uint16, numGlyphs)
+	for i := range f.advances {
+		if i < numberOfHMetrics {
+			f.advances[i] = binary.BigEndian.Uint16(hmtx[4*i:])
+		} else {
+			f.advances[i] = f.advances[numberOfHMetrics-1]
+		}
+	}
+
+	if os2 := tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+
+	if name := postScriptName(tables["name"]); name != "" {
+		f.name = name
+	}
+
+	var err error
+	f.cmap, err = parseCmap(tables["cmap"], numGlyphs)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// HasGlyph reports whether the font maps r to a glyph.
+func (f *Font) HasGlyph(r rune) bool {
+	_, ok := f.cmap[r]
+	return ok
+}
+
+// glyph returns the glyph of r, or the .notdef glyph when the font has none.
+func (f *Font) glyph(r rune) uint16 {
+	return f.cmap[r]
+}
+
+// width returns the advance of glyph g in thousandths of the font size.
+func (f *Font) width(g uint16) float64 {
+	if int(g) >= len(f.advances) {
+		return 0
+	}
+	return float64(f.advances[g]) * 1000 / float64(f.unitsPerEm)
+}
+
+// scale converts font units to thousandths of the font size.
+func (f *Font) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+// parseCmap reads the Unicode mapping of the font, preferring the full
+// repertoire subtable (format 12) over the BMP one (format 4).
+func parseCmap(cmap []byte, numGlyphs int) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, fmt.Errorf("%w: short cmap table", ErrInvalidFont)
+	}
+
+	var format4, format12 []byte
+	numTables := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < numTables; i++ {
+		record := 4 + 8*i
+		if record+8 > len(cmap) {
+			return nil, fmt.Errorf("%w: short cmap table", ErrInvalidFont)
+		}
+		platformID := binary.BigEndian.Uint16(cmap[record:])
+		encodingID := binary.BigEndian.Uint16(cmap[record+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+		if offset+2 > len(cmap) {
+			continue
+		}
+		unicode := platformID == 0 || (platformID == 3 && (encodingID == 1 || encodingID == 10))
+		if !unicode {
+			continue
+		}
+		switch binary.BigEndian.Uint16(cmap[offset:]) {
+		case 4:
+			format4 = cmap[offset:]
+		case 12:
+			format12 = cmap[offset:]
+		}
+	}
+
+	mapping := make(map[rune]uint16)
+	switch {
+	case format12 != nil:
+		if len(format12) < 16 {
+			return nil, fmt.Errorf("%w: short cmap subtable", ErrInvalidFont)
+		}
+		numGroups := int(binary.BigEndian.Uint32(format12[12:]))
+		if 16+12*numGroups > len(format12) {
+			return nil, fmt.Errorf("%w: short cmap subtable", ErrInvalidFont)
+		}
+		for i := 0; i < numGroups; i++ {
+			group := format12[16+12*i:]
+			start := binary.BigEndian.Uint32(group)
+			end := binary.BigEndian.Uint32(group[4:])
+			glyph := binary.BigEndian.Uint32(group[8:])
+			for c := start; c <= end && c <= 0x10FFFF; c++ {
+				g := glyph + c - start
+				if int(g) < numGlyphs {
+					mapping[rune(c)] = uint16(g)
+				}
+			}
+		}
+	case format4 != nil:
+		if len(format4) < 14 {
+			return nil, fmt.Errorf("%w: short cmap subtable", ErrInvalidFont)
+		}
+		segCount := int(binary.BigEndian.Uint16(format4[6:])) / 2
+		endCodes := 14
+		startCodes := endCodes + 2*segCount + 2
+		idDeltas := startCodes + 2*segCount
+		idRangeOffsets := idDeltas + 2*segCount
+		if idRangeOffsets+2*segCount > len(format4) {
+			return nil, fmt.Errorf("%w: short cmap subtable", ErrInvalidFont)
+		}
+		for i := 0; i < segCount; i++ {
+			end := int(binary.BigEndian.Uint16(format4[endCodes+2*i:]))
+			start := int(binary.BigEndian.Uint16(format4[startCodes+2*i:]))
+			delta := binary.BigEndian.Uint16(format4[idDeltas+2*i:])
+			rangeOffset := int(binary.BigEndian.Uint16(format4[idRangeOffsets+2*i:]))
+			for c := start; c <= end && c < 0xFFFF; c++ {
+				var g uint16
+				if rangeOffset == 0 {
+					g = uint16(c) + delta
+				} else {
+					at := idRangeOffsets + 2*i + rangeOffset + 2*(c-start)
+					if at+2 > len(format4) {
+						continue
+					}
+					g = binary.BigEndian.Uint16(format4[at:])
+					if g != 0 {
+						g += delta
+					}
+				}
+				if g != 0 && int(g) < numGlyphs {
+					mapping[rune(c)] = g
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: no Unicode cmap subtable", ErrInvalidFont)
+	}
+
+	return mapping, nil
+}
+
+// postScriptName returns name ID 6 of the name table, or "" if it has none.
+func postScriptName(name []byte) string {
+	if len(name) < 6 {
+		return ""
+	}
+	count := int(binary.BigEndian.Uint16(name[2:]))
+	storage := int(binary.BigEndian.Uint16(name[4:]))
+	for i := 0; i < count; i++ {
+		record := 6 + 12*i
+		if record+12 > len(name) {
+			return ""
+		}
+		platformID := binary.BigEndian.Uint16(name[record:])
+		nameID := binary.BigEndian.Uint16(name[record+6:])
+		length := int(binary.BigEndian.Uint16(name[record+8:]))
+		offset := storage + int(binary.BigEndian.Uint16(name[record+10:]))
+		if nameID != 6 || offset+length > len(name) {
+			continue
+		}
+		raw := name[offset : offset+length]
+
+		var s string
+		switch platformID {
+		case 1:
+			s = string(raw)
+		case 0, 3:
+			units := make([]uint16, len(raw)/2)
+			for j := range units {
+				units[j] = binary.BigEndian.Uint16(raw[2*j:])
+			}
+			s = string(utf16.Decode(units))
+		default:
+			continue
+		}
+
+		// PDF names cannot contain delimiters or spaces
+		s = strings.Map(func(r rune) rune {
+			if r <= ' ' || r > '~' || strings.ContainsRune("()<>[]{}/%#", r) {
+				return -1
+			}
+			return r
+		}, s)
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
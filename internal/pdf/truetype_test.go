@@ -0,0 +1,235 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// testFont builds a TrueType font with empty outlines that maps each rune
+// of glyphs to its glyph ID. Glyph i is 100*(i+1) units wide on a 2000 unit
+// em, so 50*(i+1) thousandths of the font size.
+func testFont(t *testing.T, numGlyphs int, glyphs map[rune]uint16) []byte {
+	t.Helper()
+
+	be := binary.BigEndian
+	head := make([]byte, 54)
+	be.PutUint16(head[18:], 2000)
+	for i, v := range []int16{-100, -500, 1900, 1800} {
+		be.PutUint16(head[36+2*i:], uint16(v))
+	}
+
+	hhea := make([]byte, 36)
+	be.PutUint16(hhea[4:], 1600)
+	be.PutUint16(hhea[6:], uint16(0xFFFF-400+1))
+	be.PutUint16(hhea[34:], uint16(numGlyphs))
+
+	maxp := make([]byte, 6)
+	be.PutUint32(maxp, 0x00005000)
+	be.PutUint16(maxp[4:], uint16(numGlyphs))
+
+	hmtx := make([]byte, 4*numGlyphs)
+	for i := 0; i < numGlyphs; i++ {
+		be.PutUint16(hmtx[4*i:], uint16(100*(i+1)))
+	}
+
+	name := []byte{0, 0, 0, 1, 0, 18, 0, 3, 0, 1, 0, 0, 0, 6, 0, 0, 0, 0}
+	for _, unit := range utf16.Encode([]rune("Test Font")) {
+		name = be.AppendUint16(name, unit)
+	}
+	be.PutUint16(name[14:], uint16(len(name)-18))
+
+	tables := map[string][]byte{
+		"head": head,
+		"hhea": hhea,
+		"hmtx": hmtx,
+		"maxp": maxp,
+		"cmap": format4Cmap(glyphs),
+		"glyf": {},
+		"name": name,
+	}
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	font := []byte{0, 1, 0, 0}
+	font = be.AppendUint16(font, uint16(len(tags)))
+	font = append(font, make([]byte, 6)...)
+	offset := 12 + 16*len(tags)
+	for _, tag := range tags {
+		font = append(font, tag...)
+		font = be.AppendUint32(font, 0)
+		font = be.AppendUint32(font, uint32(offset))
+		font = be.AppendUint32(font, uint32(len(tables[tag])))
+		offset += len(tables[tag])
+	}
+	for _, tag := range tags {
+		font = append(font, tables[tag]...)
+	}
+	return font
+}
+
+// format4Cmap builds a cmap with a Windows Unicode BMP subtable that has one
+// segment per rune.
+func format4Cmap(glyphs map[rune]uint16) []byte {
+	be := binary.BigEndian
+	runes := make([]rune, 0, len(glyphs))
+	for r := range glyphs {
+		runes = append(runes, r)
+	}
+	slices.Sort(runes)
+
+	segCount := len(runes) + 1
+	var ends, starts, deltas, rangeOffsets []byte
+	for _, r := range runes {
+		ends = be.AppendUint16(ends, uint16(r))
+		starts = be.AppendUint16(starts, uint16(r))
+		deltas = be.AppendUint16(deltas, glyphs[r]-uint16(r))
+		rangeOffsets = be.AppendUint16(rangeOffsets, 0)
+	}
+	ends = be.AppendUint16(ends, 0xFFFF)
+	starts = be.AppendUint16(starts, 0xFFFF)
+	deltas = be.AppendUint16(deltas, 1)
+	rangeOffsets = be.AppendUint16(rangeOffsets, 0)
+
+	subtable := be.AppendUint16(nil, 4)
+	subtable = be.AppendUint16(subtable, uint16(16+8*segCount))
+	subtable = be.AppendUint16(subtable, 0)
+	subtable = be.AppendUint16(subtable, uint16(2*segCount))
+	subtable = append(subtable, make([]byte, 6)...)
+	subtable = append(subtable, ends...)
+	subtable = append(subtable, 0, 0)
+	subtable = append(subtable, starts...)
+	subtable = append(subtable, deltas...)
+	subtable = append(subtable, rangeOffsets...)
+
+	cmap := []byte{0, 0, 0, 1, 0, 3, 0, 1, 0, 0, 0, 12}
+	return append(cmap, subtable...)
+}
+
+func TestParseFont(t *testing.T) {
+	font, err := ParseFont(testFont(t, 4, map[rune]uint16{'A': 1, 0x0628: 2, 0xFE91: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if font.name != "TestFont" {
+		t.Errorf("name = %q; want TestFont", font.name)
+	}
+	if font.unitsPerEm != 2000 || font.ascent != 1600 || font.descent != -400 {
+		t.Errorf("unitsPerEm, ascent, descent = %d, %d, %d; want 2000, 1600, -400", font.unitsPerEm, font.ascent, font.descent)
+	}
+	if font.bbox != [4]int{-100, -500, 1900, 1800} {
+		t.Errorf("bbox = %v", font.bbox)
+	}
+
+	for _, r := range []rune{'A', 0x0628, 0xFE91} {
+		if !font.HasGlyph(r) {
+			t.Errorf("HasGlyph(%U) = false; want true", r)
+		}
+	}
+	if font.HasGlyph('B') {
+		t.Error("HasGlyph('B') = true; want false")
+	}
+	if g := font.glyph(0xFE91); g != 3 {
+		t.Errorf("glyph(U+FE91) = %d; want 3", g)
+	}
+	if w := font.width(3); w != 200 {
+		t.Errorf("width(3) = %v; want 200", w)
+	}
+	if w := font.width(9); w != 0 {
+		t.Errorf("width of a missing glyph = %v; want 0", w)
+	}
+}
+
+func TestParseFontErrors(t *testing.T) {
+	valid := testFont(t, 2, map[rune]uint16{'A': 1})
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not a font", []byte("%PDF-1.4 not a font")},
+		{"CFF outlines", append([]byte("OTTO"), valid[4:]...)},
+		{"truncated", valid[:len(valid)-40]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseFont(tt.data); !errors.Is(err, ErrInvalidFont) {
+				t.Errorf("err = %v; want ErrInvalidFont", err)
+			}
+		})
+	}
+
+	t.Run("missing glyf", func(t *testing.T) {
+		data := bytes.Clone(valid)
+		i := bytes.Index(data[:12+16*7], []byte("glyf"))
+		copy(data[i:], "xxxx")
+		_, err := ParseFont(data)
+		if !errors.Is(err, ErrInvalidFont) || !strings.Contains(err.Error(), "glyf") {
+			t.Errorf("err = %v; want a missing glyf table", err)
+		}
+	})
+}
+
+func TestParseCmap(t *testing.T) {
+	be := binary.BigEndian
+
+	// A format 12 subtable with one group of three runes outside the BMP
+	format12 := be.AppendUint16(nil, 12)
+	format12 = be.AppendUint16(format12, 0)
+	format12 = be.AppendUint32(format12, 28)
+	format12 = be.AppendUint32(format12, 0)
+	format12 = be.AppendUint32(format12, 1)
+	format12 = be.AppendUint32(format12, 0x1D7CE)
+	format12 = be.AppendUint32(format12, 0x1D7D0)
+	format12 = be.AppendUint32(format12, 5)
+
+	format4 := format4Cmap(map[rune]uint16{'A': 1})[12:]
+
+	t.Run("format 4", func(t *testing.T) {
+		got, err := parseCmap(format4Cmap(map[rune]uint16{'A': 1, 0x0628: 4, 0x06F1: 2}), 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[rune]uint16{'A': 1, 0x0628: 4, 0x06F1: 2}
+		if !maps.Equal(got, want) {
+			t.Errorf("parseCmap = %v; want %v", got, want)
+		}
+	})
+
+	t.Run("format 12 is preferred", func(t *testing.T) {
+		cmap := []byte{0, 0, 0, 2, 0, 3, 0, 1, 0, 0, 0, 20, 0, 3, 0, 10}
+		cmap = be.AppendUint32(cmap, uint32(20+len(format4)))
+		cmap = append(cmap, format4...)
+		cmap = append(cmap, format12...)
+
+		got, err := parseCmap(cmap, 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Glyph 7 is past the last glyph of the font and is left out
+		want := map[rune]uint16{0x1D7CE: 5, 0x1D7CF: 6}
+		if !maps.Equal(got, want) {
+			t.Errorf("parseCmap = %v; want %v", got, want)
+		}
+	})
+
+	t.Run("no Unicode subtable", func(t *testing.T) {
+		cmap := []byte{0, 0, 0, 1, 0, 1, 0, 0, 0, 0, 0, 12}
+		cmap = append(cmap, format4...)
+		if _, err := parseCmap(cmap, 2); !errors.Is(err, ErrInvalidFont) {
+			t.Errorf("err = %v; want ErrInvalidFont", err)
+		}
+	})
+}
@@ -0,0 +1,18 @@
+package planner
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+)
+
+//go:embed planner.html
+var plannerHTML string
+
+var htmlTemplate = template.Must(template.New("planner").Parse(plannerHTML))
+
+// WriteHTML writes the planner as a standalone right-to-left HTML page that
+// prints on one landscape A4 sheet.
+func WriteHTML(w io.Writer, p *Planner) error {
+	return htmlTemplate.Execute(w, p)
+}
@@ -0,0 +1,176 @@
+package planner
+
+import (
+	"io"
+	"strings"
+
+	"github.com/Behehap/Alberta/internal/pdf"
+)
+
+var (
+	colorText        = pdf.RGB(33, 33, 33)
+	colorMuted       = pdf.RGB(97, 97, 97)
+	colorBorder      = pdf.RGB(158, 158, 158)
+	colorHeader      = pdf.RGB(236, 239, 241)
+	colorExamHeader  = pdf.RGB(255, 224, 178)
+	colorExamText    = pdf.RGB(191, 54, 12)
+	colorStudy       = pdf.RGB(227, 242, 253)
+	colorDone        = pdf.RGB(232, 245, 233)
+	colorUnavailable = pdf.RGB(224, 224, 224)
+	colorRest        = pdf.RGB(245, 245, 245)
+)
+
+var cellColors = map[CellKind]pdf.Color{
+	CellStudy:       colorStudy,
+	CellDone:        colorDone,
+	CellUnavailable: colorUnavailable,
+	CellRest:        colorRest,
+}
+
+// WritePDF writes the planner as a one-page landscape A4 PDF. The font must
+// have Persian glyphs.
+func WritePDF(w io.Writer, p *Planner, font *pdf.Font) error {
+	doc := pdf.New(font)
+	page := doc.AddPage(pdf.A4LandscapeWidth, pdf.A4LandscapeHeight)
+
+	const (
+		margin     = 28.0
+		timeWidth  = 78.0
+		lineHeight = 14.0
+	)
+	width, height := pdf.A4LandscapeWidth, pdf.A4LandscapeHeight
+	right := width - margin
+
+	page.Text(right, margin+14, 16, colorText, pdf.AlignRight, p.Title+" - "+p.Student)
+	page.Text(margin, margin+14, 11, colorMuted, pdf.AlignLeft, p.Week)
+
+	headerHeight := 32.0
+	for _, day := range p.Days {
+		if len(day.Exams) > 0 {
+			headerHeight = 46
+		}
+	}
+
+	// Below the grid come the legend, the upcoming exams and the footer
+	reserved := 2*lineHeight + 8
+	if len(p.Exams) > 0 {
+		reserved += lineHeight * float64(len(p.Exams)+1)
+	}
+
+	gridTop := margin + 28
+	rowHeight := 44.0
+	if len(p.Rows) > 0 {
+		rowHeight = min(rowHeight, (height-margin-reserved-gridTop-headerHeight)/float64(len(p.Rows)))
+	}
+	columnWidth := (width - 2*margin - timeWidth) / float64(len(p.Days))
+	columnX := func(i int) float64 {
+		return right - timeWidth - float64(i+1)*columnWidth
+	}
+
+	cell := func(x, y, w, h float64, fill pdf.Color) {
+		page.FillRect(x, y, w, h, fill)
+		page.StrokeRect(x, y, w, h, 0.5, colorBorder)
+	}
+
+	cell(right-timeWidth, gridTop, timeWidth, headerHeight, colorHeader)
+	page.Text(right-timeWidth/2, gridTop+headerHeight/2+4, 10, colorText, pdf.AlignCenter, "ساعت")
+
+	for i, day := range p.Days {
+		x := columnX(i)
+		fill := colorHeader
+		if len(day.Exams) > 0 {
+			fill = colorExamHeader
+		}
+		cell(x, gridTop, columnWidth, headerHeight, fill)
+		page.Text(x+columnWidth/2, gridTop+13, 10, colorText, pdf.AlignCenter, day.Name)
+		page.Text(x+columnWidth/2, gridTop+26, 8, colorMuted, pdf.AlignCenter, day.Date)
+		if len(day.Exams) > 0 {
+			text, size := fitText(doc, strings.Join(day.Exams, "، "), columnWidth-6, 8, 6)
+			page.Text(x+columnWidth/2, gridTop+40, size, colorExamText, pdf.AlignCenter, text)
+		}
+	}
+
+	for r, label := range p.Rows {
+		y := gridTop + headerHeight + float64(r)*rowHeight
+		baseline := y + rowHeight/2 + 3
+
+		cell(right-timeWidth, y, timeWidth, rowHeight, colorHeader)
+		page.Text(right-timeWidth/2, baseline, 8, colorText, pdf.AlignCenter, label)
+
+		for i, day := range p.Days {
+			x := columnX(i)
+			c := day.Cells[r]
+			fill, ok := cellColors[c.Kind]
+			if !ok {
+				fill = pdf.RGB(255, 255, 255)
+			}
+			cell(x, y, columnWidth, rowHeight, fill)
+			if c.Text == "" {
+				continue
+			}
+			textColor := colorText
+			if c.Kind == CellUnavailable || c.Kind == CellRest {
+				textColor = colorMuted
+			}
+			text, size := fitText(doc, c.Text, columnWidth-6, 9, 6)
+			page.Text(x+columnWidth/2, baseline, size, textColor, pdf.AlignCenter, text)
+		}
+	}
+
+	y := gridTop + headerHeight + float64(len(p.Rows))*rowHeight + lineHeight + 4
+
+	// Legend, from right to left
+	x := right
+	legend := []struct {
+		fill  pdf.Color
+		label string
+	}{
+		{colorStudy, "مطالعه"},
+		{colorDone, "انجام شده"},
+		{colorUnavailable, "غیرقابل دسترس"},
+		{colorRest, "استراحت"},
+		{colorExamHeader, "روز آزمون"},
+	}
+	for _, item := range legend {
+		cell(x-10, y-8, 10, 10, item.fill)
+		page.Text(x-14, y, 9, colorText, pdf.AlignRight, item.label)
+		x -= 14 + doc.TextWidth(item.label, 9) + 16
+	}
+
+	if len(p.Exams) > 0 {
+		y += lineHeight + 4
+		page.Text(right, y, 11, colorText, pdf.AlignRight, "آزمون‌های پیش رو")
+		for _, exam := range p.Exams {
+			y += lineHeight
+			textColor := colorText
+			if exam.ThisWeek {
+				textColor = colorExamText
+			}
+			page.Text(right, y, 9, textColor, pdf.AlignRight, exam.Title+" - "+exam.Date+" ("+exam.Remaining+")")
+		}
+	}
+
+	page.Text(right, height-margin+8, 8, colorMuted, pdf.AlignRight, p.Generated)
+
+	return doc.Write(w)
+}
+
+// fitText shrinks text down to minSize to fit maxWidth, then shortens it.
+func fitText(doc *pdf.Document, text string, maxWidth, size, minSize float64) (string, float64) {
+	for size > minSize && doc.TextWidth(text, size) > maxWidth {
+		size -= 0.5
+	}
+
+	if doc.TextWidth(text, size) <= maxWidth {
+		return text, size
+	}
+
+	runes := []rune(text)
+	for n := len(runes) - 1; n > 0; n-- {
+		shortened := strings.TrimSpace(string(runes[:n])) + "…"
+		if doc.TextWidth(shortened, size) <= maxWidth {
+			return shortened, size
+		}
+	}
+	return "…", size
+}
@@ -0,0 +1,211 @@
+// Package planner lays out a weekly plan as a printable Persian grid of days
+// by study blocks and renders it as HTML or PDF.
+package planner
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/calendar"
+	"github.com/Behehap/Alberta/internal/scheduler"
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+// maxUpcomingExams is the number of upcoming exams listed under the grid.
+const maxUpcomingExams = 5
+
+type CellKind string
+
+const (
+	CellFree        CellKind = "free"
+	CellStudy       CellKind = "study"
+	CellDone        CellKind = "done"
+	CellUnavailable CellKind = "unavailable"
+	CellRest        CellKind = "rest"
+)
+
+type Cell struct {
+	Kind CellKind
+	Text string
+}
+
+type Day struct {
+	Name  string
+	Date  string
+	Rest  bool
+	Exams []string
+	// Cells holds one cell per row of the planner.
+	Cells []Cell
+}
+
+type Exam struct {
+	Title     string
+	Date      string
+	Remaining string
+	ThisWeek  bool
+}
+
+// Planner is a laid out week. All text is in Persian with Persian digits.
+type Planner struct {
+	Title     string
+	Student   string
+	Week      string
+	Rows      []string
+	Days      []Day
+	Exams     []Exam
+	Generated string
+}
+
+// Session is a study session to place on the planner. Start and End are
+// clock times.
+type Session struct {
+	Date  time.Time
+	Start time.Time
+	End   time.Time
+	Title string
+	Done  bool
+}
+
+type Input struct {
+	Student      string
+	WeekStart    time.Time
+	DayStartTime sql.NullTime
+	Sessions     []Session
+	Unavailable  []*store.UnavailableTime
+	Exams        []*store.ExamSchedule
+	// Today is the student's current date at midnight UTC.
+	Today time.Time
+}
+
+// Build lays out the week. Rows are the blocks the scheduler uses, plus a
+// row for any session that was moved off them.
+func Build(in Input) *Planner {
+	p := &Planner{
+		Title:     "برنامه هفتگی",
+		Student:   in.Student,
+		Week:      fmt.Sprintf("%s تا %s", longDate(in.WeekStart), longDate(in.WeekStart.AddDate(0, 0, 6))),
+		Generated: "تهیه شده در " + longDate(in.Today),
+	}
+
+	rows := scheduler.DayBlocks(in.DayStartTime)
+	for _, session := range in.Sessions {
+		if rowAt(rows, session.Start) < 0 {
+			rows = append(rows, scheduler.Block{Start: session.Start, End: session.End})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Start.Before(rows[j].Start)
+	})
+	for _, row := range rows {
+		p.Rows = append(p.Rows, calendar.PersianDigits(row.Start.Format("15:04")+" - "+row.End.Format("15:04")))
+	}
+
+	for i := 0; i < 7; i++ {
+		date := in.WeekStart.AddDate(0, 0, i)
+		day := Day{
+			Name:  calendar.Jalali.WeekdayName(date.Weekday()),
+			Date:  shortDate(date),
+			Rest:  date.Weekday() == time.Friday,
+			Cells: make([]Cell, len(rows)),
+		}
+
+		for _, exam := range in.Exams {
+			if sameDate(exam.ExamDate, date) {
+				day.Exams = append(day.Exams, exam.Title)
+			}
+		}
+
+		for r, row := range rows {
+			cell := &day.Cells[r]
+			for _, ut := range in.Unavailable {
+				if scheduler.UnavailableOn(ut, date) && clockBefore(row.Start, ut.EndTime) && clockBefore(ut.StartTime, row.End) {
+					cell.Kind = CellUnavailable
+					cell.Text = ut.Title
+					break
+				}
+			}
+			if cell.Kind == "" && day.Rest {
+				cell.Kind = CellRest
+				cell.Text = "استراحت"
+			}
+			if cell.Kind == "" {
+				cell.Kind = CellFree
+			}
+		}
+
+		// Sessions take precedence over whatever else the cell holds
+		for _, session := range in.Sessions {
+			if !sameDate(session.Date, date) {
+				continue
+			}
+			cell := &day.Cells[rowAt(rows, session.Start)]
+			cell.Kind = CellStudy
+			if session.Done {
+				cell.Kind = CellDone
+			}
+			cell.Text = session.Title
+		}
+
+		p.Days = append(p.Days, day)
+	}
+
+	weekEnd := in.WeekStart.AddDate(0, 0, 7)
+	for _, exam := range in.Exams {
+		if exam.ExamDate.Before(in.Today) || len(p.Exams) == maxUpcomingExams {
+			continue
+		}
+
+		remaining := "امروز"
+		if days := int(exam.ExamDate.Sub(in.Today).Hours() / 24); days > 0 {
+			remaining = calendar.PersianDigits(fmt.Sprintf("%d روز مانده", days))
+		}
+
+		p.Exams = append(p.Exams, Exam{
+			Title:     exam.Title,
+			Date:      longDate(exam.ExamDate),
+			Remaining: remaining,
+			ThisWeek:  !exam.ExamDate.Before(in.WeekStart) && exam.ExamDate.Before(weekEnd),
+		})
+	}
+
+	return p
+}
+
+// rowAt returns the index of the row starting at clock time start, or -1.
+func rowAt(rows []scheduler.Block, start time.Time) int {
+	for i, row := range rows {
+		if clockEqual(row.Start, start) {
+			return i
+		}
+	}
+	return -1
+}
+
+func clockSeconds(t time.Time) int {
+	return t.Hour()*3600 + t.Minute()*60 + t.Second()
+}
+
+func clockEqual(a, b time.Time) bool {
+	return clockSeconds(a) == clockSeconds(b)
+}
+
+func clockBefore(a, b time.Time) bool {
+	return clockSeconds(a) < clockSeconds(b)
+}
+
+func sameDate(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
+
+// shortDate formats t as the Jalali day and month, such as ۲۸ مهر.
+func shortDate(t time.Time) string {
+	d := calendar.ToJalali(t)
+	return calendar.PersianDigits(fmt.Sprintf("%d %s", d.Day, d.MonthName()))
+}
+
+func longDate(t time.Time) string {
+	d := calendar.ToJalali(t)
+	return calendar.PersianDigits(fmt.Sprintf("%d %s %d", d.Day, d.MonthName(), d.Year))
+}
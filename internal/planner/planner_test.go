@@ -0,0 +1,101 @@
+package planner
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+func clock(hour, minute int) time.Time {
+	return time.Date(0, 1, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func date(month time.Month, day int) time.Time {
+	return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBuild(t *testing.T) {
+	p := Build(Input{
+		Student:   "سارا",
+		WeekStart: date(time.October, 17),
+		Sessions: []Session{
+			{Date: date(time.October, 18), Start: clock(9, 40), End: clock(11, 20), Title: "ریاضی"},
+			// Off the blocks of the week, so it gets a row of its own
+			{Date: date(time.October, 19), Start: clock(7, 0), End: clock(8, 40), Title: "فیزیک", Done: true},
+		},
+		Unavailable: []*store.UnavailableTime{
+			{Title: "مدرسه", DayOfWeek: 1, StartTime: clock(8, 0), EndTime: clock(10, 0), IsRecurring: true},
+		},
+		Exams: []*store.ExamSchedule{
+			{Title: "آزمون گذشته", ExamDate: date(time.October, 10)},
+			{Title: "آزمون ۱", ExamDate: date(time.October, 21)},
+			{Title: "آزمون ۲", ExamDate: date(time.November, 1)},
+		},
+		Today: date(time.October, 19),
+	})
+
+	if want := "۲۵ مهر ۱۴۰۵ تا ۱ آبان ۱۴۰۵"; p.Week != want {
+		t.Errorf("Week = %q; want %q", p.Week, want)
+	}
+
+	wantRows := []string{
+		"۰۷:۰۰ - ۰۸:۴۰",
+		"۰۸:۰۰ - ۰۹:۴۰",
+		"۰۹:۴۰ - ۱۱:۲۰",
+		"۱۱:۲۰ - ۱۳:۰۰",
+		"۱۳:۰۰ - ۱۴:۴۰",
+		"۱۴:۴۰ - ۱۶:۲۰",
+		"۱۶:۲۰ - ۱۸:۰۰",
+		"۱۸:۰۰ - ۱۹:۴۰",
+		"۱۹:۴۰ - ۲۱:۲۰",
+	}
+	if !slices.Equal(p.Rows, wantRows) {
+		t.Errorf("Rows = %q; want %q", p.Rows, wantRows)
+	}
+
+	if len(p.Days) != 7 {
+		t.Fatalf("got %d days; want 7", len(p.Days))
+	}
+	if p.Days[0].Name != "شنبه" || p.Days[0].Date != "۲۵ مهر" {
+		t.Errorf("first day = %s %s; want شنبه ۲۵ مهر", p.Days[0].Name, p.Days[0].Date)
+	}
+
+	kinds := func(day Day) []CellKind {
+		var k []CellKind
+		for _, cell := range day.Cells[:4] {
+			k = append(k, cell.Kind)
+		}
+		return k
+	}
+	// Sunday is unavailable until 10:00, but the session takes its block
+	if got, want := kinds(p.Days[1]), []CellKind{CellUnavailable, CellUnavailable, CellStudy, CellFree}; !slices.Equal(got, want) {
+		t.Errorf("Sunday cells = %v; want %v", got, want)
+	}
+	if cell := p.Days[1].Cells[0]; cell.Text != "مدرسه" {
+		t.Errorf("unavailable cell text = %q; want مدرسه", cell.Text)
+	}
+	if cell := p.Days[1].Cells[2]; cell.Text != "ریاضی" {
+		t.Errorf("study cell text = %q; want ریاضی", cell.Text)
+	}
+	if got, want := kinds(p.Days[2]), []CellKind{CellDone, CellFree, CellFree, CellFree}; !slices.Equal(got, want) {
+		t.Errorf("Monday cells = %v; want %v", got, want)
+	}
+	friday := p.Days[6]
+	if !friday.Rest || friday.Cells[0].Kind != CellRest {
+		t.Errorf("Friday is not a rest day: %+v", friday)
+	}
+
+	if got := p.Days[4].Exams; !slices.Equal(got, []string{"آزمون ۱"}) {
+		t.Errorf("Wednesday exams = %q; want [آزمون ۱]", got)
+	}
+
+	wantExams := []Exam{
+		{Title: "آزمون ۱", Date: "۲۹ مهر ۱۴۰۵", Remaining: "۲ روز مانده", ThisWeek: true},
+		{Title: "آزمون ۲", Date: "۱۰ آبان ۱۴۰۵", Remaining: "۱۳ روز مانده"},
+	}
+	if !slices.Equal(p.Exams, wantExams) {
+		t.Errorf("Exams = %+v; want %+v", p.Exams, wantExams)
+	}
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reasons a requested block was not placed.
+const (
+	ReasonBlockLimit  = "block_limit"
+	ReasonDailyLimits = "daily_limits"
+	ReasonUnavailable = "unavailable"
+	ReasonRestDay     = "rest_day"
+	ReasonNoSlots     = "no_slots"
+)
+
+type BookPlacement struct {
+	BookID    int64 `json:"book_id"`
+	Requested int   `json:"requested"`
+	Placed    int   `json:"placed"`
+	Unplaced  int   `json:"unplaced"`
+}
+
+// UnplacedReason accounts for Blocks of the unplaced demand. The blocks of
+// all reasons add up to the result's Unplaced.
+type UnplacedReason struct {
+	Reason      string `json:"reason"`
+	Blocks      int    `json:"blocks"`
+	Explanation string `json:"explanation"`
+}
+
+type Suggestion struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// GenerationResult describes what GenerateWeeklyPlan placed and, when the
+// requested frequencies did not fit, why not and what would make them fit.
+type GenerationResult struct {
+	Seed            int64            `json:"seed"`
+	SessionsCreated int              `json:"sessions_created"`
+	Requested       int              `json:"requested"`
+	Unplaced        int              `json:"unplaced"`
+	Books           []BookPlacement  `json:"books"`
+	Reasons         []UnplacedReason `json:"reasons"`
+	Suggestions     []Suggestion     `json:"suggestions"`
+}
+
+// weekCapacity is how the blocks of a week were used up.
+type weekCapacity struct {
+	dayStart time.Time
+	// free is the number of blocks on study days still free after placing,
+	// which only the plan's daily limits leave empty.
+	free int
+	// unavailable is the number of blocks on study days that overlap the
+	// student's unavailable times, and restDay the free blocks on Friday.
+	unavailable int
+	restDay     int
+	// busiestDay is the study day losing the most blocks to unavailable times.
+	busiestDay     time.Weekday
+	busiestBlocked int
+}
+
+// explainUnplaced fills in the reasons and suggestions of a result whose
+// sessions are already counted. When the weekly block total stopped the
+// scheduler, that is the only reason. Otherwise the shortfall is put down
+// first to the daily limits, as far as they left blocks empty, then to
+// unavailable times, then to the rest day, and what is left to study days
+// that are too short.
+func (r *GenerationResult) explainUnplaced(capacity weekCapacity, blockLimit int) {
+	r.Reasons = []UnplacedReason{}
+	r.Suggestions = []Suggestion{}
+	if r.Unplaced == 0 {
+		return
+	}
+
+	if r.SessionsCreated >= blockLimit {
+		r.Reasons = append(r.Reasons, UnplacedReason{
+			Reason:      ReasonBlockLimit,
+			Blocks:      r.Unplaced,
+			Explanation: fmt.Sprintf("The week is limited to %d blocks but the frequencies ask for %d.", blockLimit, r.Requested),
+		})
+		r.Suggestions = append(r.Suggestions, Suggestion{
+			Code:    "raise_block_total",
+			Message: fmt.Sprintf("Raise the weekly block total to %d.", r.Requested),
+		})
+		return
+	}
+
+	left := r.Unplaced
+	if blocks := min(left, capacity.free); blocks > 0 {
+		r.Reasons = append(r.Reasons, UnplacedReason{
+			Reason:      ReasonDailyLimits,
+			Blocks:      blocks,
+			Explanation: fmt.Sprintf("The weekly plan's daily limits leave %d free blocks empty.", capacity.free),
+		})
+		r.Suggestions = append(r.Suggestions, Suggestion{
+			Code:    "relax_daily_limits",
+			Message: "Raise the blocks allowed per day or per book, or shorten the days required between sessions of a book.",
+		})
+		left -= blocks
+	}
+
+	if blocks := min(left, capacity.unavailable); blocks > 0 {
+		r.Reasons = append(r.Reasons, UnplacedReason{
+			Reason:      ReasonUnavailable,
+			Blocks:      blocks,
+			Explanation: fmt.Sprintf("Unavailable times cover %d blocks of the study days.", capacity.unavailable),
+		})
+		r.Suggestions = append(r.Suggestions, Suggestion{
+			Code:    "free_unavailable_time",
+			Message: fmt.Sprintf("Free up unavailable time, most of all on %s, which loses %d blocks.", capacity.busiestDay, capacity.busiestBlocked),
+		})
+		left -= blocks
+	}
+
+	if blocks := min(left, capacity.restDay); blocks > 0 {
+		r.Reasons = append(r.Reasons, UnplacedReason{
+			Reason:      ReasonRestDay,
+			Blocks:      blocks,
+			Explanation: fmt.Sprintf("Friday is a rest day; it has room for %d blocks.", capacity.restDay),
+		})
+		r.Suggestions = append(r.Suggestions, Suggestion{
+			Code:    "study_on_rest_day",
+			Message: fmt.Sprintf("Study on Friday to place %d more blocks.", blocks),
+		})
+		left -= blocks
+	}
+
+	if left > 0 {
+		r.Reasons = append(r.Reasons, UnplacedReason{
+			Reason:      ReasonNoSlots,
+			Blocks:      left,
+			Explanation: "The study days have no free blocks left.",
+		})
+
+		// each block earlier adds one block to every study day
+		extra := (left + StudyDaysPerWeek - 1) / StudyDaysPerWeek
+		earlier := capacity.dayStart.Add(-time.Duration(extra) * blockDuration)
+		if earlier.Day() == capacity.dayStart.Day() {
+			r.Suggestions = append(r.Suggestions, Suggestion{
+				Code:    "start_day_earlier",
+				Message: fmt.Sprintf("Start study days at %s instead of %s to make room for %d more blocks a week.", earlier.Format("15:04"), capacity.dayStart.Format("15:04"), extra*StudyDaysPerWeek),
+			})
+		}
+	}
+
+	r.Suggestions = append(r.Suggestions, Suggestion{
+		Code:    "reduce_frequencies",
+		Message: fmt.Sprintf("Lower the requested frequencies by %d blocks in total.", r.Unplaced),
+	})
+}
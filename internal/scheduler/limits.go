@@ -0,0 +1,78 @@
+package scheduler
+
+import "github.com/Behehap/Alberta/internal/store"
+
+// DailyLimits bound how GenerateWeeklyPlan fills each day. A zero field means
+// no limit.
+type DailyLimits struct {
+	MaxBlocksPerDay     int
+	MaxBookBlocksPerDay int
+	// MinDaysBetweenBookSessions is the number of days that must pass
+	// between two days on which the same book is studied.
+	MinDaysBetweenBookSessions int
+}
+
+// LimitsOf returns the daily limits set on a weekly plan.
+func LimitsOf(wp *store.WeeklyPlan) DailyLimits {
+	return DailyLimits{
+		MaxBlocksPerDay:            int(wp.MaxBlocksPerDay.Int64),
+		MaxBookBlocksPerDay:        int(wp.MaxBookBlocksPerDay.Int64),
+		MinDaysBetweenBookSessions: int(wp.MinDaysBetweenBookSessions.Int64),
+	}
+}
+
+// dayQuotas spreads total blocks over the days as evenly as their free blocks
+// allow, handing them out one per day in turn. Days are indexes into the week
+// and free holds each day's free blocks, already capped by MaxBlocksPerDay.
+func dayQuotas(days []int, free map[int]int, total int) map[int]int {
+	quotas := make(map[int]int)
+	for assigned := 0; assigned < total; {
+		progress := false
+		for _, day := range days {
+			if assigned < total && quotas[day] < free[day] {
+				quotas[day]++
+				assigned++
+				progress = true
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+	return quotas
+}
+
+// dayPlacements counts the blocks placed on each day of the week, overall and
+// per book.
+type dayPlacements struct {
+	perDay     map[int]int
+	perBookDay map[int]map[int64]int
+}
+
+func newDayPlacements() *dayPlacements {
+	return &dayPlacements{perDay: make(map[int]int), perBookDay: make(map[int]map[int64]int)}
+}
+
+func (p *dayPlacements) add(day int, bookID int64) {
+	p.perDay[day]++
+	if p.perBookDay[day] == nil {
+		p.perBookDay[day] = make(map[int64]int)
+	}
+	p.perBookDay[day][bookID]++
+}
+
+// allows reports whether limits leave room for another block of bookID on day.
+func (p *dayPlacements) allows(day int, bookID int64, limits DailyLimits) bool {
+	if limits.MaxBookBlocksPerDay > 0 && p.perBookDay[day][bookID] >= limits.MaxBookBlocksPerDay {
+		return false
+	}
+	if limits.MinDaysBetweenBookSessions > 0 {
+		for other, books := range p.perBookDay {
+			distance := max(day-other, other-day)
+			if other != day && books[bookID] > 0 && distance <= limits.MinDaysBetweenBookSessions {
+				return false
+			}
+		}
+	}
+	return true
+}
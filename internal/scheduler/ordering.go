@@ -0,0 +1,229 @@
+package scheduler
+
+import "github.com/Behehap/Alberta/internal/store"
+
+// Costs of two adjacent blocks in a day, and of a block outside its book's
+// time preference. Heavy pairs are also equal pairs, so two heavy books in a
+// row cost both.
+const (
+	heavyPairCost        = 3
+	equalPairCost        = 1
+	missedPreferenceCost = 1
+)
+
+// orderingUnit is what the ordering pass moves as a whole: one session, or
+// the run of sessions a ConsecutiveSessions rule placed together.
+type orderingUnit struct {
+	sessions   []*store.StudySession
+	difficulty int
+	// pinnedFirst keeps a PrioritySlot "first" book at the start of the day.
+	// A unit that already meets its time preference must keep meeting it.
+	pinnedFirst    bool
+	preference     string
+	keepPreference bool
+}
+
+// orderDay rearranges the books of a day's sessions over the same blocks so
+// that heavy books are not studied back to back and difficulties alternate.
+// Sessions are in block order. The hard constraints of the placement are
+// kept: a book placed first by its priority slot stays first, a session that
+// meets its time preference still meets it, and consecutive sessions stay
+// together in adjacent blocks. Moving a session into its preferred time is
+// a small gain. Of the best orders, the one closest to the
+// placed order wins, so a day that cannot be improved is left as it is.
+func orderDay(sessions []*store.StudySession, difficulties map[int64]int, rules map[int64]*store.TemplateRule) error {
+	if len(sessions) < 2 {
+		return nil
+	}
+
+	blocks := make([]timeSlot, len(sessions))
+	for i, ss := range sessions {
+		start, err := parseSessionClock(ss.StartTime)
+		if err != nil {
+			return err
+		}
+		end, err := parseSessionClock(ss.EndTime)
+		if err != nil {
+			return err
+		}
+		blocks[i] = timeSlot{Start: start, End: end}
+	}
+	adjacent := func(i int) bool {
+		return i > 0 && blocks[i-1].End.Equal(blocks[i].Start)
+	}
+
+	var units []orderingUnit
+	for i := 0; i < len(sessions); i++ {
+		first, ss := i, sessions[i]
+		rule := rules[ss.BookID]
+		unit := orderingUnit{sessions: []*store.StudySession{ss}, difficulty: difficulties[ss.BookID]}
+		if unit.difficulty == 0 {
+			unit.difficulty = store.DifficultyMedium
+		}
+		if rule != nil && rule.ConsecutiveSessions.Valid && rule.ConsecutiveSessions.Bool {
+			for i+1 < len(sessions) && sessions[i+1].BookID == ss.BookID && adjacent(i+1) {
+				i++
+				unit.sessions = append(unit.sessions, sessions[i])
+			}
+		}
+		if rule != nil {
+			unit.pinnedFirst = len(units) == 0 && rule.PrioritySlot.Valid && rule.PrioritySlot.String == "first"
+			if rule.TimePreference.Valid {
+				unit.preference = rule.TimePreference.String
+				unit.keepPreference = meetsTimePreference(unit.preference, blocks[first].Start.Hour()) &&
+					meetsTimePreference(unit.preference, blocks[i].Start.Hour())
+			}
+		}
+		units = append(units, unit)
+	}
+
+	// fits reports whether unit can take the blocks from start on.
+	fits := func(unit orderingUnit, start int) bool {
+		if start+len(unit.sessions) > len(blocks) || (unit.pinnedFirst && start != 0) {
+			return false
+		}
+		for k := range unit.sessions {
+			if k > 0 && !adjacent(start+k) {
+				return false
+			}
+			if unit.keepPreference && !meetsTimePreference(unit.preference, blocks[start+k].Start.Hour()) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// pairCost is the cost of a block of difficulty b right after one of
+	// difficulty a, when the two are back to back.
+	pairCost := func(a, b int) int {
+		cost := 0
+		if a == b {
+			cost += equalPairCost
+		}
+		if a == store.DifficultyHeavy && b == store.DifficultyHeavy {
+			cost += heavyPairCost
+		}
+		return cost
+	}
+
+	// cost is what placing unit u at block next adds, after a unit of
+	// difficulty last.
+	cost := func(u orderingUnit, next, last int) int {
+		added := 0
+		if next > 0 && adjacent(next) {
+			added = pairCost(last, u.difficulty)
+		}
+		for k := range u.sessions {
+			// consecutive sessions of one book are back to back by rule
+			if k > 0 {
+				added += pairCost(u.difficulty, u.difficulty)
+			}
+			if u.preference != "" && !meetsTimePreference(u.preference, blocks[next+k].Start.Hour()) {
+				added += missedPreferenceCost
+			}
+		}
+		return added
+	}
+
+	// Units that differ only in their book cost the same wherever they go,
+	// so the search runs over how many units of each kind are left rather
+	// than over orders of the units, and remembers the cheapest rest of the
+	// day for each such state. A day of n units has at most 2^n states, and
+	// far fewer when books share a kind.
+	type unitKind struct {
+		difficulty     int
+		length         int
+		pinnedFirst    bool
+		preference     string
+		keepPreference bool
+	}
+	var kinds []orderingUnit
+	var members [][]int
+	kindOf := make(map[unitKind]int)
+	for u, unit := range units {
+		key := unitKind{unit.difficulty, len(unit.sessions), unit.pinnedFirst, unit.preference, unit.keepPreference}
+		k, ok := kindOf[key]
+		if !ok {
+			k = len(kinds)
+			kindOf[key] = k
+			kinds = append(kinds, unit)
+			members = append(members, nil)
+		}
+		members[k] = append(members[k], u)
+	}
+
+	left := make([]int, len(kinds))
+	for k := range kinds {
+		left[k] = len(members[k])
+	}
+	memo := make(map[string]int)
+	// cheapest returns the lowest cost of filling the blocks from next on
+	// with the units left, after a unit of difficulty last, or -1 when they
+	// do not fit.
+	var cheapest func(next, last int) int
+	cheapest = func(next, last int) int {
+		if next == len(blocks) {
+			return 0
+		}
+		state := make([]byte, 0, len(left)+1)
+		for _, n := range left {
+			state = append(state, byte(n))
+		}
+		state = append(state, byte(last))
+		if c, ok := memo[string(state)]; ok {
+			return c
+		}
+
+		best := -1
+		for k, kind := range kinds {
+			if left[k] == 0 || !fits(kind, next) {
+				continue
+			}
+			left[k]--
+			rest := cheapest(next+len(kind.sessions), kind.difficulty)
+			left[k]++
+			if rest >= 0 && (best < 0 || cost(kind, next, last)+rest < best) {
+				best = cost(kind, next, last) + rest
+			}
+		}
+		memo[string(state)] = best
+		return best
+	}
+
+	// Of the cheapest orders, take the one that uses the earliest placed
+	// unit at every step, so a day that cannot be improved is kept. Units of
+	// a kind are used in placed order.
+	var best []int
+	for next, last := 0, 0; next < len(blocks); {
+		want := cheapest(next, last)
+		choice := -1
+		for k, kind := range kinds {
+			if left[k] == 0 || !fits(kind, next) {
+				continue
+			}
+			left[k]--
+			rest := cheapest(next+len(kind.sessions), kind.difficulty)
+			left[k]++
+			if rest < 0 || cost(kind, next, last)+rest != want {
+				continue
+			}
+			if choice < 0 || members[k][len(members[k])-left[k]] < members[choice][len(members[choice])-left[choice]] {
+				choice = k
+			}
+		}
+		best = append(best, members[choice][len(members[choice])-left[choice]])
+		left[choice]--
+		next, last = next+len(kinds[choice].sessions), kinds[choice].difficulty
+	}
+
+	bookIDs := make([]int64, 0, len(sessions))
+	for _, u := range best {
+		for _, ss := range units[u].sessions {
+			bookIDs = append(bookIDs, ss.BookID)
+		}
+	}
+	for i, ss := range sessions {
+		ss.BookID = bookIDs[i]
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+// day returns sessions of the given books in consecutive blocks from 08:00.
+func day(bookIDs ...int64) []*store.StudySession {
+	times := []string{"08:00:00", "09:40:00", "11:20:00", "13:00:00", "14:40:00", "16:20:00"}
+	var sessions []*store.StudySession
+	for i, bookID := range bookIDs {
+		sessions = append(sessions, &store.StudySession{BookID: bookID, StartTime: times[i], EndTime: times[i+1]})
+	}
+	return sessions
+}
+
+func books(sessions []*store.StudySession) []int64 {
+	var bookIDs []int64
+	for _, ss := range sessions {
+		bookIDs = append(bookIDs, ss.BookID)
+	}
+	return bookIDs
+}
+
+func TestOrderDay(t *testing.T) {
+	const (
+		math       = 1
+		physics    = 2
+		chemistry  = 3
+		literature = 4
+		religion   = 5
+	)
+	difficulties := map[int64]int{
+		math:       store.DifficultyHeavy,
+		physics:    store.DifficultyHeavy,
+		chemistry:  store.DifficultyHeavy,
+		literature: store.DifficultyLight,
+		religion:   store.DifficultyLight,
+	}
+	first := &store.TemplateRule{PrioritySlot: sql.NullString{String: "first", Valid: true}}
+	morning := &store.TemplateRule{TimePreference: sql.NullString{String: "morning", Valid: true}}
+	afternoon := &store.TemplateRule{TimePreference: sql.NullString{String: "afternoon", Valid: true}}
+	consecutive := &store.TemplateRule{ConsecutiveSessions: sql.NullBool{Bool: true, Valid: true}}
+
+	tests := []struct {
+		name     string
+		sessions []*store.StudySession
+		rules    map[int64]*store.TemplateRule
+		want     []int64
+	}{
+		{
+			name:     "heavy books are separated",
+			sessions: day(math, physics, literature, religion),
+			want:     []int64{math, literature, physics, religion},
+		},
+		{
+			name:     "a day that cannot improve is kept",
+			sessions: day(math, literature, physics),
+			want:     []int64{math, literature, physics},
+		},
+		{
+			name:     "priority first stays first",
+			sessions: day(physics, math, literature),
+			rules:    map[int64]*store.TemplateRule{physics: first},
+			want:     []int64{physics, literature, math},
+		},
+		{
+			name:     "a met time preference stays met",
+			sessions: day(math, physics, literature, chemistry),
+			rules:    map[int64]*store.TemplateRule{math: morning, physics: morning},
+			// both heavy books must stay before noon, so they stay together
+			want: []int64{math, physics, literature, chemistry},
+		},
+		{
+			name:     "an unmet time preference is met when possible",
+			sessions: day(literature, math, religion, physics),
+			rules:    map[int64]*store.TemplateRule{literature: afternoon},
+			want:     []int64{math, religion, physics, literature},
+		},
+		{
+			name:     "consecutive sessions stay together",
+			sessions: day(physics, physics, math, literature),
+			rules:    map[int64]*store.TemplateRule{physics: consecutive},
+			want:     []int64{physics, physics, literature, math},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := orderDay(tt.sessions, difficulties, tt.rules); err != nil {
+				t.Fatal(err)
+			}
+			if got := books(tt.sessions); !slices.Equal(got, tt.want) {
+				t.Errorf("got books %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderDayKeepsConsecutiveSessionsAdjacent(t *testing.T) {
+	// 13:00-14:40 is not a block of this day, so the pair of book 2 cannot
+	// move to where it would start before the gap and end after it
+	sessions := day(1, 2, 1)
+	sessions = append(sessions,
+		&store.StudySession{BookID: 2, StartTime: "14:40:00", EndTime: "16:20:00"},
+		&store.StudySession{BookID: 2, StartTime: "16:20:00", EndTime: "18:00:00"},
+	)
+	rules := map[int64]*store.TemplateRule{2: {ConsecutiveSessions: sql.NullBool{Bool: true, Valid: true}}}
+	difficulties := map[int64]int{1: store.DifficultyHeavy, 2: store.DifficultyLight}
+
+	if err := orderDay(sessions, difficulties, rules); err != nil {
+		t.Fatal(err)
+	}
+	if got := books(sessions); !slices.Equal(got, []int64{1, 2, 1, 2, 2}) {
+		t.Errorf("got books %v, want the day unchanged", got)
+	}
+}
+
+func TestOrderDayOrdersAFullDayQuickly(t *testing.T) {
+	// 13 blocks from midnight, the most a day holds, with the heavy books
+	// placed first
+	difficulties := make(map[int64]int)
+	var sessions []*store.StudySession
+	start := time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 13 {
+		bookID := int64(i + 1)
+		switch {
+		case i < 5:
+			difficulties[bookID] = store.DifficultyHeavy
+		case i < 9:
+			difficulties[bookID] = store.DifficultyMedium
+		default:
+			difficulties[bookID] = store.DifficultyLight
+		}
+		end := start.Add(blockDuration)
+		sessions = append(sessions, &store.StudySession{BookID: bookID, StartTime: start.Format("15:04:05"), EndTime: end.Format("15:04:05")})
+		start = end
+	}
+	morning := &store.TemplateRule{TimePreference: sql.NullString{String: "morning", Valid: true}}
+	rules := map[int64]*store.TemplateRule{12: morning, 13: morning}
+
+	began := time.Now()
+	if err := orderDay(sessions, difficulties, rules); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(began); elapsed > time.Second {
+		t.Errorf("ordering a full day took %v", elapsed)
+	}
+
+	got := books(sessions)
+	for i := 1; i < len(got); i++ {
+		if difficulties[got[i-1]] == store.DifficultyHeavy && difficulties[got[i]] == store.DifficultyHeavy {
+			t.Errorf("heavy books %d and %d are back to back in %v", got[i-1], got[i], got)
+		}
+	}
+	sorted := slices.Sorted(slices.Values(got))
+	if !slices.Equal(sorted, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13}) {
+		t.Errorf("got books %v, want each book once", got)
+	}
+}
@@ -0,0 +1,295 @@
+package scheduler
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+// DaySessions is one day of a weekly plan with its study sessions.
+type DaySessions struct {
+	Date     time.Time
+	Sessions []*store.StudySession
+}
+
+// QualityMetric is one aspect of a week's quality. Score runs from 0 (worst)
+// to 1 (best).
+type QualityMetric struct {
+	Name        string  `json:"name"`
+	Score       float64 `json:"score"`
+	Explanation string  `json:"explanation"`
+}
+
+type BookQuality struct {
+	BookID    int64  `json:"book_id"`
+	BookTitle string `json:"book_title"`
+	// Target is nil when the weekly plan has no frequency for the book.
+	Target                *int `json:"target"`
+	Scheduled             int  `json:"scheduled"`
+	Unscheduled           int  `json:"unscheduled"`
+	Days                  int  `json:"days"`
+	ConsecutiveDayRepeats int  `json:"consecutive_day_repeats"`
+}
+
+type DayLoad struct {
+	Date         time.Time `json:"date"`
+	Sessions     int       `json:"sessions"`
+	StudyMinutes int       `json:"study_minutes"`
+	GapMinutes   int       `json:"gap_minutes"`
+}
+
+type QualityReport struct {
+	// Score is the mean of the metric scores, from 0 to 100. Metrics that
+	// do not apply to the week are left out.
+	Score              int             `json:"score"`
+	Metrics            []QualityMetric `json:"metrics"`
+	Books              []BookQuality   `json:"books"`
+	Days               []DayLoad       `json:"days"`
+	PreferenceSessions int             `json:"preference_sessions"`
+	PreferencesMet     int             `json:"preferences_met"`
+	DailyLoadMean      float64         `json:"daily_load_mean_minutes"`
+	DailyLoadStdDev    float64         `json:"daily_load_stddev_minutes"`
+	LongestGapMinutes  int             `json:"longest_gap_minutes"`
+}
+
+// meetsTimePreference reports whether a block starting at hour suits a
+// template rule's time preference.
+func meetsTimePreference(preference string, hour int) bool {
+	return (preference == "morning" && hour < 12) || (preference == "afternoon" && hour >= 12)
+}
+
+// parseSessionClock reads a study session's TIME column.
+func parseSessionClock(value string) (time.Time, error) {
+	t, err := time.Parse("15:04:05", value)
+	if err != nil {
+		return time.Parse("15:04", value)
+	}
+	return t, nil
+}
+
+// AssessWeek scores the study sessions of the week starting at
+// startDateOfWeek. Frequencies are the plan's targets per book and rules
+// supply the time preferences; either may be empty, in which case the
+// metrics that need them are left unscored. Titles name the books in the
+// explanations.
+func AssessWeek(startDateOfWeek time.Time, days []DaySessions, frequencies []*store.SubjectFrequency, rules []*store.TemplateRule, titles map[int64]string) (*QualityReport, error) {
+	report := &QualityReport{Metrics: []QualityMetric{}, Books: []BookQuality{}, Days: []DayLoad{}}
+
+	preferences := make(map[int64]string)
+	for _, rule := range rules {
+		if rule.TimePreference.Valid {
+			preferences[rule.BookID] = rule.TimePreference.String
+		}
+	}
+
+	type daySession struct {
+		bookID     int64
+		start, end time.Time
+	}
+
+	byDate := make(map[time.Time][]daySession)
+	bookDates := make(map[int64][]time.Time)
+	scheduled := make(map[int64]int)
+	for _, day := range days {
+		date := time.Date(day.Date.Year(), day.Date.Month(), day.Date.Day(), 0, 0, 0, 0, time.UTC)
+		for _, ss := range day.Sessions {
+			start, err := parseSessionClock(ss.StartTime)
+			if err != nil {
+				return nil, fmt.Errorf("study session %d: %w", ss.ID, err)
+			}
+			end, err := parseSessionClock(ss.EndTime)
+			if err != nil {
+				return nil, fmt.Errorf("study session %d: %w", ss.ID, err)
+			}
+			byDate[date] = append(byDate[date], daySession{ss.BookID, start, end})
+
+			scheduled[ss.BookID]++
+			if !slices.ContainsFunc(bookDates[ss.BookID], date.Equal) {
+				bookDates[ss.BookID] = append(bookDates[ss.BookID], date)
+			}
+			if preference, ok := preferences[ss.BookID]; ok {
+				report.PreferenceSessions++
+				if meetsTimePreference(preference, start.Hour()) {
+					report.PreferencesMet++
+				}
+			}
+		}
+	}
+
+	// daily load and gaps, over every study day of the week
+	var loads []float64
+	var gapMinutes, spanMinutes int
+	for i := 0; i < 7; i++ {
+		current := startDateOfWeek.AddDate(0, 0, i)
+		date := time.Date(current.Year(), current.Month(), current.Day(), 0, 0, 0, 0, time.UTC)
+		if date.Weekday() == time.Friday {
+			continue
+		}
+
+		sessions := byDate[date]
+		sort.Slice(sessions, func(i, j int) bool { return sessions[i].start.Before(sessions[j].start) })
+
+		load := DayLoad{Date: date, Sessions: len(sessions)}
+		for j, s := range sessions {
+			load.StudyMinutes += int(s.end.Sub(s.start).Minutes())
+			if j > 0 {
+				if gap := int(s.start.Sub(sessions[j-1].end).Minutes()); gap > 0 {
+					load.GapMinutes += gap
+					report.LongestGapMinutes = max(report.LongestGapMinutes, gap)
+				}
+			}
+		}
+		if len(sessions) > 1 {
+			gapMinutes += load.GapMinutes
+			spanMinutes += int(sessions[len(sessions)-1].end.Sub(sessions[0].start).Minutes())
+		}
+		report.Days = append(report.Days, load)
+		loads = append(loads, float64(load.StudyMinutes))
+	}
+
+	// per-book figures, for every book that has a target or a session
+	targets := make(map[int64]int)
+	for _, sf := range frequencies {
+		targets[sf.BookID] = sf.FrequencyPerWeek
+	}
+	bookIDs := slices.Sorted(maps.Keys(scheduled))
+	for bookID := range targets {
+		if _, ok := scheduled[bookID]; !ok {
+			bookIDs = append(bookIDs, bookID)
+		}
+	}
+	slices.Sort(bookIDs)
+
+	var spreadSum float64
+	var spreadBooks, repeats, avoidablePairs, targetTotal, metTotal int
+	var clustered, repeated, short []string
+	for _, bookID := range bookIDs {
+		title := titles[bookID]
+		if title == "" {
+			title = fmt.Sprintf("book %d", bookID)
+		}
+
+		dates := bookDates[bookID]
+		slices.SortFunc(dates, time.Time.Compare)
+		bq := BookQuality{BookID: bookID, BookTitle: titles[bookID], Scheduled: scheduled[bookID], Days: len(dates)}
+		for i := 1; i < len(dates); i++ {
+			if dates[i].Sub(dates[i-1]) == 24*time.Hour {
+				bq.ConsecutiveDayRepeats++
+			}
+		}
+
+		if target, ok := targets[bookID]; ok {
+			bq.Target = &target
+			bq.Unscheduled = max(target-bq.Scheduled, 0)
+			targetTotal += target
+			metTotal += min(bq.Scheduled, target)
+			if bq.Unscheduled > 0 {
+				short = append(short, fmt.Sprintf("%s (%d of %d)", title, bq.Scheduled, target))
+			}
+		}
+
+		if bq.Scheduled > 0 {
+			ideal := min(bq.Scheduled, StudyDaysPerWeek)
+			spreadSum += float64(bq.Days) / float64(ideal)
+			spreadBooks++
+			if bq.Days < ideal {
+				clustered = append(clustered, fmt.Sprintf("%s (%d sessions on %d days)", title, bq.Scheduled, bq.Days))
+			}
+
+			// on a six-day week at most three days avoid touching each other,
+			// so repeats beyond that are forced
+			forced := max(bq.Days-(StudyDaysPerWeek+1)/2, 0)
+			if bq.ConsecutiveDayRepeats > forced {
+				repeated = append(repeated, title)
+			}
+			repeats += max(bq.ConsecutiveDayRepeats-forced, 0)
+			avoidablePairs += max(bq.Days-1-forced, 0)
+		}
+		report.Books = append(report.Books, bq)
+	}
+
+	if spreadBooks > 0 {
+		explanation := "Every subject is spread over as many days as it has sessions."
+		if len(clustered) > 0 {
+			explanation = "Some subjects are bunched onto fewer days than they could use: " + strings.Join(clustered, ", ") + "."
+		}
+		report.addMetric("spread", spreadSum/float64(spreadBooks), explanation)
+	}
+
+	if spreadBooks > 0 {
+		score := 1.0
+		explanation := "No subject is studied on back-to-back days more often than the week forces."
+		if avoidablePairs > 0 {
+			score = 1 - float64(repeats)/float64(avoidablePairs)
+		}
+		if len(repeated) > 0 {
+			explanation = "These subjects are studied on back-to-back days when the week leaves room to alternate: " + strings.Join(repeated, ", ") + "."
+		}
+		report.addMetric("consecutive_days", score, explanation)
+	}
+
+	if report.PreferenceSessions > 0 {
+		explanation := fmt.Sprintf("%d of %d sessions with a time preference fall in the preferred part of the day.", report.PreferencesMet, report.PreferenceSessions)
+		report.addMetric("time_preferences", float64(report.PreferencesMet)/float64(report.PreferenceSessions), explanation)
+	}
+
+	if targetTotal > 0 {
+		explanation := "Every subject got all of its planned sessions."
+		if len(short) > 0 {
+			explanation = fmt.Sprintf("%d planned sessions did not fit into the week: ", targetTotal-metTotal) + strings.Join(short, ", ") + "."
+		}
+		report.addMetric("unscheduled", float64(metTotal)/float64(targetTotal), explanation)
+	}
+
+	var total float64
+	for _, load := range loads {
+		total += load
+	}
+	if total > 0 {
+		mean := total / float64(len(loads))
+		var variance float64
+		for _, load := range loads {
+			variance += (load - mean) * (load - mean)
+		}
+		variance /= float64(len(loads))
+		report.DailyLoadMean = math.Round(mean*10) / 10
+		report.DailyLoadStdDev = math.Round(math.Sqrt(variance)*10) / 10
+
+		busiest := slices.MaxFunc(report.Days, func(a, b DayLoad) int { return a.StudyMinutes - b.StudyMinutes })
+		lightest := slices.MinFunc(report.Days, func(a, b DayLoad) int { return a.StudyMinutes - b.StudyMinutes })
+		explanation := fmt.Sprintf("Study days average %.0f minutes; the busiest is %s with %d minutes and the lightest %s with %d.",
+			mean, busiest.Date.Weekday(), busiest.StudyMinutes, lightest.Date.Weekday(), lightest.StudyMinutes)
+		report.addMetric("daily_load", max(1-math.Sqrt(variance)/mean, 0), explanation)
+	}
+
+	if spanMinutes > 0 {
+		explanation := "Sessions follow each other without idle time in between."
+		if gapMinutes > 0 {
+			explanation = fmt.Sprintf("%d minutes of the study days sit idle between sessions; the longest gap is %d minutes. Gaps usually come from unavailable times.", gapMinutes, report.LongestGapMinutes)
+		}
+		report.addMetric("gaps", 1-float64(gapMinutes)/float64(spanMinutes), explanation)
+	}
+
+	var scoreSum float64
+	var scored int
+	for _, metric := range report.Metrics {
+		scoreSum += metric.Score
+		scored++
+	}
+	if scored > 0 {
+		report.Score = int(math.Round(100 * scoreSum / float64(scored)))
+	}
+
+	return report, nil
+}
+
+func (r *QualityReport) addMetric(name string, score float64, explanation string) {
+	score = math.Round(score*1000) / 1000
+	r.Metrics = append(r.Metrics, QualityMetric{Name: name, Score: score, Explanation: explanation})
+}
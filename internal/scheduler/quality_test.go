@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+func TestAssessWeek(t *testing.T) {
+	saturday := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	session := func(bookID int64, start, end string) *store.StudySession {
+		return &store.StudySession{BookID: bookID, StartTime: start, EndTime: end}
+	}
+	days := []DaySessions{
+		{Date: saturday, Sessions: []*store.StudySession{
+			session(1, "08:00:00", "09:40:00"),
+			session(2, "09:40:00", "11:20:00"),
+			session(2, "13:00:00", "14:40:00"),
+		}},
+		{Date: saturday.AddDate(0, 0, 1), Sessions: []*store.StudySession{session(1, "08:00:00", "09:40:00")}},
+		{Date: saturday.AddDate(0, 0, 2), Sessions: []*store.StudySession{session(1, "14:00:00", "15:40:00")}},
+	}
+	frequencies := []*store.SubjectFrequency{
+		{BookID: 1, FrequencyPerWeek: 3},
+		{BookID: 2, FrequencyPerWeek: 3},
+	}
+	rules := []*store.TemplateRule{{BookID: 1, TimePreference: sql.NullString{String: "morning", Valid: true}}}
+
+	report, err := AssessWeek(saturday, days, frequencies, rules, map[int64]string{1: "Math", 2: "Physics"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{
+		// Math is on three days out of three, Physics on one out of two
+		"spread": 0.75,
+		// Math runs Saturday to Monday when it could have alternated
+		"consecutive_days": 0,
+		"time_preferences": 0.667,
+		"unscheduled":      0.833,
+		// Saturday carries most of the week
+		"daily_load": 0,
+		// 100 idle minutes in Saturday's 400
+		"gaps": 0.75,
+	}
+	if len(report.Metrics) != len(want) {
+		t.Fatalf("got %d metrics, want %d: %+v", len(report.Metrics), len(want), report.Metrics)
+	}
+	for _, metric := range report.Metrics {
+		if score, ok := want[metric.Name]; !ok || metric.Score != score {
+			t.Errorf("%s: score %v, want %v", metric.Name, metric.Score, score)
+		}
+		if metric.Explanation == "" {
+			t.Errorf("%s: no explanation", metric.Name)
+		}
+	}
+	if report.Score != 50 {
+		t.Errorf("score %d, want 50", report.Score)
+	}
+
+	if len(report.Days) != StudyDaysPerWeek {
+		t.Errorf("got %d days, want %d without Friday", len(report.Days), StudyDaysPerWeek)
+	}
+	if report.LongestGapMinutes != 100 {
+		t.Errorf("longest gap %d, want 100", report.LongestGapMinutes)
+	}
+
+	physics := report.Books[1]
+	if physics.Unscheduled != 1 || physics.Days != 1 || physics.Target == nil || *physics.Target != 3 {
+		t.Errorf("physics %+v, want 1 of 3 sessions unscheduled on 1 day", physics)
+	}
+	if maths := report.Books[0]; maths.ConsecutiveDayRepeats != 2 {
+		t.Errorf("math repeats %d, want 2", maths.ConsecutiveDayRepeats)
+	}
+}
+
+func TestAssessWeekWithoutTargets(t *testing.T) {
+	saturday := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+
+	report, err := AssessWeek(saturday, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Metrics) != 0 || report.Score != 0 {
+		t.Errorf("empty week: got %+v, want no metrics", report)
+	}
+
+	days := []DaySessions{{Date: saturday, Sessions: []*store.StudySession{{BookID: 1, StartTime: "8am", EndTime: "10am"}}}}
+	if _, err := AssessWeek(saturday, days, nil, nil, nil); err == nil {
+		t.Error("unreadable session time: got no error")
+	}
+}
@@ -0,0 +1,233 @@
+package scheduler
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+var ErrNoStudyDays = errors.New("there are no study days left before the exam")
+
+// StudyDaysPerWeek is the number of days the scheduler places sessions on;
+// Friday is always a rest day.
+const StudyDaysPerWeek = 6
+
+// WeekStart returns midnight of the last firstDay on or before date, which
+// is where the week containing date begins.
+func WeekStart(date time.Time, firstDay time.Weekday) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	offset := (int(day.Weekday()) - int(firstDay) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+type RoadmapOptions struct {
+	Today          time.Time
+	ExamDate       time.Time
+	ReviewDays     int
+	BlocksPerWeek  int
+	FirstDayOfWeek time.Weekday
+}
+
+type BookFrequency struct {
+	BookID           int64 `json:"book_id"`
+	FrequencyPerWeek int   `json:"frequency_per_week"`
+}
+
+type RoadmapWeek struct {
+	StartDateOfWeek time.Time       `json:"start_date_of_week"`
+	NewStudyDays    int             `json:"new_study_days"`
+	ReviewDays      int             `json:"review_days"`
+	CapacityBlocks  int             `json:"capacity_blocks"`
+	PlannedBlocks   int             `json:"planned_blocks"`
+	OverflowBlocks  int             `json:"overflow_blocks"`
+	LessonIDs       []int64         `json:"lesson_ids"`
+	Frequencies     []BookFrequency `json:"subject_frequencies"`
+}
+
+type Roadmap struct {
+	ReviewStartDate time.Time      `json:"review_start_date"`
+	TotalLessons    int            `json:"total_lessons"`
+	Weeks           []*RoadmapWeek `json:"weeks"`
+}
+
+// lessonBlocks is the number of blocks needed to study a lesson once.
+func lessonBlocks(lesson *store.Lesson) int {
+	if !lesson.EstimatedStudyTimeMinutes.Valid || lesson.EstimatedStudyTimeMinutes.Int64 <= 0 {
+		return 1
+	}
+	return int(math.Ceil(float64(lesson.EstimatedStudyTimeMinutes.Int64) / blockDuration.Minutes()))
+}
+
+// BuildRoadmap plans the weeks from today up to the day before the exam. The
+// scope lessons are interleaved across books and spread over the new-material
+// days in proportion to each week's study days, and the last ReviewDays days
+// are kept for reviewing every scope book. A week whose lessons need more
+// blocks than its capacity has its frequencies scaled down to the capacity,
+// and the difference is reported as its OverflowBlocks.
+func BuildRoadmap(lessons []*store.Lesson, opts RoadmapOptions) (*Roadmap, error) {
+	today := time.Date(opts.Today.Year(), opts.Today.Month(), opts.Today.Day(), 0, 0, 0, 0, time.UTC)
+	examDate := time.Date(opts.ExamDate.Year(), opts.ExamDate.Month(), opts.ExamDate.Day(), 0, 0, 0, 0, time.UTC)
+	reviewStart := examDate.AddDate(0, 0, -opts.ReviewDays)
+	if reviewStart.Before(today) {
+		reviewStart = today
+	}
+
+	roadmap := &Roadmap{ReviewStartDate: reviewStart, TotalLessons: len(lessons)}
+
+	for weekStart := WeekStart(today, opts.FirstDayOfWeek); weekStart.Before(examDate); weekStart = weekStart.AddDate(0, 0, 7) {
+		week := &RoadmapWeek{StartDateOfWeek: weekStart, LessonIDs: []int64{}}
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if day.Weekday() == time.Friday || day.Before(today) || !day.Before(examDate) {
+				continue
+			}
+			if day.Before(reviewStart) {
+				week.NewStudyDays++
+			} else {
+				week.ReviewDays++
+			}
+		}
+		week.CapacityBlocks = (week.NewStudyDays + week.ReviewDays) * opts.BlocksPerWeek / StudyDaysPerWeek
+		roadmap.Weeks = append(roadmap.Weeks, week)
+	}
+
+	totalNewDays, totalDays := 0, 0
+	for _, week := range roadmap.Weeks {
+		totalNewDays += week.NewStudyDays
+		totalDays += week.NewStudyDays + week.ReviewDays
+	}
+	if totalDays == 0 {
+		return nil, ErrNoStudyDays
+	}
+
+	ordered := interleaveByBook(lessons)
+	newBlocks := make([]map[int64]int, len(roadmap.Weeks))
+	for i := range newBlocks {
+		newBlocks[i] = make(map[int64]int)
+	}
+
+	// Hand out lessons in order, moving to the next week once the current one
+	// has received its proportional share of the lessons.
+	if totalNewDays == 0 {
+		totalNewDays = totalDays
+		for _, week := range roadmap.Weeks {
+			week.NewStudyDays, week.ReviewDays = week.NewStudyDays+week.ReviewDays, 0
+		}
+	}
+	weekIndex, cumulativeDays := 0, 0
+	for i, lesson := range ordered {
+		for weekIndex < len(roadmap.Weeks)-1 {
+			quota := (cumulativeDays + roadmap.Weeks[weekIndex].NewStudyDays) * len(ordered)
+			if i*totalNewDays < quota {
+				break
+			}
+			cumulativeDays += roadmap.Weeks[weekIndex].NewStudyDays
+			weekIndex++
+		}
+		week := roadmap.Weeks[weekIndex]
+		week.LessonIDs = append(week.LessonIDs, lesson.ID)
+		newBlocks[weekIndex][lesson.BookID] += lessonBlocks(lesson)
+	}
+
+	bookLessons := make(map[int64]int)
+	for _, lesson := range lessons {
+		bookLessons[lesson.BookID]++
+	}
+
+	for i, week := range roadmap.Weeks {
+		blocks := newBlocks[i]
+		if week.ReviewDays > 0 {
+			reviewBlocks := week.ReviewDays * opts.BlocksPerWeek / StudyDaysPerWeek
+			for bookID, extra := range distributeProportionally(bookLessons, reviewBlocks) {
+				blocks[bookID] += extra
+			}
+		}
+
+		// Every lesson takes at least a block, so a short week can be handed
+		// more than it has room for.
+		planned := 0
+		for _, frequency := range blocks {
+			planned += frequency
+		}
+		if planned > week.CapacityBlocks {
+			week.OverflowBlocks = planned - week.CapacityBlocks
+			blocks = distributeProportionally(blocks, week.CapacityBlocks)
+		}
+
+		for bookID, frequency := range blocks {
+			if frequency > 0 {
+				week.Frequencies = append(week.Frequencies, BookFrequency{BookID: bookID, FrequencyPerWeek: frequency})
+				week.PlannedBlocks += frequency
+			}
+		}
+		sort.Slice(week.Frequencies, func(a, b int) bool {
+			return week.Frequencies[a].BookID < week.Frequencies[b].BookID
+		})
+	}
+
+	return roadmap, nil
+}
+
+// interleaveByBook orders lessons round-robin across books, keeping each
+// book's lessons in their original order, so that every week mixes subjects.
+func interleaveByBook(lessons []*store.Lesson) []*store.Lesson {
+	var bookOrder []int64
+	byBook := make(map[int64][]*store.Lesson)
+	for _, lesson := range lessons {
+		if _, ok := byBook[lesson.BookID]; !ok {
+			bookOrder = append(bookOrder, lesson.BookID)
+		}
+		byBook[lesson.BookID] = append(byBook[lesson.BookID], lesson)
+	}
+
+	ordered := make([]*store.Lesson, 0, len(lessons))
+	for len(ordered) < len(lessons) {
+		for _, bookID := range bookOrder {
+			if queue := byBook[bookID]; len(queue) > 0 {
+				ordered = append(ordered, queue[0])
+				byBook[bookID] = queue[1:]
+			}
+		}
+	}
+	return ordered
+}
+
+// distributeProportionally splits total between the keys in proportion to
+// their weights using the largest-remainder method. Ties go to the lower key.
+func distributeProportionally(weights map[int64]int, total int) map[int64]int {
+	result := make(map[int64]int)
+	sumWeights := 0
+	var keys []int64
+	for key, weight := range weights {
+		if weight > 0 {
+			keys = append(keys, key)
+			sumWeights += weight
+		}
+	}
+	if sumWeights == 0 || total <= 0 {
+		return result
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	remainders := make(map[int64]int)
+	assigned := 0
+	for _, key := range keys {
+		share := total * weights[key]
+		result[key] = share / sumWeights
+		remainders[key] = share % sumWeights
+		assigned += result[key]
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return remainders[keys[i]] > remainders[keys[j]]
+	})
+	for i := 0; assigned < total; i++ {
+		result[keys[i%len(keys)]]++
+		assigned++
+	}
+
+	return result
+}
@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+func TestBuildRoadmapKeepsWeeksWithinCapacity(t *testing.T) {
+	lessons := []*store.Lesson{
+		{ID: 1, BookID: 1},
+		{ID: 2, BookID: 1},
+		{ID: 3, BookID: 2},
+		{ID: 4, BookID: 2},
+	}
+
+	// Thursday is the only study day left before a Saturday exam
+	roadmap, err := BuildRoadmap(lessons, RoadmapOptions{
+		Today:          time.Date(2026, time.October, 22, 0, 0, 0, 0, time.UTC),
+		ExamDate:       time.Date(2026, time.October, 24, 0, 0, 0, 0, time.UTC),
+		BlocksPerWeek:  12,
+		FirstDayOfWeek: time.Saturday,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roadmap.Weeks) != 1 {
+		t.Fatalf("got %d weeks, want 1", len(roadmap.Weeks))
+	}
+
+	week := roadmap.Weeks[0]
+	if week.CapacityBlocks != 2 || week.PlannedBlocks != 2 || week.OverflowBlocks != 2 {
+		t.Errorf("capacity %d, planned %d, overflow %d; want 2, 2 and 2",
+			week.CapacityBlocks, week.PlannedBlocks, week.OverflowBlocks)
+	}
+	want := []BookFrequency{{BookID: 1, FrequencyPerWeek: 1}, {BookID: 2, FrequencyPerWeek: 1}}
+	if len(week.Frequencies) != len(want) || week.Frequencies[0] != want[0] || week.Frequencies[1] != want[1] {
+		t.Errorf("got frequencies %v, want %v", week.Frequencies, want)
+	}
+	if len(week.LessonIDs) != len(lessons) {
+		t.Errorf("got %d lessons, want all %d", len(week.LessonIDs), len(lessons))
+	}
+}
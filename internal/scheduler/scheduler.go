@@ -2,13 +2,19 @@ package scheduler
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
 	"sort"
 	"time"
 
 	"github.com/Behehap/Alberta/internal/store"
 )
 
+const blockDuration = 100 * time.Minute
+
 type Scheduler struct {
 	Store           *store.Storage
 	TemplateMatcher *TemplateMatcher
@@ -21,111 +27,101 @@ func NewScheduler(s *store.Storage) *Scheduler {
 	}
 }
 
-func generateTimeSlots(date time.Time, dayStartTime time.Time, dayEndTime time.Time, blockDuration time.Duration) []struct {
-	Start time.Time
-	End   time.Time
-} {
-	var slots []struct {
-		Start time.Time
-		End   time.Time
-	}
-	currentSlotStart := time.Date(date.Year(), date.Month(), date.Day(), dayStartTime.Hour(), dayStartTime.Minute(), dayStartTime.Second(), 0, time.Local)
-	dayEndAdjusted := time.Date(date.Year(), date.Month(), date.Day(), dayEndTime.Hour(), dayEndTime.Minute(), dayEndTime.Second(), 0, time.Local)
-
-	for currentSlotStart.Before(dayEndAdjusted) {
-		slotEnd := currentSlotStart.Add(blockDuration)
-		if slotEnd.After(dayEndAdjusted) {
-			break
-		}
-		slots = append(slots, struct {
-			Start time.Time
-			End   time.Time
-		}{Start: currentSlotStart, End: slotEnd})
-		currentSlotStart = slotEnd
-	}
-	return slots
-}
-
+// GenerateWeeklyPlan lays out the study sessions of a weekly plan in place of
+// the ones not completed yet, so that generating again with the same seed
+// leaves the same week. Demand that does not fit into the week is not an
+// error; the result reports it with the reasons and what would make it fit.
 func (s *Scheduler) GenerateWeeklyPlan(
 	ctx context.Context,
 	studentID int64,
 	weeklyPlanID int64,
 	startDateOfWeek time.Time,
+	loc *time.Location,
 	totalStudyBlocksPerWeek int,
 	unavailableTimes []*store.UnavailableTime,
 	subjectFrequencies []*store.SubjectFrequency,
 	templateRules []*store.TemplateRule,
-) error {
-	const blockDuration = 100 * time.Minute
-
+	seed int64,
+) (*GenerationResult, error) {
 	weeklyPlan, err := s.Store.WeeklyPlans.Get(ctx, weeklyPlanID)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve weekly plan: %w", err)
+		return nil, fmt.Errorf("failed to retrieve weekly plan: %w", err)
 	}
 
-	dayStartDefault := time.Date(0, 1, 1, 8, 0, 0, 0, time.UTC)
-	dayEndDefault := time.Date(0, 1, 1, 22, 0, 0, 0, time.UTC)
+	dayStart, dayEnd := dayBounds(weeklyPlan.DayStartTime)
 
-	if weeklyPlan.DayStartTime.Valid {
-		dayStartDefault = weeklyPlan.DayStartTime.Time
+	// The generation replaces the plan's unfinished sessions, and the
+	// completed ones it keeps hold on to their blocks
+	completed, err := s.completedSessionTimes(ctx, weeklyPlanID)
+	if err != nil {
+		return nil, err
 	}
+	unavailableTimes = append(slices.Clip(unavailableTimes), completed...)
 
-	availableSlotsPerDay := make(map[time.Weekday][]struct {
-		Start time.Time
-		End   time.Time
-	})
+	availableSlotsPerDay := buildAvailableSlots(startDateOfWeek, dayStart, dayEnd, unavailableTimes, loc)
 
+	capacity := weekCapacity{dayStart: dayStart}
 	for i := 0; i < 7; i++ {
 		currentDate := startDateOfWeek.AddDate(0, 0, i)
-		currentWeekday := currentDate.Weekday()
-
-		dailySlots := generateTimeSlots(currentDate, dayStartDefault, dayEndDefault, blockDuration)
-		var filteredSlots []struct {
-			Start time.Time
-			End   time.Time
+		day := currentDate.Weekday()
+		if day == time.Friday {
+			capacity.restDay = len(availableSlotsPerDay[day])
+			continue
 		}
-
-		for _, slot := range dailySlots {
-			isUnavailable := false
-			for _, ut := range unavailableTimes {
-				customDayOfWeek := (int(currentWeekday) + 1) % 7
-				if customDayOfWeek == ut.DayOfWeek || (!ut.IsRecurring && ut.DayOfWeek == -1) {
-					unavailableStart := time.Date(slot.Start.Year(), slot.Start.Month(), slot.Start.Day(), ut.StartTime.Hour(), ut.StartTime.Minute(), ut.StartTime.Second(), 0, time.Local)
-					unavailableEnd := time.Date(slot.Start.Year(), slot.Start.Month(), slot.Start.Day(), ut.EndTime.Hour(), ut.EndTime.Minute(), ut.EndTime.Second(), 0, time.Local)
-
-					if slot.Start.Before(unavailableEnd) && slot.End.After(unavailableStart) {
-						isUnavailable = true
-						break
-					}
-				}
-			}
-			if !isUnavailable {
-				filteredSlots = append(filteredSlots, slot)
-			}
+		blocked := len(generateTimeSlots(currentDate, dayStart, dayEnd, blockDuration, loc)) - len(availableSlotsPerDay[day])
+		capacity.unavailable += blocked
+		if blocked > capacity.busiestBlocked {
+			capacity.busiestDay, capacity.busiestBlocked = day, blocked
 		}
-		availableSlotsPerDay[currentWeekday] = filteredSlots
 	}
 
 	subjectsToSchedule := make(map[int64]int)
 	for _, sf := range subjectFrequencies {
 		subjectsToSchedule[sf.BookID] = sf.FrequencyPerWeek
 	}
+	// books without a priority are considered in an order drawn from seed,
+	// so that the same input and seed always yield the same calendar
+	bookIDs := shuffledBookIDs(slices.Sorted(maps.Keys(subjectsToSchedule)), seed)
 
 	rulesMap := make(map[int64]*store.TemplateRule)
 	for _, rule := range templateRules {
 		rulesMap[rule.BookID] = rule
 	}
 
+	// Each study day first gets an even share of the week's blocks. Once
+	// no day can take more within its share, the shares are dropped and
+	// only the plan's daily limits apply.
+	limits := LimitsOf(weeklyPlan)
+	var studyDays []int
+	free := make(map[int]int)
+	for i := 0; i < 7; i++ {
+		day := startDateOfWeek.AddDate(0, 0, i).Weekday()
+		if day == time.Friday {
+			continue
+		}
+		studyDays = append(studyDays, i)
+		free[i] = len(availableSlotsPerDay[day])
+		if limits.MaxBlocksPerDay > 0 {
+			free[i] = min(free[i], limits.MaxBlocksPerDay)
+		}
+	}
+	quotas := dayQuotas(studyDays, free, totalStudyBlocksPerWeek)
+	balanced := true
+	placed := newDayPlacements()
+	// planned holds each day's sessions in block order until the ordering
+	// pass has arranged them
+	planned := make(map[int][]*store.StudySession)
+
 	scheduledCount := 0
-	days := []time.Weekday{time.Saturday, time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
 
 	for scheduledCount < totalStudyBlocksPerWeek {
 		initialScheduledCount := scheduledCount
-		for _, day := range days {
+		for i := 0; i < 7; i++ {
+			currentDate := startDateOfWeek.AddDate(0, 0, i)
+			day := currentDate.Weekday()
 			if day == time.Friday {
 				continue
 			}
-			currentDate := startDateOfWeek.AddDate(0, 0, int(day-startDateOfWeek.Weekday()+7)%7)
 
 			dailyPlan, err := s.Store.DailyPlans.GetByWeeklyPlanAndDate(ctx, weeklyPlanID, currentDate)
 			if err != nil {
@@ -135,7 +131,7 @@ func (s *Scheduler) GenerateWeeklyPlan(
 				}
 				err = s.Store.DailyPlans.Insert(ctx, dailyPlan)
 				if err != nil {
-					return fmt.Errorf("failed to create daily plan for %s: %w", currentDate.Format("2006-01-02"), err)
+					return nil, fmt.Errorf("failed to create daily plan for %s: %w", currentDate.Format("2006-01-02"), err)
 				}
 			}
 
@@ -144,11 +140,19 @@ func (s *Scheduler) GenerateWeeklyPlan(
 				continue
 			}
 
+			dayLimit := limits.MaxBlocksPerDay
+			if balanced {
+				dayLimit = quotas[i]
+			}
+			dayFull := func() bool {
+				return (balanced || dayLimit > 0) && placed.perDay[i] >= dayLimit
+			}
+
 			var prioritizedBooks []int64
 			var otherBooks []int64
 
-			for bookID, remainingFreq := range subjectsToSchedule {
-				if remainingFreq > 0 {
+			for _, bookID := range bookIDs {
+				if subjectsToSchedule[bookID] > 0 {
 					rule, hasRule := rulesMap[bookID]
 					if hasRule {
 						if rule.PrioritySlot.Valid && rule.PrioritySlot.String == "first" {
@@ -157,9 +161,7 @@ func (s *Scheduler) GenerateWeeklyPlan(
 						}
 						if rule.TimePreference.Valid {
 							if len(slots) > 0 {
-								slotHour := slots[0].Start.Hour()
-								if (rule.TimePreference.String == "morning" && slotHour < 12) ||
-									(rule.TimePreference.String == "afternoon" && slotHour >= 12) {
+								if meetsTimePreference(rule.TimePreference.String, slots[0].Start.Hour()) {
 									prioritizedBooks = append(prioritizedBooks, bookID)
 									continue
 								}
@@ -182,10 +184,10 @@ func (s *Scheduler) GenerateWeeklyPlan(
 			prioritizedBooks = append(prioritizedBooks, otherBooks...)
 
 			for _, selectedBookID := range prioritizedBooks {
-				if scheduledCount >= totalStudyBlocksPerWeek {
+				if scheduledCount >= totalStudyBlocksPerWeek || dayFull() {
 					break
 				}
-				if subjectsToSchedule[selectedBookID] <= 0 {
+				if subjectsToSchedule[selectedBookID] <= 0 || !placed.allows(i, selectedBookID, limits) {
 					continue
 				}
 
@@ -200,16 +202,15 @@ func (s *Scheduler) GenerateWeeklyPlan(
 						EndTime:     slots[0].End.Format("15:04:05"),
 						IsCompleted: false,
 					}
-					err = s.Store.StudySessions.Insert(ctx, studySession1)
-					if err != nil {
-						return fmt.Errorf("failed to insert consecutive study session 1: %w", err)
-					}
+					planned[i] = append(planned[i], studySession1)
 					subjectsToSchedule[selectedBookID]--
 					slots = slots[1:]
 					availableSlotsPerDay[day] = slots
 					scheduledCount++
+					placed.add(i, selectedBookID)
 
-					if scheduledCount < totalStudyBlocksPerWeek && subjectsToSchedule[selectedBookID] > 0 && len(slots) >= 1 {
+					if scheduledCount < totalStudyBlocksPerWeek && subjectsToSchedule[selectedBookID] > 0 && len(slots) >= 1 &&
+						!dayFull() && placed.allows(i, selectedBookID, limits) {
 						studySession2 := &store.StudySession{
 							DailyPlanID: dailyPlan.ID,
 							BookID:      selectedBookID,
@@ -217,14 +218,12 @@ func (s *Scheduler) GenerateWeeklyPlan(
 							EndTime:     slots[0].End.Format("15:04:05"),
 							IsCompleted: false,
 						}
-						err = s.Store.StudySessions.Insert(ctx, studySession2)
-						if err != nil {
-							return fmt.Errorf("failed to insert consecutive study session 2: %w", err)
-						}
+						planned[i] = append(planned[i], studySession2)
 						subjectsToSchedule[selectedBookID]--
 						slots = slots[1:]
 						availableSlotsPerDay[day] = slots
 						scheduledCount++
+						placed.add(i, selectedBookID)
 					}
 					continue
 				}
@@ -238,26 +237,119 @@ func (s *Scheduler) GenerateWeeklyPlan(
 						EndTime:     slot.End.Format("15:04:05"),
 						IsCompleted: false,
 					}
-					err = s.Store.StudySessions.Insert(ctx, studySession)
-					if err != nil {
-						return fmt.Errorf("failed to insert study session: %w", err)
-					}
+					planned[i] = append(planned[i], studySession)
 
 					subjectsToSchedule[selectedBookID]--
 					slots = slots[1:]
 					availableSlotsPerDay[day] = slots
 					scheduledCount++
+					placed.add(i, selectedBookID)
 				}
 			}
 		}
 
 		if scheduledCount == initialScheduledCount && scheduledCount < totalStudyBlocksPerWeek {
+			if balanced {
+				balanced = false
+				continue
+			}
 			break
 		}
 	}
 
-	return nil
-} // THIS IS THE MISSING CLOSING BRACE FOR GenerateWeeklyPlan
+	for _, i := range studyDays {
+		capacity.free += len(availableSlotsPerDay[startDateOfWeek.AddDate(0, 0, i).Weekday()])
+	}
+
+	difficulties := make(map[int64]int)
+	for bookID := range subjectsToSchedule {
+		book, err := s.Store.Books.Get(ctx, bookID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve book %d: %w", bookID, err)
+		}
+		difficulties[bookID] = book.Difficulty
+	}
+
+	var sessions []*store.StudySession
+	for _, i := range studyDays {
+		err = orderDay(planned[i], difficulties, rulesMap)
+		if err != nil {
+			return nil, fmt.Errorf("failed to order the sessions of %s: %w", startDateOfWeek.AddDate(0, 0, i).Format("2006-01-02"), err)
+		}
+		sessions = append(sessions, planned[i]...)
+	}
+
+	err = s.Store.StudySessions.ReplaceUnfinishedForWeeklyPlan(ctx, weeklyPlanID, sessions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert study sessions: %w", err)
+	}
+
+	err = s.Store.WeeklyPlans.SetGenerationSeed(ctx, weeklyPlanID, seed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record generation seed: %w", err)
+	}
+
+	result := &GenerationResult{Seed: seed, SessionsCreated: scheduledCount, Books: []BookPlacement{}}
+	for _, bookID := range slices.Sorted(maps.Keys(subjectsToSchedule)) {
+		placement := BookPlacement{BookID: bookID, Unplaced: subjectsToSchedule[bookID]}
+		for _, sf := range subjectFrequencies {
+			if sf.BookID == bookID {
+				placement.Requested = sf.FrequencyPerWeek
+			}
+		}
+		placement.Placed = placement.Requested - placement.Unplaced
+		result.Requested += placement.Requested
+		result.Unplaced += placement.Unplaced
+		result.Books = append(result.Books, placement)
+	}
+	result.explainUnplaced(capacity, totalStudyBlocksPerWeek)
+
+	return result, nil
+}
+
+// completedSessionTimes returns the completed sessions of a weekly plan as
+// unavailable times on their dates.
+func (s *Scheduler) completedSessionTimes(ctx context.Context, weeklyPlanID int64) ([]*store.UnavailableTime, error) {
+	dailyPlans, _, err := s.Store.DailyPlans.GetAllForWeeklyPlan(ctx, weeklyPlanID, store.QueryOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve daily plans: %w", err)
+	}
+
+	isCompleted := true
+	var times []*store.UnavailableTime
+	for _, dp := range dailyPlans {
+		sessions, _, err := s.Store.StudySessions.GetAllForDailyPlan(ctx, dp.ID, store.QueryOptions{Completed: &isCompleted})
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve the sessions of daily plan %d: %w", dp.ID, err)
+		}
+		for _, ss := range sessions {
+			start, err := time.Parse(time.TimeOnly, ss.StartTime)
+			if err != nil {
+				return nil, fmt.Errorf("study session %d: %w", ss.ID, err)
+			}
+			end, err := time.Parse(time.TimeOnly, ss.EndTime)
+			if err != nil {
+				return nil, fmt.Errorf("study session %d: %w", ss.ID, err)
+			}
+			times = append(times, &store.UnavailableTime{
+				DayOfWeek:    -1,
+				StartTime:    start,
+				EndTime:      end,
+				SpecificDate: sql.NullTime{Time: dp.PlanDate, Valid: true},
+			})
+		}
+	}
+	return times, nil
+}
+
+// shuffledBookIDs returns a permutation of bookIDs determined by seed alone.
+func shuffledBookIDs(bookIDs []int64, seed int64) []int64 {
+	rng := rand.New(rand.NewPCG(uint64(seed), 0))
+	rng.Shuffle(len(bookIDs), func(i, j int) {
+		bookIDs[i], bookIDs[j] = bookIDs[j], bookIDs[i]
+	})
+	return bookIDs
+}
 
 // FindClosestTemplate delegates to TemplateMatcher
 func (s *Scheduler) FindClosestTemplate(ctx context.Context, gradeID, majorID int64, targetBlocks int) (*store.ScheduleTemplate, error) {
@@ -0,0 +1,340 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden calendars in testdata/generate")
+
+// generateCase is a fixture in testdata/generate. Books are numbered from 1
+// in the order they are listed, and rules and frequencies refer to them by
+// that number.
+type generateCase struct {
+	Timezone     string `json:"timezone"`
+	WeekStart    string `json:"week_start"`
+	DayStartTime string `json:"day_start_time"`
+	// TotalBlocks defaults to the sum of the frequencies.
+	TotalBlocks                int      `json:"total_blocks"`
+	Seed                       int64    `json:"seed"`
+	MaxBlocksPerDay            int64    `json:"max_blocks_per_day"`
+	MaxBookBlocksPerDay        int64    `json:"max_book_blocks_per_day"`
+	MinDaysBetweenBookSessions int64    `json:"min_days_between_book_sessions"`
+	Books                      []string `json:"books"`
+	// Difficulties, when given, are the books' difficulties in the same
+	// order; books default to medium.
+	Difficulties []int `json:"difficulties"`
+	Frequencies  []struct {
+		Book      int64 `json:"book"`
+		Frequency int   `json:"frequency"`
+	} `json:"frequencies"`
+	Rules []struct {
+		Book                int64  `json:"book"`
+		PrioritySlot        string `json:"priority_slot"`
+		TimePreference      string `json:"time_preference"`
+		ConsecutiveSessions *bool  `json:"consecutive_sessions"`
+	} `json:"rules"`
+	UnavailableTimes []struct {
+		DayOfWeek    int    `json:"day_of_week"`
+		SpecificDate string `json:"specific_date"`
+		Start        string `json:"start"`
+		End          string `json:"end"`
+	} `json:"unavailable_times"`
+}
+
+// TestGenerateWeeklyPlan runs every fixture in testdata/generate through
+// GenerateWeeklyPlan on an in-memory store and compares the calendar it
+// produces, followed by its account of unplaced blocks, with the .golden
+// file next to the fixture. Run with -update to
+// rewrite the golden files after an intended change to the scheduler.
+func TestGenerateWeeklyPlan(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "generate", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures in testdata/generate")
+	}
+
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".json")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var tc generateCase
+			if err := json.Unmarshal(data, &tc); err != nil {
+				t.Fatalf("parsing %s: %v", fixture, err)
+			}
+
+			got := runGenerateCase(t, &tc)
+			// a second run on a fresh store must lay out the same calendar
+			if again := runGenerateCase(t, &tc); again != got {
+				t.Fatalf("two runs differ:\n%s\n---\n%s", got, again)
+			}
+
+			golden := strings.TrimSuffix(fixture, ".json") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v; run go test ./internal/scheduler -run TestGenerateWeeklyPlan -update to create it", err)
+			}
+			if !bytes.Equal([]byte(got), want) {
+				t.Errorf("calendar differs from %s:\n--- want\n%s--- got\n%s", golden, want, got)
+			}
+		})
+	}
+}
+
+// runGenerateCase generates the fixture's week and renders the calendar.
+func runGenerateCase(t *testing.T, tc *generateCase) string {
+	t.Helper()
+	return newGenerateRun(t, tc).generate(t)
+}
+
+// generateRun is the week of a fixture on its own in-memory store.
+type generateRun struct {
+	storage          *store.Storage
+	student          *store.Student
+	weeklyPlan       *store.WeeklyPlan
+	totalBlocks      int
+	unavailableTimes []*store.UnavailableTime
+	frequencies      []*store.SubjectFrequency
+	rules            []*store.TemplateRule
+	seed             int64
+}
+
+// newGenerateRun stores the fixture's curriculum, student and weekly plan.
+func newGenerateRun(t *testing.T, tc *generateCase) *generateRun {
+	t.Helper()
+	ctx := context.Background()
+
+	db := store.NewMemoryDB()
+	storage := store.NewMemoryStorage(db)
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	must(db.InsertGrade(&store.Grade{Name: "grade"}))
+	must(db.InsertMajor(&store.Major{Name: "major"}))
+	for i, title := range tc.Books {
+		book := &store.Book{Title: title, InherentGradeLevelID: 1}
+		if i < len(tc.Difficulties) {
+			book.Difficulty = tc.Difficulties[i]
+		}
+		must(db.InsertBook(book))
+	}
+
+	student := &store.Student{FirstName: "Test", LastName: "Student", Email: "student@example.com", GradeID: 1, MajorID: 1, Timezone: tc.Timezone}
+	must(storage.Students.Insert(ctx, student))
+
+	weekStart, err := time.Parse(time.DateOnly, tc.WeekStart)
+	must(err)
+	wp := &store.WeeklyPlan{StudentID: student.ID, StartDateOfWeek: weekStart}
+	if tc.DayStartTime != "" {
+		dayStart, err := time.Parse("15:04", tc.DayStartTime)
+		must(err)
+		wp.DayStartTime = sql.NullTime{Time: dayStart, Valid: true}
+	}
+	if tc.MaxBlocksPerDay > 0 {
+		wp.MaxBlocksPerDay = sql.NullInt64{Int64: tc.MaxBlocksPerDay, Valid: true}
+	}
+	if tc.MaxBookBlocksPerDay > 0 {
+		wp.MaxBookBlocksPerDay = sql.NullInt64{Int64: tc.MaxBookBlocksPerDay, Valid: true}
+	}
+	if tc.MinDaysBetweenBookSessions > 0 {
+		wp.MinDaysBetweenBookSessions = sql.NullInt64{Int64: tc.MinDaysBetweenBookSessions, Valid: true}
+	}
+	must(storage.WeeklyPlans.Insert(ctx, wp))
+
+	var unavailableTimes []*store.UnavailableTime
+	for _, u := range tc.UnavailableTimes {
+		start, err := time.Parse("15:04", u.Start)
+		must(err)
+		end, err := time.Parse("15:04", u.End)
+		must(err)
+		ut := &store.UnavailableTime{StudentID: student.ID, DayOfWeek: u.DayOfWeek, StartTime: start, EndTime: end, IsRecurring: true}
+		if u.SpecificDate != "" {
+			date, err := time.Parse(time.DateOnly, u.SpecificDate)
+			must(err)
+			ut.SpecificDate = sql.NullTime{Time: date, Valid: true}
+			ut.IsRecurring = false
+		}
+		unavailableTimes = append(unavailableTimes, ut)
+	}
+
+	totalBlocks := tc.TotalBlocks
+	var frequencies []*store.SubjectFrequency
+	for _, f := range tc.Frequencies {
+		frequencies = append(frequencies, &store.SubjectFrequency{WeeklyPlanID: wp.ID, BookID: f.Book, FrequencyPerWeek: f.Frequency})
+		if tc.TotalBlocks == 0 {
+			totalBlocks += f.Frequency
+		}
+	}
+
+	var rules []*store.TemplateRule
+	for _, r := range tc.Rules {
+		rule := &store.TemplateRule{BookID: r.Book}
+		if r.PrioritySlot != "" {
+			rule.PrioritySlot = sql.NullString{String: r.PrioritySlot, Valid: true}
+		}
+		if r.TimePreference != "" {
+			rule.TimePreference = sql.NullString{String: r.TimePreference, Valid: true}
+		}
+		if r.ConsecutiveSessions != nil {
+			rule.ConsecutiveSessions = sql.NullBool{Bool: *r.ConsecutiveSessions, Valid: true}
+		}
+		rules = append(rules, rule)
+	}
+
+	return &generateRun{
+		storage:          storage,
+		student:          student,
+		weeklyPlan:       wp,
+		totalBlocks:      totalBlocks,
+		unavailableTimes: unavailableTimes,
+		frequencies:      frequencies,
+		rules:            rules,
+		seed:             tc.Seed,
+	}
+}
+
+// generate generates the week and renders the calendar.
+func (r *generateRun) generate(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+
+	s := NewScheduler(r.storage)
+	wp := r.weeklyPlan
+	result, err := s.GenerateWeeklyPlan(ctx, r.student.ID, wp.ID, wp.StartDateOfWeek, r.student.Location(), r.totalBlocks, r.unavailableTimes, r.frequencies, r.rules, r.seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generated, err := r.storage.WeeklyPlans.Get(ctx, wp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !generated.GenerationSeed.Valid || generated.GenerationSeed.Int64 != r.seed {
+		t.Errorf("generation seed %+v, want %d", generated.GenerationSeed, r.seed)
+	}
+
+	return renderCalendar(t, r.storage, wp.ID) + renderResult(result)
+}
+
+// renderResult lists what the generation result says was left unplaced.
+func renderResult(result *GenerationResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "placed %d of %d blocks\n", result.SessionsCreated, result.Requested)
+	for _, book := range result.Books {
+		if book.Unplaced > 0 {
+			fmt.Fprintf(&b, "  book %d: %d of %d placed\n", book.BookID, book.Placed, book.Requested)
+		}
+	}
+	for _, reason := range result.Reasons {
+		fmt.Fprintf(&b, "reason %s: %d blocks\n", reason.Reason, reason.Blocks)
+	}
+	for _, suggestion := range result.Suggestions {
+		fmt.Fprintf(&b, "suggestion %s: %s\n", suggestion.Code, suggestion.Message)
+	}
+	return b.String()
+}
+
+// renderCalendar lists the sessions of a weekly plan day by day.
+func renderCalendar(t *testing.T, storage *store.Storage, weeklyPlanID int64) string {
+	t.Helper()
+	ctx := context.Background()
+
+	dailyPlans, _, err := storage.DailyPlans.GetAllForWeeklyPlan(ctx, weeklyPlanID, store.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	total := 0
+	for _, dp := range dailyPlans {
+		fmt.Fprintf(&b, "%s %s\n", dp.PlanDate.Format(time.DateOnly), dp.PlanDate.Weekday())
+		sessions, _, err := storage.StudySessions.GetAllForDailyPlan(ctx, dp.ID, store.QueryOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ss := range sessions {
+			book, err := storage.Books.Get(ctx, ss.BookID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fmt.Fprintf(&b, "  %s-%s %s\n", ss.StartTime[:5], ss.EndTime[:5], book.Title)
+		}
+		total += len(sessions)
+	}
+	fmt.Fprintf(&b, "%d sessions\n", total)
+	return b.String()
+}
+
+// TestRegenerateWeeklyPlan generates a week again with its seed, which
+// replaces the sessions of the earlier generation except the completed ones.
+func TestRegenerateWeeklyPlan(t *testing.T) {
+	ctx := context.Background()
+	data, err := os.ReadFile(filepath.Join("testdata", "generate", "basic.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tc generateCase
+	if err := json.Unmarshal(data, &tc); err != nil {
+		t.Fatal(err)
+	}
+
+	run := newGenerateRun(t, &tc)
+	first := run.generate(t)
+	if again := run.generate(t); again != first {
+		t.Fatalf("generating again with the same seed changed the week:\n%s\n---\n%s", first, again)
+	}
+
+	dailyPlans, _, err := run.storage.DailyPlans.GetAllForWeeklyPlan(ctx, run.weeklyPlan.ID, store.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saturday := dailyPlans[0].ID
+	sessions, _, err := run.storage.StudySessions.GetAllForDailyPlan(ctx, saturday, store.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := sessions[0]
+	done.IsCompleted = true
+	if err := run.storage.StudySessions.Update(ctx, done); err != nil {
+		t.Fatal(err)
+	}
+
+	run.generate(t)
+	if kept, err := run.storage.StudySessions.Get(ctx, done.ID); err != nil || !kept.IsCompleted {
+		t.Fatalf("completed session after generating again: %+v, %v", kept, err)
+	}
+	sessions, _, err = run.storage.StudySessions.GetAllForDailyPlan(ctx, saturday, store.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ss := range sessions {
+		if ss.ID != done.ID && ss.StartTime < done.EndTime && ss.EndTime > done.StartTime {
+			t.Errorf("session %s-%s overlaps the completed session %s-%s", ss.StartTime, ss.EndTime, done.StartTime, done.EndTime)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+// timeSlot is one study block. Its times are wall-clock times in the
+// student's location.
+type timeSlot struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Block is a study block of a day, as clock times on 0000-01-01 UTC.
+type Block struct {
+	Start time.Time
+	End   time.Time
+}
+
+// dayBounds returns the clock times between which study blocks are laid out,
+// starting at the weekly plan's day start time when it has one.
+func dayBounds(dayStartTime sql.NullTime) (time.Time, time.Time) {
+	dayStart := time.Date(0, 1, 1, 8, 0, 0, 0, time.UTC)
+	dayEnd := time.Date(0, 1, 1, 22, 0, 0, 0, time.UTC)
+	if dayStartTime.Valid {
+		dayStart = dayStartTime.Time
+	}
+	return dayStart, dayEnd
+}
+
+// DayBlocks returns the blocks GenerateWeeklyPlan lays out on each day of a
+// weekly plan with the given day start time.
+func DayBlocks(dayStartTime sql.NullTime) []Block {
+	dayStart, dayEnd := dayBounds(dayStartTime)
+
+	var blocks []Block
+	for _, slot := range generateTimeSlots(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC), dayStart, dayEnd, blockDuration, time.UTC) {
+		blocks = append(blocks, Block{Start: slot.Start, End: slot.End})
+	}
+	return blocks
+}
+
+// generateTimeSlots splits the day between dayStartTime and dayEndTime into
+// consecutive blocks. Only the calendar date of date and the clock of the
+// start and end times are used, so the result does not depend on the zone
+// those values were read in.
+func generateTimeSlots(date time.Time, dayStartTime time.Time, dayEndTime time.Time, blockDuration time.Duration, loc *time.Location) []timeSlot {
+	var slots []timeSlot
+	currentSlotStart := time.Date(date.Year(), date.Month(), date.Day(), dayStartTime.Hour(), dayStartTime.Minute(), dayStartTime.Second(), 0, loc)
+	dayEndAdjusted := time.Date(date.Year(), date.Month(), date.Day(), dayEndTime.Hour(), dayEndTime.Minute(), dayEndTime.Second(), 0, loc)
+
+	for currentSlotStart.Before(dayEndAdjusted) {
+		slotEnd := currentSlotStart.Add(blockDuration)
+		if slotEnd.After(dayEndAdjusted) {
+			break
+		}
+		slots = append(slots, timeSlot{Start: currentSlotStart, End: slotEnd})
+		currentSlotStart = slotEnd
+	}
+	return slots
+}
+
+// buildAvailableSlots returns the blocks of each day of the week starting at
+// startDateOfWeek that do not overlap the student's unavailable times, where
+// date-specific entries only apply on their own date. TIME
+// columns carry no zone, so unavailable times are read as wall-clock times
+// in loc, the student's location.
+func buildAvailableSlots(startDateOfWeek, dayStart, dayEnd time.Time, unavailableTimes []*store.UnavailableTime, loc *time.Location) map[time.Weekday][]timeSlot {
+	availableSlotsPerDay := make(map[time.Weekday][]timeSlot)
+
+	for i := 0; i < 7; i++ {
+		currentDate := startDateOfWeek.AddDate(0, 0, i)
+		currentWeekday := currentDate.Weekday()
+
+		dailySlots := generateTimeSlots(currentDate, dayStart, dayEnd, blockDuration, loc)
+		var filteredSlots []timeSlot
+
+		for _, slot := range dailySlots {
+			isUnavailable := false
+			for _, ut := range unavailableTimes {
+				if UnavailableOn(ut, currentDate) {
+					unavailableStart := time.Date(slot.Start.Year(), slot.Start.Month(), slot.Start.Day(), ut.StartTime.Hour(), ut.StartTime.Minute(), ut.StartTime.Second(), 0, loc)
+					unavailableEnd := time.Date(slot.Start.Year(), slot.Start.Month(), slot.Start.Day(), ut.EndTime.Hour(), ut.EndTime.Minute(), ut.EndTime.Second(), 0, loc)
+
+					if slot.Start.Before(unavailableEnd) && slot.End.After(unavailableStart) {
+						isUnavailable = true
+						break
+					}
+				}
+			}
+			if !isUnavailable {
+				filteredSlots = append(filteredSlots, slot)
+			}
+		}
+		availableSlotsPerDay[currentWeekday] = filteredSlots
+	}
+
+	return availableSlotsPerDay
+}
+
+// UnavailableOn reports whether ut applies on date. Recurring entries apply on
+// their weekday, counted from Saturday, and date-specific ones on their date.
+func UnavailableOn(ut *store.UnavailableTime, date time.Time) bool {
+	if ut.SpecificDate.Valid {
+		return sameDate(ut.SpecificDate.Time, date)
+	}
+	customDayOfWeek := (int(date.Weekday()) + 1) % 7
+	return customDayOfWeek == ut.DayOfWeek || (!ut.IsRecurring && ut.DayOfWeek == -1)
+}
+
+func sameDate(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
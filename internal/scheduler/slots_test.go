@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+// serverZones are the server time zones the slot tests run under. The
+// generated slots must only depend on the student's zone.
+var serverZones = []string{"UTC", "Asia/Tehran", "America/Los_Angeles", "Pacific/Kiritimati", "Europe/London"}
+
+func withServerZone(t *testing.T, name string) {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("loading %s: %v", name, err)
+	}
+
+	previous := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = previous })
+}
+
+func clock(hour, minute int) time.Time {
+	return time.Date(0, 1, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func TestBuildAvailableSlotsIgnoresServerZone(t *testing.T) {
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Saturday, as read from a DATE column
+	weekStart := time.Date(2024, time.March, 16, 0, 0, 0, 0, time.UTC)
+	unavailable := []*store.UnavailableTime{
+		// School on Saturday (day_of_week 0) from 07:30 to 13:00
+		{DayOfWeek: 0, StartTime: clock(7, 30), EndTime: clock(13, 0), IsRecurring: true},
+		// A one-off appointment on Monday 2024-03-18 from 10:00 to 12:00
+		{
+			DayOfWeek:    2,
+			StartTime:    clock(10, 0),
+			EndTime:      clock(12, 0),
+			SpecificDate: sql.NullTime{Time: time.Date(2024, time.March, 18, 0, 0, 0, 0, time.UTC), Valid: true},
+		},
+		// The same appointment a week later does not affect this week
+		{
+			DayOfWeek:    2,
+			StartTime:    clock(8, 0),
+			EndTime:      clock(22, 0),
+			SpecificDate: sql.NullTime{Time: time.Date(2024, time.March, 25, 0, 0, 0, 0, time.UTC), Valid: true},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		day     time.Weekday
+		wantHHM []string
+	}{
+		{
+			name:    "unavailable morning is skipped",
+			day:     time.Saturday,
+			wantHHM: []string{"13:00", "14:40", "16:20", "18:00", "19:40"},
+		},
+		{
+			name:    "date-specific entry only blocks its own date",
+			day:     time.Monday,
+			wantHHM: []string{"08:00", "13:00", "14:40", "16:20", "18:00", "19:40"},
+		},
+		{
+			name:    "free day starts at the day start",
+			day:     time.Sunday,
+			wantHHM: []string{"08:00", "09:40", "11:20", "13:00", "14:40", "16:20", "18:00", "19:40"},
+		},
+	}
+
+	for _, zone := range serverZones {
+		t.Run(zone, func(t *testing.T) {
+			withServerZone(t, zone)
+
+			slots := buildAvailableSlots(weekStart, clock(8, 0), clock(22, 0), unavailable, tehran)
+
+			for _, tt := range tests {
+				got := slots[tt.day]
+				if len(got) != len(tt.wantHHM) {
+					t.Fatalf("%s: got %d slots, want %d", tt.name, len(got), len(tt.wantHHM))
+				}
+				for i, slot := range got {
+					if slot.Start.Location() != tehran {
+						t.Errorf("%s: slot %d is in %s, want Asia/Tehran", tt.name, i, slot.Start.Location())
+					}
+					if start := slot.Start.Format("15:04"); start != tt.wantHHM[i] {
+						t.Errorf("%s: slot %d starts at %s, want %s", tt.name, i, start, tt.wantHHM[i])
+					}
+					if slot.End.Sub(slot.Start) != blockDuration {
+						t.Errorf("%s: slot %d lasts %s", tt.name, i, slot.End.Sub(slot.Start))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateTimeSlotsUsesStudentDate(t *testing.T) {
+	// 23:00 UTC on Friday is already Saturday in Tehran, but slots follow the
+	// calendar date of the plan, not the instant.
+	date := time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)
+
+	for _, zone := range serverZones {
+		t.Run(zone, func(t *testing.T) {
+			withServerZone(t, zone)
+
+			for _, student := range []string{"Asia/Tehran", "America/New_York"} {
+				loc, err := time.LoadLocation(student)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				slots := generateTimeSlots(date, clock(20, 0), clock(23, 30), blockDuration, loc)
+				if len(slots) != 2 {
+					t.Fatalf("%s: got %d slots, want 2", student, len(slots))
+				}
+				if day := slots[0].Start.Day(); day != 15 {
+					t.Errorf("%s: first slot on day %d, want 15", student, day)
+				}
+				if got := slots[1].End.Format("15:04"); got != "23:20" {
+					t.Errorf("%s: last slot ends at %s, want 23:20", student, got)
+				}
+			}
+		})
+	}
+}
@@ -20,7 +20,7 @@ func NewTemplateMatcher(store *store.Storage) *TemplateMatcher {
 
 // FindClosestTemplate finds the template with total study blocks closest to target
 func (tm *TemplateMatcher) FindClosestTemplate(ctx context.Context, gradeID, majorID int64, targetBlocks int) (*store.ScheduleTemplate, error) {
-	templates, err := tm.Store.ScheduleTemplates.GetAll(ctx, gradeID, majorID)
+	templates, _, err := tm.Store.ScheduleTemplates.GetAll(ctx, gradeID, majorID, store.QueryOptions{})
 	if err != nil {
 		return nil, err
 	}
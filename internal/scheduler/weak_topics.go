@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"sort"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+// WeakBookIDs returns the distinct books of the ranked weak topics, weakest first.
+func WeakBookIDs(topics []*store.WeakTopic) []int64 {
+	seen := make(map[int64]bool)
+	var bookIDs []int64
+	for _, topic := range topics {
+		if seen[topic.BookID] {
+			continue
+		}
+		seen[topic.BookID] = true
+		bookIDs = append(bookIDs, topic.BookID)
+	}
+	return bookIDs
+}
+
+// BiasFrequencies moves up to extraBlocks blocks from the other books to the
+// weak books, weakest first, without changing the weekly total. A donor book
+// always keeps at least one block, and books missing from freqs are ignored.
+// It returns the number of blocks actually moved.
+func BiasFrequencies(freqs map[int64]int, weakBookIDs []int64, extraBlocks int) int {
+	isWeak := make(map[int64]bool)
+	var targets []int64
+	for _, bookID := range weakBookIDs {
+		if _, ok := freqs[bookID]; ok && !isWeak[bookID] {
+			isWeak[bookID] = true
+			targets = append(targets, bookID)
+		}
+	}
+
+	var donors []int64
+	for bookID := range freqs {
+		if !isWeak[bookID] {
+			donors = append(donors, bookID)
+		}
+	}
+
+	if len(targets) == 0 || len(donors) == 0 {
+		return 0
+	}
+
+	moved := 0
+	for moved < extraBlocks {
+		// Always take from the book with the most blocks left so the
+		// remaining subjects shrink evenly.
+		sort.Slice(donors, func(i, j int) bool {
+			if freqs[donors[i]] != freqs[donors[j]] {
+				return freqs[donors[i]] > freqs[donors[j]]
+			}
+			return donors[i] < donors[j]
+		})
+		donor := donors[0]
+		if freqs[donor] <= 1 {
+			break
+		}
+
+		freqs[donor]--
+		freqs[targets[moved%len(targets)]]++
+		moved++
+	}
+
+	return moved
+}
@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/Behehap/Alberta/internal/store"
+)
+
+func TestWeakBookIDs(t *testing.T) {
+	topics := []*store.WeakTopic{
+		{Rank: 1, BookID: 3},
+		{Rank: 2, BookID: 1},
+		{Rank: 3, BookID: 3},
+		{Rank: 4, BookID: 2},
+	}
+	if got, want := WeakBookIDs(topics), []int64{3, 1, 2}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := WeakBookIDs(nil); len(got) != 0 {
+		t.Errorf("no topics: got %v, want none", got)
+	}
+}
+
+func TestBiasFrequencies(t *testing.T) {
+	tests := []struct {
+		name        string
+		freqs       map[int64]int
+		weakBookIDs []int64
+		extraBlocks int
+		moved       int
+		want        map[int64]int
+	}{
+		{
+			// The fullest donor gives each block, and the weak books take
+			// them in turn, weakest first
+			name:        "round robin from the fullest donor",
+			freqs:       map[int64]int{1: 4, 2: 4, 3: 2, 4: 2},
+			weakBookIDs: []int64{3, 4},
+			extraBlocks: 3,
+			moved:       3,
+			want:        map[int64]int{1: 2, 2: 3, 3: 4, 4: 3},
+		},
+		{
+			name:        "donors keep one block",
+			freqs:       map[int64]int{1: 2, 2: 1, 3: 1},
+			weakBookIDs: []int64{3},
+			extraBlocks: 5,
+			moved:       1,
+			want:        map[int64]int{1: 1, 2: 1, 3: 2},
+		},
+		{
+			name:        "weak books without a frequency are ignored",
+			freqs:       map[int64]int{1: 3, 2: 1},
+			weakBookIDs: []int64{9, 2},
+			extraBlocks: 1,
+			moved:       1,
+			want:        map[int64]int{1: 2, 2: 2},
+		},
+		{
+			name:        "a weak book listed twice takes one turn",
+			freqs:       map[int64]int{1: 5, 2: 1, 3: 1},
+			weakBookIDs: []int64{2, 2, 3},
+			extraBlocks: 2,
+			moved:       2,
+			want:        map[int64]int{1: 3, 2: 2, 3: 2},
+		},
+		{
+			name:        "no donors",
+			freqs:       map[int64]int{1: 3},
+			weakBookIDs: []int64{1},
+			extraBlocks: 2,
+			moved:       0,
+			want:        map[int64]int{1: 3},
+		},
+		{
+			name:        "no weak books",
+			freqs:       map[int64]int{1: 3, 2: 2},
+			extraBlocks: 2,
+			moved:       0,
+			want:        map[int64]int{1: 3, 2: 2},
+		},
+		{
+			name:        "nothing to move",
+			freqs:       map[int64]int{1: 3, 2: 2},
+			weakBookIDs: []int64{2},
+			extraBlocks: 0,
+			moved:       0,
+			want:        map[int64]int{1: 3, 2: 2},
+		},
+	}
+
+	total := func(freqs map[int64]int) int {
+		sum := 0
+		for _, freq := range freqs {
+			sum += freq
+		}
+		return sum
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := total(tt.freqs)
+			moved := BiasFrequencies(tt.freqs, tt.weakBookIDs, tt.extraBlocks)
+			if moved != tt.moved {
+				t.Errorf("moved %d blocks, want %d", moved, tt.moved)
+			}
+			if !maps.Equal(tt.freqs, tt.want) {
+				t.Errorf("got %v, want %v", tt.freqs, tt.want)
+			}
+			if after := total(tt.freqs); after != before {
+				t.Errorf("weekly total went from %d to %d", before, after)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// AnalyticsFilter restricts analytics to daily plans dated within [From, To].
+// A zero value on either side leaves that side unbounded.
+type AnalyticsFilter struct {
+	From time.Time
+	To   time.Time
+}
+
+func (f AnalyticsFilter) args() (sql.NullTime, sql.NullTime) {
+	return sql.NullTime{Time: f.From, Valid: !f.From.IsZero()}, sql.NullTime{Time: f.To, Valid: !f.To.IsZero()}
+}
+
+type ProgressStats struct {
+	PlannedSessions   int      `json:"planned_sessions"`
+	CompletedSessions int      `json:"completed_sessions"`
+	CompletionRate    float64  `json:"completion_rate"`
+	StudyMinutes      int      `json:"study_minutes"`
+	NumTests          int      `json:"num_tests"`
+	NumWrongTests     int      `json:"num_wrong_tests"`
+	TestAccuracy      *float64 `json:"test_accuracy"`
+	AverageScore      *float64 `json:"average_score,omitempty"`
+	ReviewSessions    int      `json:"review_sessions"`
+	NewSessions       int      `json:"new_sessions"`
+}
+
+// Finalize derives the ratio fields from the raw counters.
+func (p *ProgressStats) Finalize() {
+	if p.PlannedSessions > 0 {
+		p.CompletionRate = float64(p.CompletedSessions) / float64(p.PlannedSessions)
+	}
+	if p.NumTests > 0 {
+		accuracy := float64(p.NumTests-p.NumWrongTests) / float64(p.NumTests)
+		p.TestAccuracy = &accuracy
+	}
+}
+
+type BookProgress struct {
+	BookID    int64  `json:"book_id"`
+	BookTitle string `json:"book_title"`
+	ProgressStats
+}
+
+type WeekProgress struct {
+	WeeklyPlanID    int64     `json:"weekly_plan_id"`
+	StartDateOfWeek time.Time `json:"start_date_of_week"`
+	ProgressStats
+}
+
+type AnalyticsModel struct {
+	DB *sql.DB
+}
+
+const progressColumns = `
+        COUNT(ss.id),
+        COUNT(ss.id) FILTER (WHERE ss.is_completed),
+        COALESCE(SUM(EXTRACT(EPOCH FROM (ss.end_time - ss.start_time)) / 60) FILTER (WHERE ss.is_completed), 0)::int,
+        COALESCE(SUM(sr.num_tests), 0),
+        COALESCE(SUM(sr.num_wrong_tests), 0),
+        AVG(sr.session_score)::float8,
+        COUNT(ss.id) FILTER (WHERE ss.is_completed AND sr.is_review),
+        COUNT(ss.id) FILTER (WHERE ss.is_completed AND NOT COALESCE(sr.is_review, FALSE))`
+
+const progressJoins = `
+        FROM study_sessions ss
+        INNER JOIN daily_plans dp ON dp.id = ss.daily_plan_id
+        INNER JOIN weekly_plans wp ON wp.id = dp.weekly_plan_id
+        INNER JOIN books b ON b.id = ss.book_id
+        LEFT JOIN session_reports sr ON sr.study_session_id = ss.id
+        WHERE wp.student_id = $1
+          AND ($2::date IS NULL OR dp.plan_date >= $2::date)
+          AND ($3::date IS NULL OR dp.plan_date <= $3::date)`
+
+func progressScanArgs(p *ProgressStats, avgScore *sql.NullFloat64) []any {
+	return []any{
+		&p.PlannedSessions,
+		&p.CompletedSessions,
+		&p.StudyMinutes,
+		&p.NumTests,
+		&p.NumWrongTests,
+		avgScore,
+		&p.ReviewSessions,
+		&p.NewSessions,
+	}
+}
+
+// GetBookProgress aggregates planned and completed sessions, study time and
+// report results for every book the student has sessions for.
+func (m *AnalyticsModel) GetBookProgress(ctx context.Context, studentID int64, filter AnalyticsFilter) ([]*BookProgress, error) {
+	query := `
+        SELECT b.id, b.title,` + progressColumns + progressJoins + `
+        GROUP BY b.id, b.title
+        ORDER BY b.title`
+
+	from, to := filter.args()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, studentID, from, to)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	var progress []*BookProgress
+	for rows.Next() {
+		var bp BookProgress
+		var avgScore sql.NullFloat64
+		dest := append([]any{&bp.BookID, &bp.BookTitle}, progressScanArgs(&bp.ProgressStats, &avgScore)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, wrapError(err)
+		}
+		if avgScore.Valid {
+			bp.AverageScore = &avgScore.Float64
+		}
+		bp.Finalize()
+		progress = append(progress, &bp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+
+	return progress, nil
+}
+
+// GetWeeklyProgress aggregates the same figures as GetBookProgress, grouped by
+// weekly plan, so that trends can be charted week over week.
+func (m *AnalyticsModel) GetWeeklyProgress(ctx context.Context, studentID int64, filter AnalyticsFilter) ([]*WeekProgress, error) {
+	query := `
+        SELECT wp.id, wp.start_date_of_week,` + progressColumns + progressJoins + `
+        GROUP BY wp.id, wp.start_date_of_week
+        ORDER BY wp.start_date_of_week`
+
+	from, to := filter.args()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, studentID, from, to)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	var progress []*WeekProgress
+	for rows.Next() {
+		var wp WeekProgress
+		var avgScore sql.NullFloat64
+		dest := append([]any{&wp.WeeklyPlanID, &wp.StartDateOfWeek}, progressScanArgs(&wp.ProgressStats, &avgScore)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, wrapError(err)
+		}
+		if avgScore.Valid {
+			wp.AverageScore = &avgScore.Float64
+		}
+		wp.Finalize()
+		progress = append(progress, &wp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+
+	return progress, nil
+}
+
+// GetStudyDates returns, in ascending order, every distinct date on which the
+// student completed at least one study session.
+func (m *AnalyticsModel) GetStudyDates(ctx context.Context, studentID int64, filter AnalyticsFilter) ([]time.Time, error) {
+	query := `
+        SELECT DISTINCT dp.plan_date
+        FROM study_sessions ss
+        INNER JOIN daily_plans dp ON dp.id = ss.daily_plan_id
+        INNER JOIN weekly_plans wp ON wp.id = dp.weekly_plan_id
+        WHERE wp.student_id = $1
+          AND ss.is_completed
+          AND ($2::date IS NULL OR dp.plan_date >= $2::date)
+          AND ($3::date IS NULL OR dp.plan_date <= $3::date)
+        ORDER BY dp.plan_date`
+
+	from, to := filter.args()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, studentID, from, to)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	var dates []time.Time
+	for rows.Next() {
+		var date time.Time
+		if err := rows.Scan(&date); err != nil {
+			return nil, wrapError(err)
+		}
+		dates = append(dates, date)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+
+	return dates, nil
+}
+
+// WeakTopicParams controls weak-topic detection. Only the RecentSessions most
+// recent reported sessions of each topic are considered, and a topic is weak
+// when its wrong-answer ratio exceeds MaxWrongRatio (given at least MinTests
+// answered questions) or its average score is below MinScore.
+type WeakTopicParams struct {
+	RecentSessions int
+	MaxWrongRatio  float64
+	MinTests       int
+	MinScore       float64
+}
+
+// WeakTopic is a book, or a lesson of a book when sessions were linked to
+// one, whose recent results fall below the detection thresholds.
+type WeakTopic struct {
+	Rank          int      `json:"rank"`
+	BookID        int64    `json:"book_id"`
+	BookTitle     string   `json:"book_title"`
+	LessonID      *int64   `json:"lesson_id,omitempty"`
+	LessonName    string   `json:"lesson_name,omitempty"`
+	Sessions      int      `json:"sessions"`
+	NumTests      int      `json:"num_tests"`
+	NumWrongTests int      `json:"num_wrong_tests"`
+	WrongRatio    *float64 `json:"wrong_ratio"`
+	AverageScore  *float64 `json:"average_score"`
+	Severity      float64  `json:"severity"`
+}
+
+// GetWeakTopics returns the student's weak topics ordered from weakest to
+// strongest. Severity is the larger of the relative overshoot of the wrong
+// ratio and the relative shortfall of the score against their thresholds.
+// A session score of zero means "not scored" and is ignored.
+func (m *AnalyticsModel) GetWeakTopics(ctx context.Context, studentID int64, params WeakTopicParams) ([]*WeakTopic, error) {
+	query := `
+        WITH recent AS (
+            SELECT ss.book_id, ss.lesson_id, sr.num_tests, sr.num_wrong_tests,
+                   NULLIF(sr.session_score, 0) AS session_score,
+                   ROW_NUMBER() OVER (
+                       PARTITION BY ss.book_id, ss.lesson_id
+                       ORDER BY dp.plan_date DESC, ss.start_time DESC
+                   ) AS recency
+            FROM session_reports sr
+            INNER JOIN study_sessions ss ON ss.id = sr.study_session_id
+            INNER JOIN daily_plans dp ON dp.id = ss.daily_plan_id
+            INNER JOIN weekly_plans wp ON wp.id = dp.weekly_plan_id
+            WHERE wp.student_id = $1
+        ), totals AS (
+            SELECT book_id, lesson_id,
+                   COUNT(*) AS sessions,
+                   COALESCE(SUM(num_tests), 0) AS num_tests,
+                   COALESCE(SUM(num_wrong_tests), 0) AS num_wrong_tests,
+                   AVG(session_score)::float8 AS average_score
+            FROM recent
+            WHERE recency <= $2
+            GROUP BY book_id, lesson_id
+        ), scored AS (
+            SELECT t.*,
+                   CASE WHEN t.num_tests >= $3 AND t.num_tests > 0
+                        THEN t.num_wrong_tests::float8 / t.num_tests END AS wrong_ratio
+            FROM totals t
+        )
+        SELECT s.book_id, b.title, s.lesson_id, COALESCE(l.name, ''),
+               s.sessions, s.num_tests, s.num_wrong_tests, s.wrong_ratio, s.average_score,
+               GREATEST(
+                   COALESCE((s.wrong_ratio - $4) / NULLIF($4, 0), 0),
+                   COALESCE(($5 - s.average_score) / NULLIF($5, 0), 0)
+               ) AS severity
+        FROM scored s
+        INNER JOIN books b ON b.id = s.book_id
+        LEFT JOIN lessons l ON l.id = s.lesson_id
+        WHERE s.wrong_ratio > $4 OR s.average_score < $5
+        ORDER BY severity DESC, s.wrong_ratio DESC NULLS LAST, b.title, s.lesson_id NULLS FIRST`
+
+	args := []any{studentID, params.RecentSessions, params.MinTests, params.MaxWrongRatio, params.MinScore}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	var topics []*WeakTopic
+	for rows.Next() {
+		var wt WeakTopic
+		var lessonID sql.NullInt64
+		var wrongRatio, averageScore sql.NullFloat64
+		err := rows.Scan(
+			&wt.BookID,
+			&wt.BookTitle,
+			&lessonID,
+			&wt.LessonName,
+			&wt.Sessions,
+			&wt.NumTests,
+			&wt.NumWrongTests,
+			&wrongRatio,
+			&averageScore,
+			&wt.Severity,
+		)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+		if lessonID.Valid {
+			wt.LessonID = &lessonID.Int64
+		}
+		if wrongRatio.Valid {
+			wt.WrongRatio = &wrongRatio.Float64
+		}
+		if averageScore.Valid {
+			wt.AverageScore = &averageScore.Float64
+		}
+		wt.Rank = len(topics) + 1
+		topics = append(topics, &wt)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+
+	return topics, nil
+}
@@ -4,13 +4,22 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 )
 
+// Book difficulties, the cognitive load of studying a book.
+const (
+	DifficultyLight  = 1
+	DifficultyMedium = 2
+	DifficultyHeavy  = 3
+)
+
 type Book struct {
 	ID                   int64  `json:"id"`
 	Title                string `json:"title"`
 	InherentGradeLevelID int64  `json:"inherent_grade_level_id"`
+	Difficulty           int    `json:"difficulty"`
 }
 
 type BookModel struct {
@@ -22,7 +31,7 @@ func (m *BookModel) Get(ctx context.Context, id int64) (*Book, error) {
 		return nil, ErrorNotFound
 	}
 
-	query := `SELECT id, title, inherent_grade_level_id FROM books WHERE id = $1`
+	query := `SELECT id, title, inherent_grade_level_id, difficulty FROM books WHERE id = $1`
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
@@ -32,54 +41,106 @@ func (m *BookModel) Get(ctx context.Context, id int64) (*Book, error) {
 		&book.ID,
 		&book.Title,
 		&book.InherentGradeLevelID,
+		&book.Difficulty,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrorNotFound
 		}
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	return &book, nil
 }
 
+// GetByTitle returns the book with exactly the given title. When several
+// books share a title the oldest one wins.
+func (m *BookModel) GetByTitle(ctx context.Context, title string) (*Book, error) {
+	query := `
+        SELECT id, title, inherent_grade_level_id, difficulty
+        FROM books
+        WHERE title = $1
+        ORDER BY id
+        LIMIT 1`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var book Book
+	err := m.DB.QueryRowContext(ctx, query, title).Scan(
+		&book.ID,
+		&book.Title,
+		&book.InherentGradeLevelID,
+		&book.Difficulty,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrorNotFound
+		}
+		return nil, wrapError(err)
+	}
+
+	return &book, nil
+}
+
+var bookSortColumns = map[string]string{
+	"title": "b.title",
+	"id":    "b.id",
+}
+
 // GetAllForCurriculum gets all books for a specific grade and major.
 // This uses the book_roles table to figure out the right curriculum.
-func (m *BookModel) GetAllForCurriculum(ctx context.Context, gradeID, majorID int64) ([]*Book, error) {
-	query := `
-        SELECT b.id, b.title, b.inherent_grade_level_id
+func (m *BookModel) GetAllForCurriculum(ctx context.Context, gradeID, majorID int64, opts QueryOptions) ([]*Book, Metadata, error) {
+	orderBy, err := opts.orderBy(bookSortColumns, "title", "b.id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), b.id, b.title, b.inherent_grade_level_id, b.difficulty
         FROM books b
         INNER JOIN book_roles br ON b.id = br.book_id
         WHERE br.target_student_grade_id = $1 AND br.major_id = $2
-        ORDER BY b.title`
+        %s
+        LIMIT $3 OFFSET $4`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, gradeID, majorID)
+	args := []any{gradeID, majorID, opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var books []*Book
 	for rows.Next() {
 		var book Book
 		err := rows.Scan(
+			&totalRecords,
 			&book.ID,
 			&book.Title,
 			&book.InherentGradeLevelID,
+			&book.Difficulty,
 		)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, wrapError(err)
 		}
 		books = append(books, &book)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
 	}
 
-	return books, nil
+	return books, metadata, nil
 }
@@ -4,6 +4,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 )
 
@@ -19,26 +20,42 @@ type BookRoleModel struct {
 	DB *sql.DB
 }
 
-func (m *BookRoleModel) GetAllForCurriculum(ctx context.Context, gradeID, majorID int64) ([]*BookRole, error) {
-	query := `
-        SELECT id, target_student_grade_id, major_id, book_id, role
+var bookRoleSortColumns = map[string]string{
+	"id":      "id",
+	"book_id": "book_id",
+	"role":    "role",
+}
+
+func (m *BookRoleModel) GetAllForCurriculum(ctx context.Context, gradeID, majorID int64, opts QueryOptions) ([]*BookRole, Metadata, error) {
+	orderBy, err := opts.orderBy(bookRoleSortColumns, "id", "id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, target_student_grade_id, major_id, book_id, role
         FROM book_roles
-        WHERE target_student_grade_id = $1 AND major_id = $2`
+        WHERE target_student_grade_id = $1 AND major_id = $2
+        %s
+        LIMIT $3 OFFSET $4`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, gradeID, majorID)
+	args := []any{gradeID, majorID, opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var bookRoles []*BookRole
 
 	for rows.Next() {
 		var bookRole BookRole
 		err := rows.Scan(
+			&totalRecords,
 			&bookRole.ID,
 			&bookRole.TargetStudentGradeID,
 			&bookRole.MajorID,
@@ -46,14 +63,19 @@ func (m *BookRoleModel) GetAllForCurriculum(ctx context.Context, gradeID, majorI
 			&bookRole.Role,
 		)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, wrapError(err)
 		}
 		bookRoles = append(bookRoles, &bookRole)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
 	}
 
-	return bookRoles, nil
+	return bookRoles, metadata, nil
 }
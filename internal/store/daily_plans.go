@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -27,7 +28,7 @@ func (m *DailyPlanModel) Insert(ctx context.Context, dp *DailyPlan) error {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&dp.ID)
+	return wrapError(m.DB.QueryRowContext(ctx, query, args...).Scan(&dp.ID))
 }
 
 func (m *DailyPlanModel) Get(ctx context.Context, id int64) (*DailyPlan, error) {
@@ -52,46 +53,67 @@ func (m *DailyPlanModel) Get(ctx context.Context, id int64) (*DailyPlan, error)
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrorNotFound
 		}
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	return &dp, nil
 }
 
-func (m *DailyPlanModel) GetAllForWeeklyPlan(ctx context.Context, weeklyPlanID int64) ([]*DailyPlan, error) {
-	query := `
-        SELECT id, weekly_plan_id, plan_date
+var dailyPlanSortColumns = map[string]string{
+	"plan_date": "plan_date",
+	"id":        "id",
+}
+
+func (m *DailyPlanModel) GetAllForWeeklyPlan(ctx context.Context, weeklyPlanID int64, opts QueryOptions) ([]*DailyPlan, Metadata, error) {
+	orderBy, err := opts.orderBy(dailyPlanSortColumns, "plan_date", "id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, weekly_plan_id, plan_date
         FROM daily_plans
         WHERE weekly_plan_id = $1
-        ORDER BY plan_date`
+          AND ($2::date IS NULL OR plan_date >= $2)
+          AND ($3::date IS NULL OR plan_date <= $3)
+        %s
+        LIMIT $4 OFFSET $5`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, weeklyPlanID)
+	args := []any{weeklyPlanID, opts.from(), opts.to(), opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var plans []*DailyPlan
 	for rows.Next() {
 		var dp DailyPlan
 		err := rows.Scan(
+			&totalRecords,
 			&dp.ID,
 			&dp.WeeklyPlanID,
 			&dp.PlanDate,
 		)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, wrapError(err)
 		}
 		plans = append(plans, &dp)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
-	return plans, nil
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return plans, metadata, nil
 }
 
 func (m *DailyPlanModel) Delete(ctx context.Context, id int64) error {
@@ -105,12 +127,12 @@ func (m *DailyPlanModel) Delete(ctx context.Context, id int64) error {
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -138,7 +160,7 @@ func (m *DailyPlanModel) GetByWeeklyPlanAndDate(ctx context.Context, weeklyPlanI
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrorNotFound
 		}
-		return nil, err
+		return nil, wrapError(err)
 	}
 	return &dp, nil
 }
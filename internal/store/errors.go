@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// The kinds of error store methods return. Errors from the database are
+// wrapped in a *DBError of one of these kinds, so callers check them with
+// errors.Is rather than inspecting driver errors.
+var (
+	ErrorNotFound   = errors.New("resource not found")
+	ErrorConflict   = errors.New("conflicts with an existing resource")
+	ErrorForeignKey = errors.New("refers to a resource that does not exist")
+	ErrorValidation = errors.New("invalid value")
+	ErrorTimeout    = errors.New("the database took too long to respond")
+)
+
+// Conflicts with a meaning of their own.
+var (
+	ErrorDuplicateEmail  = fmt.Errorf("duplicate email: %w", ErrorConflict)
+	ErrorAlreadyEnrolled = fmt.Errorf("already enrolled: %w", ErrorConflict)
+)
+
+// PostgreSQL error codes, see
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+	pqNotNullViolation    = "23502"
+	pqCheckViolation      = "23514"
+	pqQueryCanceled       = "57014"
+	pqDataExceptionClass  = "22"
+)
+
+// DBError is a database error classified by Kind.
+type DBError struct {
+	Kind error
+	// Table, Column and Constraint are set when the database reports them.
+	Table      string
+	Column     string
+	Constraint string
+	Err        error
+}
+
+func (e *DBError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+}
+
+// Unwrap makes both the kind and the underlying error visible to errors.Is
+// and errors.As.
+func (e *DBError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// Field returns the column the error is about, taken from the constraint name
+// when the database does not report the column. Constraints are named the
+// PostgreSQL way, such as study_sessions_book_id_fkey.
+func (e *DBError) Field() string {
+	if e.Column != "" {
+		return e.Column
+	}
+	field := strings.TrimPrefix(e.Constraint, e.Table+"_")
+	for _, suffix := range []string{"_fkey", "_key", "_check"} {
+		field = strings.TrimSuffix(field, suffix)
+	}
+	if field == e.Constraint {
+		return ""
+	}
+	return field
+}
+
+// wrapError classifies database errors and returns every other error as it
+// is. sql.ErrNoRows is left to the caller, for which it does not always mean
+// not found.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var dbErr *DBError
+	if errors.As(err, &dbErr) {
+		return err
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &DBError{Kind: ErrorTimeout, Err: err}
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	dbErr = &DBError{Table: pqErr.Table, Column: pqErr.Column, Constraint: pqErr.Constraint, Err: err}
+	switch {
+	case pqErr.Code == pqUniqueViolation:
+		dbErr.Kind = ErrorConflict
+	case pqErr.Code == pqForeignKeyViolation:
+		dbErr.Kind = ErrorForeignKey
+	case pqErr.Code == pqNotNullViolation, pqErr.Code == pqCheckViolation, pqErr.Code.Class() == pqDataExceptionClass:
+		dbErr.Kind = ErrorValidation
+	case pqErr.Code == pqQueryCanceled:
+		dbErr.Kind = ErrorTimeout
+	default:
+		return err
+	}
+	return dbErr
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type ExamEnrollment struct {
+	ID         int64     `json:"id"`
+	StudentID  int64     `json:"student_id"`
+	ExamID     int64     `json:"exam_id"`
+	EnrolledAt time.Time `json:"enrolled_at"`
+}
+
+type ExamEnrollmentModel struct {
+	DB *sql.DB
+}
+
+// Insert enrolls the student in the exam. It returns ErrorAlreadyEnrolled
+// when the enrollment already exists.
+func (m *ExamEnrollmentModel) Insert(ctx context.Context, ee *ExamEnrollment) error {
+	query := `
+        INSERT INTO exam_enrollments (student_id, exam_id)
+        VALUES ($1, $2)
+        ON CONFLICT (student_id, exam_id) DO NOTHING
+        RETURNING id, enrolled_at`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, ee.StudentID, ee.ExamID).Scan(&ee.ID, &ee.EnrolledAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrorAlreadyEnrolled
+		}
+		return wrapError(err)
+	}
+	return nil
+}
+
+func (m *ExamEnrollmentModel) Get(ctx context.Context, studentID, examID int64) (*ExamEnrollment, error) {
+	if studentID < 1 || examID < 1 {
+		return nil, ErrorNotFound
+	}
+
+	query := `
+        SELECT id, student_id, exam_id, enrolled_at
+        FROM exam_enrollments
+        WHERE student_id = $1 AND exam_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var ee ExamEnrollment
+	err := m.DB.QueryRowContext(ctx, query, studentID, examID).Scan(
+		&ee.ID,
+		&ee.StudentID,
+		&ee.ExamID,
+		&ee.EnrolledAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrorNotFound
+		}
+		return nil, wrapError(err)
+	}
+
+	return &ee, nil
+}
+
+func (m *ExamEnrollmentModel) Delete(ctx context.Context, studentID, examID int64) error {
+	if studentID < 1 || examID < 1 {
+		return ErrorNotFound
+	}
+
+	query := `DELETE FROM exam_enrollments WHERE student_id = $1 AND exam_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, studentID, examID)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ScopeLessonProgress is a student's progress on one lesson of an exam scope.
+type ScopeLessonProgress struct {
+	LessonID         int64      `json:"lesson_id"`
+	LessonName       string     `json:"lesson_name"`
+	BookID           int64      `json:"book_id"`
+	BookTitle        string     `json:"book_title"`
+	StudySessions    int        `json:"study_sessions"`
+	ReviewSessions   int        `json:"review_sessions"`
+	NumTests         int        `json:"num_tests"`
+	NumWrongTests    int        `json:"num_wrong_tests"`
+	TestAccuracy     *float64   `json:"test_accuracy"`
+	LastStudiedDate  *time.Time `json:"last_studied_date,omitempty"`
+	UpcomingSessions int        `json:"upcoming_sessions"`
+}
+
+func (p *ScopeLessonProgress) Studied() bool {
+	return p.StudySessions > 0 || p.ReviewSessions > 0
+}
+
+func (p *ScopeLessonProgress) Reviewed() bool {
+	return p.ReviewSessions > 0
+}
+
+// GetScopeLessonProgress returns one row per lesson in the exam's scope with
+// the student's completed sessions on that lesson and the sessions still
+// planned for it between from and until.
+func (m *AnalyticsModel) GetScopeLessonProgress(ctx context.Context, studentID, examID int64, from, until time.Time) ([]*ScopeLessonProgress, error) {
+	query := `
+        SELECT l.id, l.name, b.id, b.title,
+               COUNT(ss.id) FILTER (WHERE ss.is_completed AND NOT COALESCE(sr.is_review, FALSE)),
+               COUNT(ss.id) FILTER (WHERE ss.is_completed AND sr.is_review),
+               COALESCE(SUM(sr.num_tests), 0),
+               COALESCE(SUM(sr.num_wrong_tests), 0),
+               MAX(dp.plan_date) FILTER (WHERE ss.is_completed),
+               COUNT(ss.id) FILTER (WHERE NOT ss.is_completed AND dp.plan_date BETWEEN $3 AND $4)
+        FROM (` + examScopeLessonIDs("$2") + `) scope
+        INNER JOIN lessons l ON l.id = scope.lesson_id
+        INNER JOIN books b ON b.id = l.book_id
+        LEFT JOIN (
+            study_sessions ss
+            INNER JOIN daily_plans dp ON dp.id = ss.daily_plan_id
+            INNER JOIN weekly_plans wp ON wp.id = dp.weekly_plan_id AND wp.student_id = $1
+        ) ON ss.lesson_id = l.id
+        LEFT JOIN session_reports sr ON sr.study_session_id = ss.id
+        GROUP BY l.id, l.name, b.id, b.title
+        ORDER BY b.title, l.id`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, studentID, examID, from, until)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	var lessons []*ScopeLessonProgress
+	for rows.Next() {
+		var p ScopeLessonProgress
+		var lastStudied sql.NullTime
+		err := rows.Scan(
+			&p.LessonID,
+			&p.LessonName,
+			&p.BookID,
+			&p.BookTitle,
+			&p.StudySessions,
+			&p.ReviewSessions,
+			&p.NumTests,
+			&p.NumWrongTests,
+			&lastStudied,
+			&p.UpcomingSessions,
+		)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+		if lastStudied.Valid {
+			p.LastStudiedDate = &lastStudied.Time
+		}
+		if p.NumTests > 0 {
+			accuracy := float64(p.NumTests-p.NumWrongTests) / float64(p.NumTests)
+			p.TestAccuracy = &accuracy
+		}
+		lessons = append(lessons, &p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+
+	return lessons, nil
+}
+
+// GetUnassignedUpcomingSessions counts, per book, the student's sessions
+// planned between from and until that are not linked to a specific lesson.
+func (m *AnalyticsModel) GetUnassignedUpcomingSessions(ctx context.Context, studentID int64, bookIDs []int64, from, until time.Time) (map[int64]int, error) {
+	query := `
+        SELECT ss.book_id, COUNT(*)
+        FROM study_sessions ss
+        INNER JOIN daily_plans dp ON dp.id = ss.daily_plan_id
+        INNER JOIN weekly_plans wp ON wp.id = dp.weekly_plan_id
+        WHERE wp.student_id = $1
+          AND ss.book_id = ANY($2)
+          AND ss.lesson_id IS NULL
+          AND NOT ss.is_completed
+          AND dp.plan_date BETWEEN $3 AND $4
+        GROUP BY ss.book_id`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, studentID, pq.Array(bookIDs), from, until)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]int)
+	for rows.Next() {
+		var bookID int64
+		var count int
+		if err := rows.Scan(&bookID, &count); err != nil {
+			return nil, wrapError(err)
+		}
+		counts[bookID] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,240 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ExamResult is a student's result in one subject (book) of an exam.
+type ExamResult struct {
+	ID         int64     `json:"id"`
+	StudentID  int64     `json:"student_id"`
+	ExamID     int64     `json:"exam_id"`
+	BookID     int64     `json:"book_id"`
+	BookTitle  string    `json:"book_title,omitempty"`
+	RawScore   float64   `json:"raw_score"`
+	Percentage float64   `json:"percentage"`
+	Rank       *int64    `json:"rank,omitempty"`
+	NumCorrect int       `json:"num_correct"`
+	NumWrong   int       `json:"num_wrong"`
+	NumBlank   int       `json:"num_blank"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// ExamResultEntry is an exam result together with the exam it belongs to, as
+// used in a student's performance history.
+type ExamResultEntry struct {
+	ExamResult
+	ExamTitle string    `json:"exam_title"`
+	ExamDate  time.Time `json:"exam_date"`
+	SeriesID  *int64    `json:"series_id,omitempty"`
+}
+
+// ExamResultFilter restricts a student's result history. Zero values leave
+// the corresponding filter off.
+type ExamResultFilter struct {
+	SeriesID int64
+	BookID   int64
+	AnalyticsFilter
+}
+
+type ExamResultModel struct {
+	DB *sql.DB
+}
+
+// Upsert records the results of one exam in a single transaction. A result
+// for a subject that was already recorded replaces the earlier one.
+func (m *ExamResultModel) Upsert(ctx context.Context, results []*ExamResult) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapError(err)
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO exam_results (student_id, exam_id, book_id, raw_score, percentage, rank, num_correct, num_wrong, num_blank)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        ON CONFLICT (student_id, exam_id, book_id) DO UPDATE
+        SET raw_score = EXCLUDED.raw_score,
+            percentage = EXCLUDED.percentage,
+            rank = EXCLUDED.rank,
+            num_correct = EXCLUDED.num_correct,
+            num_wrong = EXCLUDED.num_wrong,
+            num_blank = EXCLUDED.num_blank,
+            recorded_at = NOW()
+        RETURNING id, recorded_at`
+
+	for _, er := range results {
+		args := []any{er.StudentID, er.ExamID, er.BookID, er.RawScore, er.Percentage, er.Rank, er.NumCorrect, er.NumWrong, er.NumBlank}
+		err = tx.QueryRowContext(ctx, query, args...).Scan(&er.ID, &er.RecordedAt)
+		if err != nil {
+			return wrapError(err)
+		}
+	}
+
+	return wrapError(tx.Commit())
+}
+
+var examResultSortColumns = map[string]string{
+	"book_title": "b.title",
+	"percentage": "er.percentage",
+	"raw_score":  "er.raw_score",
+	"rank":       "er.rank",
+	"id":         "er.id",
+}
+
+// GetAllForExam returns the student's results in an exam ordered by book title.
+func (m *ExamResultModel) GetAllForExam(ctx context.Context, studentID, examID int64, opts QueryOptions) ([]*ExamResult, Metadata, error) {
+	orderBy, err := opts.orderBy(examResultSortColumns, "book_title", "er.id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), er.id, er.student_id, er.exam_id, er.book_id, b.title, er.raw_score, er.percentage,
+               er.rank, er.num_correct, er.num_wrong, er.num_blank, er.recorded_at
+        FROM exam_results er
+        INNER JOIN books b ON b.id = er.book_id
+        WHERE er.student_id = $1 AND er.exam_id = $2
+        %s
+        LIMIT $3 OFFSET $4`, orderBy)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	args := []any{studentID, examID, opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	var results []*ExamResult
+	for rows.Next() {
+		var er ExamResult
+		err := rows.Scan(
+			&totalRecords,
+			&er.ID,
+			&er.StudentID,
+			&er.ExamID,
+			&er.BookID,
+			&er.BookTitle,
+			&er.RawScore,
+			&er.Percentage,
+			&er.Rank,
+			&er.NumCorrect,
+			&er.NumWrong,
+			&er.NumBlank,
+			&er.RecordedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, wrapError(err)
+		}
+		results = append(results, &er)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return results, metadata, nil
+}
+
+// GetHistory returns the student's results ordered by exam date and then by
+// book title.
+func (m *ExamResultModel) GetHistory(ctx context.Context, studentID int64, filter ExamResultFilter) ([]*ExamResultEntry, error) {
+	query := `
+        SELECT er.id, er.student_id, er.exam_id, er.book_id, b.title, er.raw_score, er.percentage,
+               er.rank, er.num_correct, er.num_wrong, er.num_blank, er.recorded_at,
+               es.title, es.exam_date, es.series_id
+        FROM exam_results er
+        INNER JOIN exam_schedules es ON es.id = er.exam_id
+        INNER JOIN books b ON b.id = er.book_id
+        WHERE er.student_id = $1
+          AND ($2 = 0 OR es.series_id = $2)
+          AND ($3 = 0 OR er.book_id = $3)
+          AND ($4::date IS NULL OR es.exam_date >= $4)
+          AND ($5::date IS NULL OR es.exam_date <= $5)
+        ORDER BY es.exam_date, es.id, b.title`
+
+	from, to := filter.args()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, studentID, filter.SeriesID, filter.BookID, from, to)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	var entries []*ExamResultEntry
+	for rows.Next() {
+		var entry ExamResultEntry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.StudentID,
+			&entry.ExamID,
+			&entry.BookID,
+			&entry.BookTitle,
+			&entry.RawScore,
+			&entry.Percentage,
+			&entry.Rank,
+			&entry.NumCorrect,
+			&entry.NumWrong,
+			&entry.NumBlank,
+			&entry.RecordedAt,
+			&entry.ExamTitle,
+			&entry.ExamDate,
+			&entry.SeriesID,
+		)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+
+	return entries, nil
+}
+
+func (m *ExamResultModel) DeleteForExam(ctx context.Context, studentID, examID int64) error {
+	if studentID < 1 || examID < 1 {
+		return ErrorNotFound
+	}
+
+	query := `DELETE FROM exam_results WHERE student_id = $1 AND exam_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, studentID, examID)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorNotFound
+	}
+
+	return nil
+}
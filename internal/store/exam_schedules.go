@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -14,6 +15,7 @@ type ExamSchedule struct {
 	Organisation  string    `json:"organisation,omitempty"`
 	TargetGradeID int64     `json:"target_grade_id"`
 	MajorID       int64     `json:"major_id"`
+	SeriesID      *int64    `json:"series_id,omitempty"`
 }
 
 type ExamScheduleModel struct {
@@ -22,16 +24,16 @@ type ExamScheduleModel struct {
 
 func (m *ExamScheduleModel) Insert(ctx context.Context, es *ExamSchedule) error {
 	query := `
-        INSERT INTO exam_schedules (title, exam_date, organisation, target_grade_id, major_id)
-        VALUES ($1, $2, $3, $4, $5)
+        INSERT INTO exam_schedules (title, exam_date, organisation, target_grade_id, major_id, series_id)
+        VALUES ($1, $2, $3, $4, $5, $6)
         RETURNING id`
 
-	args := []any{es.Title, es.ExamDate, es.Organisation, es.TargetGradeID, es.MajorID}
+	args := []any{es.Title, es.ExamDate, es.Organisation, es.TargetGradeID, es.MajorID, es.SeriesID}
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&es.ID)
+	return wrapError(m.DB.QueryRowContext(ctx, query, args...).Scan(&es.ID))
 }
 
 func (m *ExamScheduleModel) Get(ctx context.Context, id int64) (*ExamSchedule, error) {
@@ -40,7 +42,7 @@ func (m *ExamScheduleModel) Get(ctx context.Context, id int64) (*ExamSchedule, e
 	}
 
 	query := `
-        SELECT id, title, exam_date, organisation, target_grade_id, major_id
+        SELECT id, title, exam_date, organisation, target_grade_id, major_id, series_id
         FROM exam_schedules
         WHERE id = $1`
 
@@ -55,77 +57,257 @@ func (m *ExamScheduleModel) Get(ctx context.Context, id int64) (*ExamSchedule, e
 		&es.Organisation,
 		&es.TargetGradeID,
 		&es.MajorID,
+		&es.SeriesID,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrorNotFound
 		}
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	return &es, nil
 }
 
-func (m *ExamScheduleModel) GetAllForStudentCurriculum(ctx context.Context, gradeID, majorID int64) ([]*ExamSchedule, error) {
-	query := `
-        SELECT id, title, exam_date, organisation, target_grade_id, major_id
-        FROM exam_schedules
+var examScheduleSortColumns = map[string]string{
+	"exam_date": "es.exam_date",
+	"title":     "es.title",
+	"id":        "es.id",
+}
+
+func (m *ExamScheduleModel) GetAllForStudentCurriculum(ctx context.Context, gradeID, majorID int64, opts QueryOptions) ([]*ExamSchedule, Metadata, error) {
+	orderBy, err := opts.orderBy(examScheduleSortColumns, "exam_date", "es.id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, title, exam_date, organisation, target_grade_id, major_id, series_id
+        FROM exam_schedules es
         WHERE target_grade_id = $1 AND major_id = $2
-        ORDER BY exam_date ASC`
+          AND ($3::date IS NULL OR exam_date >= $3)
+          AND ($4::date IS NULL OR exam_date <= $4)
+        %s
+        LIMIT $5 OFFSET $6`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, gradeID, majorID)
+	args := []any{gradeID, majorID, opts.from(), opts.to(), opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var exams []*ExamSchedule
 	for rows.Next() {
 		var es ExamSchedule
 		err := rows.Scan(
+			&totalRecords,
 			&es.ID,
 			&es.Title,
 			&es.ExamDate,
 			&es.Organisation,
 			&es.TargetGradeID,
 			&es.MajorID,
+			&es.SeriesID,
 		)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, wrapError(err)
 		}
 		exams = append(exams, &es)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return exams, metadata, nil
+}
+
+// GetAllForStudent returns the exams the student is enrolled in.
+func (m *ExamScheduleModel) GetAllForStudent(ctx context.Context, studentID int64, opts QueryOptions) ([]*ExamSchedule, Metadata, error) {
+	orderBy, err := opts.orderBy(examScheduleSortColumns, "exam_date", "es.id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), es.id, es.title, es.exam_date, es.organisation, es.target_grade_id, es.major_id, es.series_id
+        FROM exam_schedules es
+        INNER JOIN exam_enrollments ee ON ee.exam_id = es.id
+        WHERE ee.student_id = $1
+          AND ($2::date IS NULL OR es.exam_date >= $2)
+          AND ($3::date IS NULL OR es.exam_date <= $3)
+        %s
+        LIMIT $4 OFFSET $5`, orderBy)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	args := []any{studentID, opts.from(), opts.to(), opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	var exams []*ExamSchedule
+	for rows.Next() {
+		var es ExamSchedule
+		err := rows.Scan(
+			&totalRecords,
+			&es.ID,
+			&es.Title,
+			&es.ExamDate,
+			&es.Organisation,
+			&es.TargetGradeID,
+			&es.MajorID,
+			&es.SeriesID,
+		)
+		if err != nil {
+			return nil, Metadata{}, wrapError(err)
+		}
+		exams = append(exams, &es)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return exams, metadata, nil
+}
+
+// GetAllForSeries returns the exams of a series in date order.
+func (m *ExamScheduleModel) GetAllForSeries(ctx context.Context, seriesID int64, opts QueryOptions) ([]*ExamSchedule, Metadata, error) {
+	orderBy, err := opts.orderBy(examScheduleSortColumns, "exam_date", "es.id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), es.id, es.title, es.exam_date, es.organisation, es.target_grade_id, es.major_id, es.series_id
+        FROM exam_schedules es
+        WHERE es.series_id = $1
+          AND ($2::date IS NULL OR es.exam_date >= $2)
+          AND ($3::date IS NULL OR es.exam_date <= $3)
+        %s
+        LIMIT $4 OFFSET $5`, orderBy)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	args := []any{seriesID, opts.from(), opts.to(), opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	var exams []*ExamSchedule
+	for rows.Next() {
+		var es ExamSchedule
+		err := rows.Scan(
+			&totalRecords,
+			&es.ID,
+			&es.Title,
+			&es.ExamDate,
+			&es.Organisation,
+			&es.TargetGradeID,
+			&es.MajorID,
+			&es.SeriesID,
+		)
+		if err != nil {
+			return nil, Metadata{}, wrapError(err)
+		}
+		exams = append(exams, &es)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return exams, metadata, nil
+}
+
+// GetPrevious returns the latest exam before es in the same series, or, for
+// exams outside a series, from the same organisation and curriculum.
+func (m *ExamScheduleModel) GetPrevious(ctx context.Context, es *ExamSchedule) (*ExamSchedule, error) {
+	query := `
+        SELECT id, title, exam_date, organisation, target_grade_id, major_id, series_id
+        FROM exam_schedules
+        WHERE (exam_date, id) < ($1, $2)
+          AND CASE WHEN $3::int IS NOT NULL THEN series_id = $3
+                   ELSE series_id IS NULL AND organisation = $4 AND target_grade_id = $5 AND major_id = $6
+              END
+        ORDER BY exam_date DESC, id DESC
+        LIMIT 1`
+
+	args := []any{es.ExamDate, es.ID, es.SeriesID, es.Organisation, es.TargetGradeID, es.MajorID}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var previous ExamSchedule
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(
+		&previous.ID,
+		&previous.Title,
+		&previous.ExamDate,
+		&previous.Organisation,
+		&previous.TargetGradeID,
+		&previous.MajorID,
+		&previous.SeriesID,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrorNotFound
+		}
+		return nil, wrapError(err)
 	}
 
-	return exams, nil
+	return &previous, nil
 }
 
 func (m *ExamScheduleModel) Update(ctx context.Context, es *ExamSchedule) error {
 	query := `
         UPDATE exam_schedules
-        SET title = $1, exam_date = $2, organisation = $3, target_grade_id = $4, major_id = $5
-        WHERE id = $6`
+        SET title = $1, exam_date = $2, organisation = $3, target_grade_id = $4, major_id = $5, series_id = $6
+        WHERE id = $7`
 
-	args := []any{es.Title, es.ExamDate, es.Organisation, es.TargetGradeID, es.MajorID, es.ID}
+	args := []any{es.Title, es.ExamDate, es.Organisation, es.TargetGradeID, es.MajorID, es.SeriesID, es.ID}
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	result, err := m.DB.ExecContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -147,12 +329,12 @@ func (m *ExamScheduleModel) Delete(ctx context.Context, id int64) error {
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
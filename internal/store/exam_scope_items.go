@@ -3,73 +3,121 @@ package store
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 )
 
+// ExamScopeItem covers either a single lesson (LessonID), a whole book
+// (BookID alone) or the lessons FromLessonNumber..ToLessonNumber of a book,
+// where lessons are numbered from 1 in book order.
 type ExamScopeItem struct {
-	ID            int64  `json:"id"`
-	ExamID        int64  `json:"exam_id"`
-	LessonID      int64  `json:"lesson_id"`
-	TitleOverride string `json:"title_override,omitempty"`
+	ID               int64  `json:"id"`
+	ExamID           int64  `json:"exam_id"`
+	LessonID         *int64 `json:"lesson_id,omitempty"`
+	BookID           *int64 `json:"book_id,omitempty"`
+	FromLessonNumber *int64 `json:"from_lesson_number,omitempty"`
+	ToLessonNumber   *int64 `json:"to_lesson_number,omitempty"`
+	TitleOverride    string `json:"title_override,omitempty"`
 }
 
 type ExamScopeItemModel struct {
 	DB *sql.DB
 }
 
+// examScopeLessonIDs returns a subquery selecting the distinct lesson_id of
+// every lesson in the scope of the exam bound to param, expanding book and
+// lesson range items.
+func examScopeLessonIDs(param string) string {
+	return fmt.Sprintf(`
+        SELECT DISTINCT l.id AS lesson_id
+        FROM exam_scope_items esi
+        INNER JOIN (
+            SELECT id, book_id, ROW_NUMBER() OVER (PARTITION BY book_id ORDER BY id) AS lesson_number
+            FROM lessons
+        ) l ON l.id = esi.lesson_id
+            OR (esi.lesson_id IS NULL AND l.book_id = esi.book_id
+                AND (esi.from_lesson_number IS NULL
+                     OR l.lesson_number BETWEEN esi.from_lesson_number AND esi.to_lesson_number))
+        WHERE esi.exam_id = %s`, param)
+}
+
 func (m *ExamScopeItemModel) Insert(ctx context.Context, esi *ExamScopeItem) error {
 	query := `
-        INSERT INTO exam_scope_items (exam_id, lesson_id, title_override)
-        VALUES ($1, $2, $3)
+        INSERT INTO exam_scope_items (exam_id, lesson_id, book_id, from_lesson_number, to_lesson_number, title_override)
+        VALUES ($1, $2, $3, $4, $5, $6)
         RETURNING id`
 
-	args := []any{esi.ExamID, esi.LessonID, esi.TitleOverride}
+	args := []any{esi.ExamID, esi.LessonID, esi.BookID, esi.FromLessonNumber, esi.ToLessonNumber, esi.TitleOverride}
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&esi.ID)
+	return wrapError(m.DB.QueryRowContext(ctx, query, args...).Scan(&esi.ID))
+}
+
+var examScopeItemSortColumns = map[string]string{
+	"id":        "id",
+	"book_id":   "book_id",
+	"lesson_id": "lesson_id",
 }
 
-func (m *ExamScopeItemModel) GetAllForExam(ctx context.Context, examID int64) ([]*ExamScopeItem, error) {
+func (m *ExamScopeItemModel) GetAllForExam(ctx context.Context, examID int64, opts QueryOptions) ([]*ExamScopeItem, Metadata, error) {
 	if examID < 1 {
-		return nil, ErrorNotFound
+		return nil, Metadata{}, ErrorNotFound
 	}
 
-	query := `
-        SELECT id, exam_id, lesson_id, title_override
+	orderBy, err := opts.orderBy(examScopeItemSortColumns, "id", "id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, exam_id, lesson_id, book_id, from_lesson_number, to_lesson_number, title_override
         FROM exam_scope_items
-        WHERE exam_id = $1`
+        WHERE exam_id = $1
+        %s
+        LIMIT $2 OFFSET $3`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, examID)
+	args := []any{examID, opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var items []*ExamScopeItem
 	for rows.Next() {
 		var esi ExamScopeItem
 		err := rows.Scan(
+			&totalRecords,
 			&esi.ID,
 			&esi.ExamID,
 			&esi.LessonID,
+			&esi.BookID,
+			&esi.FromLessonNumber,
+			&esi.ToLessonNumber,
 			&esi.TitleOverride,
 		)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, wrapError(err)
 		}
 		items = append(items, &esi)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
 	}
 
-	return items, nil
+	return items, metadata, nil
 }
 
 func (m *ExamScopeItemModel) Delete(ctx context.Context, id int64) error {
@@ -84,12 +132,12 @@ func (m *ExamScopeItemModel) Delete(ctx context.Context, id int64) error {
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ExamSeries groups the recurring exams a provider publishes for a season.
+type ExamSeries struct {
+	ID            int64  `json:"id"`
+	Title         string `json:"title"`
+	Organisation  string `json:"organisation,omitempty"`
+	TargetGradeID int64  `json:"target_grade_id"`
+	MajorID       int64  `json:"major_id"`
+	IntervalDays  *int64 `json:"interval_days,omitempty"`
+}
+
+// SeasonExam is an exam to be imported together with its scope.
+type SeasonExam struct {
+	Exam       *ExamSchedule
+	ScopeItems []*ExamScopeItem
+}
+
+type ExamSeriesModel struct {
+	DB *sql.DB
+}
+
+func (m *ExamSeriesModel) Get(ctx context.Context, id int64) (*ExamSeries, error) {
+	if id < 1 {
+		return nil, ErrorNotFound
+	}
+
+	query := `
+        SELECT id, title, organisation, target_grade_id, major_id, interval_days
+        FROM exam_series
+        WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var series ExamSeries
+	var organisation sql.NullString
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&series.ID,
+		&series.Title,
+		&organisation,
+		&series.TargetGradeID,
+		&series.MajorID,
+		&series.IntervalDays,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrorNotFound
+		}
+		return nil, wrapError(err)
+	}
+	series.Organisation = organisation.String
+
+	return &series, nil
+}
+
+// InsertSeason creates the series, its exams and their scope items in a
+// single transaction, so a failed import leaves nothing behind. The IDs of
+// all inserted rows are filled in on success.
+func (m *ExamSeriesModel) InsertSeason(ctx context.Context, series *ExamSeries, exams []*SeasonExam) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapError(err)
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO exam_series (title, organisation, target_grade_id, major_id, interval_days)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id`
+
+	args := []any{series.Title, series.Organisation, series.TargetGradeID, series.MajorID, series.IntervalDays}
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&series.ID)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	examQuery := `
+        INSERT INTO exam_schedules (title, exam_date, organisation, target_grade_id, major_id, series_id)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id`
+
+	scopeQuery := `
+        INSERT INTO exam_scope_items (exam_id, lesson_id, book_id, from_lesson_number, to_lesson_number, title_override)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id`
+
+	for _, se := range exams {
+		es := se.Exam
+		es.SeriesID = &series.ID
+
+		args := []any{es.Title, es.ExamDate, es.Organisation, es.TargetGradeID, es.MajorID, es.SeriesID}
+		err = tx.QueryRowContext(ctx, examQuery, args...).Scan(&es.ID)
+		if err != nil {
+			return wrapError(err)
+		}
+
+		for _, esi := range se.ScopeItems {
+			esi.ExamID = es.ID
+			args := []any{esi.ExamID, esi.LessonID, esi.BookID, esi.FromLessonNumber, esi.ToLessonNumber, esi.TitleOverride}
+			err = tx.QueryRowContext(ctx, scopeQuery, args...).Scan(&esi.ID)
+			if err != nil {
+				return wrapError(err)
+			}
+		}
+	}
+
+	return wrapError(tx.Commit())
+}
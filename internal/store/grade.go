@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 )
 
@@ -31,37 +32,58 @@ func (m *GradeModel) Get(ctx context.Context, id int64) (*Grade, error) {
 		if err == sql.ErrNoRows {
 			return nil, ErrorNotFound
 		}
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	return &grade, nil
 }
 
-func (m *GradeModel) GetAll(ctx context.Context) ([]*Grade, error) {
-	query := `SELECT id, name FROM grades ORDER BY id`
+var gradeSortColumns = map[string]string{
+	"id":   "id",
+	"name": "name",
+}
+
+func (m *GradeModel) GetAll(ctx context.Context, opts QueryOptions) ([]*Grade, Metadata, error) {
+	orderBy, err := opts.orderBy(gradeSortColumns, "id", "id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, name
+        FROM grades
+        %s
+        LIMIT $1 OFFSET $2`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query)
+	args := []any{opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var grades []*Grade
 
 	for rows.Next() {
 		var grade Grade
-		if err := rows.Scan(&grade.ID, &grade.Name); err != nil {
-			return nil, err
+		if err := rows.Scan(&totalRecords, &grade.ID, &grade.Name); err != nil {
+			return nil, Metadata{}, wrapError(err)
 		}
 		grades = append(grades, &grade)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
 	}
 
-	return grades, nil
+	return grades, metadata, nil
 }
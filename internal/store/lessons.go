@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -43,46 +44,126 @@ func (m *LessonModel) Get(ctx context.Context, id int64) (*Lesson, error) {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrorNotFound
 		}
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	return &lesson, nil
 }
 
-func (m *LessonModel) GetAllForBook(ctx context.Context, bookID int64) ([]*Lesson, error) {
-	query := `
-        SELECT id, name, book_id, estimated_study_time_minutes
+var lessonSortColumns = map[string]string{
+	"id":                           "id",
+	"name":                         "name",
+	"estimated_study_time_minutes": "estimated_study_time_minutes",
+}
+
+func (m *LessonModel) GetAllForBook(ctx context.Context, bookID int64, opts QueryOptions) ([]*Lesson, Metadata, error) {
+	orderBy, err := opts.orderBy(lessonSortColumns, "id", "id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, name, book_id, estimated_study_time_minutes
         FROM lessons
         WHERE book_id = $1
-        ORDER BY id`
+        %s
+        LIMIT $2 OFFSET $3`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, bookID)
+	args := []any{bookID, opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var lessons []*Lesson
 	for rows.Next() {
 		var lesson Lesson
 		err := rows.Scan(
+			&totalRecords,
 			&lesson.ID,
 			&lesson.Name,
 			&lesson.BookID,
 			&lesson.EstimatedStudyTimeMinutes,
 		)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, wrapError(err)
 		}
 		lessons = append(lessons, &lesson)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return lessons, metadata, nil
+}
+
+var scopeLessonSortColumns = map[string]string{
+	"book_id": "l.book_id",
+	"id":      "l.id",
+	"name":    "l.name",
+}
+
+// GetAllForExamScope returns the distinct lessons in an exam's scope, with
+// book and range items expanded, ordered by book and then by lesson.
+func (m *LessonModel) GetAllForExamScope(ctx context.Context, examID int64, opts QueryOptions) ([]*Lesson, Metadata, error) {
+	orderBy, err := opts.orderBy(scopeLessonSortColumns, "book_id", "l.id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), l.id, l.name, l.book_id, l.estimated_study_time_minutes
+        FROM lessons l
+        WHERE l.id IN (`+examScopeLessonIDs("$1")+`)
+        %s
+        LIMIT $2 OFFSET $3`, orderBy)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	args := []any{examID, opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	var lessons []*Lesson
+	for rows.Next() {
+		var lesson Lesson
+		err := rows.Scan(
+			&totalRecords,
+			&lesson.ID,
+			&lesson.Name,
+			&lesson.BookID,
+			&lesson.EstimatedStudyTimeMinutes,
+		)
+		if err != nil {
+			return nil, Metadata{}, wrapError(err)
+		}
+		lessons = append(lessons, &lesson)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
 	}
 
-	return lessons, nil
+	return lessons, metadata, nil
 }
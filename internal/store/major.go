@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 )
 
@@ -31,36 +32,57 @@ func (m *MajorModel) Get(ctx context.Context, id int64) (*Major, error) {
 		if err == sql.ErrNoRows {
 			return nil, ErrorNotFound
 		}
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	return &major, nil
 }
 
-func (m *MajorModel) GetAll(ctx context.Context) ([]*Major, error) {
-	query := `SELECT id, name FROM majors ORDER BY id`
+var majorSortColumns = map[string]string{
+	"id":   "id",
+	"name": "name",
+}
+
+func (m *MajorModel) GetAll(ctx context.Context, opts QueryOptions) ([]*Major, Metadata, error) {
+	orderBy, err := opts.orderBy(majorSortColumns, "id", "id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, name
+        FROM majors
+        %s
+        LIMIT $1 OFFSET $2`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query)
+	args := []any{opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var majors []*Major
 	for rows.Next() {
 		var major Major
-		if err := rows.Scan(&major.ID, &major.Name); err != nil {
-			return nil, err
+		if err := rows.Scan(&totalRecords, &major.ID, &major.Name); err != nil {
+			return nil, Metadata{}, wrapError(err)
 		}
 		majors = append(majors, &major)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
 	}
 
-	return majors, nil
+	return majors, metadata, nil
 }
@@ -0,0 +1,321 @@
+package store
+
+import (
+	"cmp"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryDB holds the tables of an in-memory Storage. It is safe for
+// concurrent use and enforces the unique constraints, foreign keys, checks and
+// cascades of the PostgreSQL schema, so that store methods return the same
+// errors as they do against the database. It is meant for tests and demos.
+type MemoryDB struct {
+	mu sync.RWMutex
+
+	grades                 memoryTable[Grade]
+	majors                 memoryTable[Major]
+	books                  memoryTable[Book]
+	bookRoles              memoryTable[BookRole]
+	lessons                memoryTable[Lesson]
+	students               memoryTable[memoryStudent]
+	unavailableTimes       memoryTable[UnavailableTime]
+	weeklyPlans            memoryTable[WeeklyPlan]
+	subjectFrequencies     memoryTable[SubjectFrequency]
+	dailyPlans             memoryTable[DailyPlan]
+	studySessions          memoryTable[StudySession]
+	sessionReports         memoryTable[SessionReport]
+	examSeries             memoryTable[ExamSeries]
+	examSchedules          memoryTable[ExamSchedule]
+	examScopeItems         memoryTable[ExamScopeItem]
+	examEnrollments        memoryTable[ExamEnrollment]
+	examResults            memoryTable[ExamResult]
+	scheduleTemplates      memoryTable[ScheduleTemplate]
+	templateRules          memoryTable[TemplateRule]
+	templateSubjectWeights memoryTable[TemplateSubjectWeight]
+}
+
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{}
+}
+
+// NewMemoryStorage returns a Storage backed by db instead of PostgreSQL.
+func NewMemoryStorage(db *MemoryDB) *Storage {
+	return &Storage{
+		Students:               &memoryStudentModel{db: db},
+		Grades:                 &memoryGradeModel{db: db},
+		Majors:                 &memoryMajorModel{db: db},
+		Books:                  &memoryBookModel{db: db},
+		Lessons:                &memoryLessonModel{db: db},
+		UnavailableTimes:       &memoryUnavailableTimeModel{db: db},
+		WeeklyPlans:            &memoryWeeklyPlanModel{db: db},
+		SubjectFrequencies:     &memorySubjectFrequencyModel{db: db},
+		DailyPlans:             &memoryDailyPlanModel{db: db},
+		StudySessions:          &memoryStudySessionModel{db: db},
+		SessionReports:         &memorySessionReportModel{db: db},
+		ExamSchedules:          &memoryExamScheduleModel{db: db},
+		ExamScopeItems:         &memoryExamScopeItemModel{db: db},
+		ExamEnrollments:        &memoryExamEnrollmentModel{db: db},
+		ExamSeries:             &memoryExamSeriesModel{db: db},
+		ExamResults:            &memoryExamResultModel{db: db},
+		ScheduleTemplates:      &memoryScheduleTemplateModel{db: db},
+		TemplateRules:          &memoryTemplateRuleModel{db: db},
+		TemplateSubjectWeights: &memoryTemplateSubjectWeightModel{db: db},
+		Analytics:              &memoryAnalyticsModel{db: db},
+	}
+}
+
+// memoryTable stores rows by id. IDs are handed out like a SERIAL column's:
+// increasing and never reused.
+type memoryTable[T any] struct {
+	rows   map[int64]T
+	lastID int64
+}
+
+func (t *memoryTable[T]) nextID() int64 {
+	t.lastID++
+	return t.lastID
+}
+
+func (t *memoryTable[T]) get(id int64) (T, bool) {
+	row, ok := t.rows[id]
+	return row, ok
+}
+
+func (t *memoryTable[T]) has(id int64) bool {
+	_, ok := t.rows[id]
+	return ok
+}
+
+func (t *memoryTable[T]) put(id int64, row T) {
+	if t.rows == nil {
+		t.rows = make(map[int64]T)
+	}
+	t.rows[id] = row
+}
+
+func (t *memoryTable[T]) delete(id int64) bool {
+	if !t.has(id) {
+		return false
+	}
+	delete(t.rows, id)
+	return true
+}
+
+// all returns the rows in id order.
+func (t *memoryTable[T]) all() []T {
+	ids := make([]int64, 0, len(t.rows))
+	for id := range t.rows {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	rows := make([]T, len(ids))
+	for i, id := range ids {
+		rows[i] = t.rows[id]
+	}
+	return rows
+}
+
+// deleteWhere deletes the rows for which match returns true and returns their
+// ids.
+func (t *memoryTable[T]) deleteWhere(match func(T) bool) []int64 {
+	var ids []int64
+	for id, row := range t.rows {
+		if match(row) {
+			ids = append(ids, id)
+			delete(t.rows, id)
+		}
+	}
+	return ids
+}
+
+// constraintError returns the error the database reports when a row violates
+// a named constraint.
+func constraintError(kind error, table, constraint string) error {
+	return &DBError{
+		Kind:       kind,
+		Table:      table,
+		Constraint: constraint,
+		Err:        fmt.Errorf("%s violates constraint %q", table, constraint),
+	}
+}
+
+// foreignKeyError reports a reference from table.column to a missing row,
+// under the constraint name PostgreSQL gives such a foreign key.
+func foreignKeyError(table, column string) error {
+	return constraintError(ErrorForeignKey, table, table+"_"+column+"_fkey")
+}
+
+// invalidValueError reports a value the column's type cannot hold.
+func invalidValueError(table, column string, value any) error {
+	return &DBError{
+		Kind:   ErrorValidation,
+		Table:  table,
+		Column: column,
+		Err:    fmt.Errorf("invalid input for %s.%s: %v", table, column, value),
+	}
+}
+
+// checkReferences returns the error for the first reference that does not
+// exist.
+func checkReferences(table string, refs ...memoryReference) error {
+	for _, ref := range refs {
+		if !ref.exists {
+			return foreignKeyError(table, ref.column)
+		}
+	}
+	return nil
+}
+
+type memoryReference struct {
+	column string
+	exists bool
+}
+
+// optionalReference is a nullable foreign key, which a NULL satisfies.
+func optionalReference[T any](column string, id sql.NullInt64, table *memoryTable[T]) memoryReference {
+	return memoryReference{column, !id.Valid || table.has(id.Int64)}
+}
+
+// memoryDate returns the value a DATE column stores for t: its calendar date
+// in t's own location.
+func memoryDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// memoryClock returns the value a TIME column stores for t: its wall clock.
+func memoryClock(t time.Time) time.Time {
+	return time.Date(0, time.January, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+func memoryNullDate(t sql.NullTime) sql.NullTime {
+	if !t.Valid {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: memoryDate(t.Time), Valid: true}
+}
+
+func memoryNullClock(t sql.NullTime) sql.NullTime {
+	if !t.Valid {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: memoryClock(t.Time), Valid: true}
+}
+
+// memoryTimeOfDay parses a TIME given as text, the way study session times
+// are, and formats it the way they are read back.
+func memoryTimeOfDay(table, column, value string) (string, error) {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t.Format("15:04:05"), nil
+		}
+	}
+	return "", invalidValueError(table, column, value)
+}
+
+// memoryNow returns the current time at the precision of TIMESTAMP(0).
+func memoryNow() time.Time {
+	return time.Now().Truncate(time.Second)
+}
+
+// inDateRange reports whether date is within from and to, either of which is
+// unbounded when zero, like the ($n::date IS NULL OR column >= $n) conditions
+// of the SQL list methods.
+func inDateRange(date time.Time, from, to time.Time) bool {
+	if !from.IsZero() && date.Before(memoryDate(from)) {
+		return false
+	}
+	if !to.IsZero() && date.After(memoryDate(to)) {
+		return false
+	}
+	return true
+}
+
+// listRows orders and pages rows the way the SQL list methods do: by the
+// requested field, then by id, with the total counted over every matching row
+// before paging, like count(*) OVER(). sortColumns must have the same fields
+// as the corresponding SQL method.
+func listRows[T any](rows []*T, opts QueryOptions, sortColumns map[string]func(a, b *T) int, defaultSort string) ([]*T, Metadata, error) {
+	field, descending := opts.sortField(defaultSort)
+	compare, ok := sortColumns[field]
+	if !ok {
+		return nil, Metadata{}, invalidSortError(sortColumns)
+	}
+
+	byID := sortColumns["id"]
+	slices.SortStableFunc(rows, func(a, b *T) int {
+		c := compare(a, b)
+		if descending {
+			c = -c
+		}
+		if c == 0 {
+			c = byID(a, b)
+		}
+		return c
+	})
+
+	start := min(opts.offset(), len(rows))
+	end := len(rows)
+	if opts.PageSize > 0 {
+		end = min(start+opts.PageSize, len(rows))
+	}
+	if start == end {
+		return nil, calculateMetadata(len(rows), opts), nil
+	}
+	return rows[start:end], calculateMetadata(len(rows), opts), nil
+}
+
+// compareNullInt64 orders NULLs after every value, as PostgreSQL does.
+func compareNullInt64(a, b sql.NullInt64) int {
+	switch {
+	case a.Valid && b.Valid:
+		return cmp.Compare(a.Int64, b.Int64)
+	case a.Valid:
+		return -1
+	case b.Valid:
+		return 1
+	}
+	return 0
+}
+
+// compareOptionalInt64 orders nil after every value, as compareNullInt64 does.
+func compareOptionalInt64(a, b *int64) int {
+	switch {
+	case a != nil && b != nil:
+		return cmp.Compare(*a, *b)
+	case a != nil:
+		return -1
+	case b != nil:
+		return 1
+	}
+	return 0
+}
+
+func compareNullTime(a, b sql.NullTime) int {
+	switch {
+	case a.Valid && b.Valid:
+		return a.Time.Compare(b.Time)
+	case a.Valid:
+		return -1
+	case b.Valid:
+		return 1
+	}
+	return 0
+}
+
+// compareBool orders false before true.
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	}
+	return 1
+}
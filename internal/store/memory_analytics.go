@@ -0,0 +1,359 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// memoryPlannedSession is a study session joined with its daily plan and,
+// when one was filed, its report.
+type memoryPlannedSession struct {
+	StudySession
+	planDate     time.Time
+	weeklyPlanID int64
+	report       *SessionReport
+}
+
+// completed reports whether the session was completed as a review or, when
+// review is false, as a new session without a review report.
+func (s *memoryPlannedSession) completed(review bool) bool {
+	if !s.IsCompleted {
+		return false
+	}
+	isReview := s.report != nil && s.report.IsReview
+	return isReview == review
+}
+
+// studentSessions returns the sessions in the student's weekly plans, in id
+// order.
+func (db *MemoryDB) studentSessions(studentID int64) []memoryPlannedSession {
+	reports := make(map[int64]SessionReport)
+	for _, sr := range db.sessionReports.all() {
+		reports[sr.StudySessionID] = sr
+	}
+
+	var sessions []memoryPlannedSession
+	for _, ss := range db.studySessions.all() {
+		dp, _ := db.dailyPlans.get(ss.DailyPlanID)
+		wp, _ := db.weeklyPlans.get(dp.WeeklyPlanID)
+		if wp.StudentID != studentID {
+			continue
+		}
+
+		session := memoryPlannedSession{StudySession: ss, planDate: dp.PlanDate, weeklyPlanID: wp.ID}
+		if sr, ok := reports[ss.ID]; ok {
+			session.report = &sr
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// memoryProgress accumulates the figures of progressColumns.
+type memoryProgress struct {
+	ProgressStats
+	minutes    float64
+	scoreSum   float64
+	scoreCount int
+}
+
+func (p *memoryProgress) add(s *memoryPlannedSession) {
+	p.PlannedSessions++
+	if s.IsCompleted {
+		p.CompletedSessions++
+		start, _ := time.Parse("15:04:05", s.StartTime)
+		end, _ := time.Parse("15:04:05", s.EndTime)
+		p.minutes += end.Sub(start).Minutes()
+	}
+	if s.report != nil {
+		p.NumTests += s.report.NumTests
+		p.NumWrongTests += s.report.NumWrongTests
+		p.scoreSum += s.report.SessionScore
+		p.scoreCount++
+	}
+	if s.completed(true) {
+		p.ReviewSessions++
+	}
+	if s.completed(false) {
+		p.NewSessions++
+	}
+}
+
+func (p *memoryProgress) stats() ProgressStats {
+	stats := p.ProgressStats
+	stats.StudyMinutes = int(math.Round(p.minutes))
+	if p.scoreCount > 0 {
+		average := p.scoreSum / float64(p.scoreCount)
+		stats.AverageScore = &average
+	}
+	stats.Finalize()
+	return stats
+}
+
+type memoryAnalyticsModel struct {
+	db *MemoryDB
+}
+
+func (m *memoryAnalyticsModel) GetBookProgress(ctx context.Context, studentID int64, filter AnalyticsFilter) ([]*BookProgress, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	totals := make(map[int64]*memoryProgress)
+	for _, s := range m.db.studentSessions(studentID) {
+		if !inDateRange(s.planDate, filter.From, filter.To) {
+			continue
+		}
+		if totals[s.BookID] == nil {
+			totals[s.BookID] = &memoryProgress{}
+		}
+		totals[s.BookID].add(&s)
+	}
+
+	var progress []*BookProgress
+	for bookID, total := range totals {
+		book, _ := m.db.books.get(bookID)
+		progress = append(progress, &BookProgress{BookID: bookID, BookTitle: book.Title, ProgressStats: total.stats()})
+	}
+	slices.SortFunc(progress, func(a, b *BookProgress) int {
+		return cmp.Or(strings.Compare(a.BookTitle, b.BookTitle), cmp.Compare(a.BookID, b.BookID))
+	})
+	return progress, nil
+}
+
+func (m *memoryAnalyticsModel) GetWeeklyProgress(ctx context.Context, studentID int64, filter AnalyticsFilter) ([]*WeekProgress, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	totals := make(map[int64]*memoryProgress)
+	for _, s := range m.db.studentSessions(studentID) {
+		if !inDateRange(s.planDate, filter.From, filter.To) {
+			continue
+		}
+		if totals[s.weeklyPlanID] == nil {
+			totals[s.weeklyPlanID] = &memoryProgress{}
+		}
+		totals[s.weeklyPlanID].add(&s)
+	}
+
+	var progress []*WeekProgress
+	for planID, total := range totals {
+		wp, _ := m.db.weeklyPlans.get(planID)
+		progress = append(progress, &WeekProgress{WeeklyPlanID: planID, StartDateOfWeek: wp.StartDateOfWeek, ProgressStats: total.stats()})
+	}
+	slices.SortFunc(progress, func(a, b *WeekProgress) int {
+		return cmp.Or(a.StartDateOfWeek.Compare(b.StartDateOfWeek), cmp.Compare(a.WeeklyPlanID, b.WeeklyPlanID))
+	})
+	return progress, nil
+}
+
+func (m *memoryAnalyticsModel) GetStudyDates(ctx context.Context, studentID int64, filter AnalyticsFilter) ([]time.Time, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var dates []time.Time
+	for _, s := range m.db.studentSessions(studentID) {
+		if s.IsCompleted && inDateRange(s.planDate, filter.From, filter.To) && !slices.ContainsFunc(dates, s.planDate.Equal) {
+			dates = append(dates, s.planDate)
+		}
+	}
+	slices.SortFunc(dates, time.Time.Compare)
+	return dates, nil
+}
+
+// memoryTopic identifies a weak-topic candidate: a book and, optionally, one
+// of its lessons.
+type memoryTopic struct {
+	bookID   int64
+	lessonID sql.NullInt64
+}
+
+func (m *memoryAnalyticsModel) GetWeakTopics(ctx context.Context, studentID int64, params WeakTopicParams) ([]*WeakTopic, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	recent := make(map[memoryTopic][]memoryPlannedSession)
+	var order []memoryTopic
+	for _, s := range m.db.studentSessions(studentID) {
+		if s.report == nil {
+			continue
+		}
+		topic := memoryTopic{s.BookID, s.LessonID}
+		if _, ok := recent[topic]; !ok {
+			order = append(order, topic)
+		}
+		recent[topic] = append(recent[topic], s)
+	}
+
+	var topics []*WeakTopic
+	for _, topic := range order {
+		sessions := recent[topic]
+		slices.SortStableFunc(sessions, func(a, b memoryPlannedSession) int {
+			return cmp.Or(b.planDate.Compare(a.planDate), strings.Compare(b.StartTime, a.StartTime))
+		})
+		if len(sessions) > params.RecentSessions {
+			sessions = sessions[:max(params.RecentSessions, 0)]
+		}
+		if len(sessions) == 0 {
+			continue
+		}
+
+		wt := WeakTopic{BookID: topic.bookID, Sessions: len(sessions)}
+		var scoreSum float64
+		var scoreCount int
+		for _, s := range sessions {
+			wt.NumTests += s.report.NumTests
+			wt.NumWrongTests += s.report.NumWrongTests
+			// a score of zero means the session was not scored
+			if s.report.SessionScore != 0 {
+				scoreSum += s.report.SessionScore
+				scoreCount++
+			}
+		}
+		if wt.NumTests >= params.MinTests && wt.NumTests > 0 {
+			ratio := float64(wt.NumWrongTests) / float64(wt.NumTests)
+			wt.WrongRatio = &ratio
+		}
+		if scoreCount > 0 {
+			average := scoreSum / float64(scoreCount)
+			wt.AverageScore = &average
+		}
+
+		weakRatio := wt.WrongRatio != nil && *wt.WrongRatio > params.MaxWrongRatio
+		weakScore := wt.AverageScore != nil && *wt.AverageScore < params.MinScore
+		if !weakRatio && !weakScore {
+			continue
+		}
+
+		var overshoot, shortfall float64
+		if wt.WrongRatio != nil && params.MaxWrongRatio != 0 {
+			overshoot = (*wt.WrongRatio - params.MaxWrongRatio) / params.MaxWrongRatio
+		}
+		if wt.AverageScore != nil && params.MinScore != 0 {
+			shortfall = (params.MinScore - *wt.AverageScore) / params.MinScore
+		}
+		wt.Severity = max(overshoot, shortfall)
+
+		book, _ := m.db.books.get(topic.bookID)
+		wt.BookTitle = book.Title
+		if topic.lessonID.Valid {
+			lessonID := topic.lessonID.Int64
+			wt.LessonID = &lessonID
+			lesson, _ := m.db.lessons.get(lessonID)
+			wt.LessonName = lesson.Name
+		}
+		topics = append(topics, &wt)
+	}
+
+	slices.SortStableFunc(topics, func(a, b *WeakTopic) int {
+		return cmp.Or(
+			cmp.Compare(b.Severity, a.Severity),
+			compareWrongRatioDesc(a.WrongRatio, b.WrongRatio),
+			strings.Compare(a.BookTitle, b.BookTitle),
+			compareLessonNullsFirst(a.LessonID, b.LessonID),
+		)
+	})
+	for i, wt := range topics {
+		wt.Rank = i + 1
+	}
+	return topics, nil
+}
+
+// compareWrongRatioDesc orders ratios from highest to lowest, then NULLs.
+func compareWrongRatioDesc(a, b *float64) int {
+	switch {
+	case a != nil && b != nil:
+		return cmp.Compare(*b, *a)
+	case a != nil:
+		return -1
+	case b != nil:
+		return 1
+	}
+	return 0
+}
+
+// compareLessonNullsFirst orders book-level topics before lesson-level ones.
+func compareLessonNullsFirst(a, b *int64) int {
+	switch {
+	case a != nil && b != nil:
+		return cmp.Compare(*a, *b)
+	case a != nil:
+		return 1
+	case b != nil:
+		return -1
+	}
+	return 0
+}
+
+// inTimeRange reports whether date is BETWEEN from AND until.
+func inTimeRange(date, from, until time.Time) bool {
+	return !date.Before(from) && !date.After(until)
+}
+
+func (m *memoryAnalyticsModel) GetScopeLessonProgress(ctx context.Context, studentID, examID int64, from, until time.Time) ([]*ScopeLessonProgress, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	sessions := m.db.studentSessions(studentID)
+
+	var lessons []*ScopeLessonProgress
+	for _, lesson := range m.db.examScopeLessons(examID) {
+		book, _ := m.db.books.get(lesson.BookID)
+		p := ScopeLessonProgress{
+			LessonID:   lesson.ID,
+			LessonName: lesson.Name,
+			BookID:     book.ID,
+			BookTitle:  book.Title,
+		}
+
+		for _, s := range sessions {
+			if !s.LessonID.Valid || s.LessonID.Int64 != lesson.ID {
+				continue
+			}
+			if s.completed(false) {
+				p.StudySessions++
+			}
+			if s.completed(true) {
+				p.ReviewSessions++
+			}
+			if s.report != nil {
+				p.NumTests += s.report.NumTests
+				p.NumWrongTests += s.report.NumWrongTests
+			}
+			if s.IsCompleted && (p.LastStudiedDate == nil || s.planDate.After(*p.LastStudiedDate)) {
+				lastStudied := s.planDate
+				p.LastStudiedDate = &lastStudied
+			}
+			if !s.IsCompleted && inTimeRange(s.planDate, from, until) {
+				p.UpcomingSessions++
+			}
+		}
+		if p.NumTests > 0 {
+			accuracy := float64(p.NumTests-p.NumWrongTests) / float64(p.NumTests)
+			p.TestAccuracy = &accuracy
+		}
+		lessons = append(lessons, &p)
+	}
+
+	slices.SortStableFunc(lessons, func(a, b *ScopeLessonProgress) int {
+		return cmp.Or(strings.Compare(a.BookTitle, b.BookTitle), cmp.Compare(a.LessonID, b.LessonID))
+	})
+	return lessons, nil
+}
+
+func (m *memoryAnalyticsModel) GetUnassignedUpcomingSessions(ctx context.Context, studentID int64, bookIDs []int64, from, until time.Time) (map[int64]int, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	counts := make(map[int64]int)
+	for _, s := range m.db.studentSessions(studentID) {
+		if slices.Contains(bookIDs, s.BookID) && !s.LessonID.Valid && !s.IsCompleted && inTimeRange(s.planDate, from, until) {
+			counts[s.BookID]++
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,462 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"strings"
+)
+
+// The curriculum is read-only through Storage, so MemoryDB has methods of its
+// own to fill it in.
+
+func (db *MemoryDB) InsertGrade(grade *Grade) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, other := range db.grades.all() {
+		if other.Name == grade.Name {
+			return constraintError(ErrorConflict, "grades", "grades_name_key")
+		}
+	}
+
+	grade.ID = db.grades.nextID()
+	db.grades.put(grade.ID, *grade)
+	return nil
+}
+
+func (db *MemoryDB) InsertMajor(major *Major) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, other := range db.majors.all() {
+		if other.Name == major.Name {
+			return constraintError(ErrorConflict, "majors", "majors_name_key")
+		}
+	}
+
+	major.ID = db.majors.nextID()
+	db.majors.put(major.ID, *major)
+	return nil
+}
+
+func (db *MemoryDB) InsertBook(book *Book) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	err := checkReferences("books",
+		memoryReference{"inherent_grade_level_id", db.grades.has(book.InherentGradeLevelID)},
+	)
+	if err != nil {
+		return err
+	}
+
+	if book.Difficulty == 0 {
+		book.Difficulty = DifficultyMedium
+	}
+	if book.Difficulty < DifficultyLight || book.Difficulty > DifficultyHeavy {
+		return constraintError(ErrorValidation, "books", "books_difficulty_check")
+	}
+
+	book.ID = db.books.nextID()
+	db.books.put(book.ID, *book)
+	return nil
+}
+
+func (db *MemoryDB) InsertBookRole(role *BookRole) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	err := checkReferences("book_roles",
+		memoryReference{"target_student_grade_id", db.grades.has(role.TargetStudentGradeID)},
+		memoryReference{"major_id", role.MajorID == 0 || db.majors.has(role.MajorID)},
+		memoryReference{"book_id", db.books.has(role.BookID)},
+	)
+	if err != nil {
+		return err
+	}
+
+	role.ID = db.bookRoles.nextID()
+	db.bookRoles.put(role.ID, *role)
+	return nil
+}
+
+func (db *MemoryDB) InsertLesson(lesson *Lesson) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	err := checkReferences("lessons",
+		memoryReference{"book_id", db.books.has(lesson.BookID)},
+	)
+	if err != nil {
+		return err
+	}
+
+	lesson.ID = db.lessons.nextID()
+	db.lessons.put(lesson.ID, *lesson)
+	return nil
+}
+
+func (db *MemoryDB) InsertScheduleTemplate(tpl *ScheduleTemplate) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, other := range db.scheduleTemplates.all() {
+		if other.Name == tpl.Name {
+			return constraintError(ErrorConflict, "schedule_templates", "schedule_templates_name_key")
+		}
+	}
+	err := checkReferences("schedule_templates",
+		memoryReference{"target_grade_id", db.grades.has(tpl.TargetGradeID)},
+		memoryReference{"target_major_id", db.majors.has(tpl.TargetMajorID)},
+	)
+	if err != nil {
+		return err
+	}
+
+	tpl.ID = db.scheduleTemplates.nextID()
+	db.scheduleTemplates.put(tpl.ID, *tpl)
+	return nil
+}
+
+type memoryGradeModel struct {
+	db *MemoryDB
+}
+
+func (m *memoryGradeModel) Get(ctx context.Context, id int64) (*Grade, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	grade, ok := m.db.grades.get(id)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &grade, nil
+}
+
+var memoryGradeSortColumns = map[string]func(a, b *Grade) int{
+	"id":   func(a, b *Grade) int { return cmp.Compare(a.ID, b.ID) },
+	"name": func(a, b *Grade) int { return strings.Compare(a.Name, b.Name) },
+}
+
+func (m *memoryGradeModel) GetAll(ctx context.Context, opts QueryOptions) ([]*Grade, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var grades []*Grade
+	for _, grade := range m.db.grades.all() {
+		grades = append(grades, &grade)
+	}
+	return listRows(grades, opts, memoryGradeSortColumns, "id")
+}
+
+type memoryMajorModel struct {
+	db *MemoryDB
+}
+
+func (m *memoryMajorModel) Get(ctx context.Context, id int64) (*Major, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	major, ok := m.db.majors.get(id)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &major, nil
+}
+
+var memoryMajorSortColumns = map[string]func(a, b *Major) int{
+	"id":   func(a, b *Major) int { return cmp.Compare(a.ID, b.ID) },
+	"name": func(a, b *Major) int { return strings.Compare(a.Name, b.Name) },
+}
+
+func (m *memoryMajorModel) GetAll(ctx context.Context, opts QueryOptions) ([]*Major, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var majors []*Major
+	for _, major := range m.db.majors.all() {
+		majors = append(majors, &major)
+	}
+	return listRows(majors, opts, memoryMajorSortColumns, "id")
+}
+
+type memoryBookModel struct {
+	db *MemoryDB
+}
+
+func (m *memoryBookModel) Get(ctx context.Context, id int64) (*Book, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	book, ok := m.db.books.get(id)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &book, nil
+}
+
+func (m *memoryBookModel) GetByTitle(ctx context.Context, title string) (*Book, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	for _, book := range m.db.books.all() {
+		if book.Title == title {
+			return &book, nil
+		}
+	}
+	return nil, ErrorNotFound
+}
+
+var memoryBookSortColumns = map[string]func(a, b *Book) int{
+	"title": func(a, b *Book) int { return strings.Compare(a.Title, b.Title) },
+	"id":    func(a, b *Book) int { return cmp.Compare(a.ID, b.ID) },
+}
+
+// GetAllForCurriculum lists a book once for every role it has in the
+// curriculum, as the join of the SQL method does.
+func (m *memoryBookModel) GetAllForCurriculum(ctx context.Context, gradeID, majorID int64, opts QueryOptions) ([]*Book, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var books []*Book
+	for _, role := range m.db.bookRoles.all() {
+		if role.TargetStudentGradeID != gradeID || role.MajorID != majorID || role.MajorID == 0 {
+			continue
+		}
+		if book, ok := m.db.books.get(role.BookID); ok {
+			books = append(books, &book)
+		}
+	}
+	return listRows(books, opts, memoryBookSortColumns, "title")
+}
+
+type memoryLessonModel struct {
+	db *MemoryDB
+}
+
+func (m *memoryLessonModel) Get(ctx context.Context, id int64) (*Lesson, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	lesson, ok := m.db.lessons.get(id)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &lesson, nil
+}
+
+var memoryLessonSortColumns = map[string]func(a, b *Lesson) int{
+	"id":   func(a, b *Lesson) int { return cmp.Compare(a.ID, b.ID) },
+	"name": func(a, b *Lesson) int { return strings.Compare(a.Name, b.Name) },
+	"estimated_study_time_minutes": func(a, b *Lesson) int {
+		return compareNullInt64(a.EstimatedStudyTimeMinutes, b.EstimatedStudyTimeMinutes)
+	},
+}
+
+func (m *memoryLessonModel) GetAllForBook(ctx context.Context, bookID int64, opts QueryOptions) ([]*Lesson, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var lessons []*Lesson
+	for _, lesson := range m.db.lessons.all() {
+		if lesson.BookID == bookID {
+			lessons = append(lessons, &lesson)
+		}
+	}
+	return listRows(lessons, opts, memoryLessonSortColumns, "id")
+}
+
+var memoryScopeLessonSortColumns = map[string]func(a, b *Lesson) int{
+	"book_id": func(a, b *Lesson) int { return cmp.Compare(a.BookID, b.BookID) },
+	"id":      func(a, b *Lesson) int { return cmp.Compare(a.ID, b.ID) },
+	"name":    func(a, b *Lesson) int { return strings.Compare(a.Name, b.Name) },
+}
+
+func (m *memoryLessonModel) GetAllForExamScope(ctx context.Context, examID int64, opts QueryOptions) ([]*Lesson, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var lessons []*Lesson
+	for _, lesson := range m.db.examScopeLessons(examID) {
+		lessons = append(lessons, &lesson)
+	}
+	return listRows(lessons, opts, memoryScopeLessonSortColumns, "book_id")
+}
+
+type memoryScheduleTemplateModel struct {
+	db *MemoryDB
+}
+
+func (m *memoryScheduleTemplateModel) Get(ctx context.Context, id int64) (*ScheduleTemplate, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	tpl, ok := m.db.scheduleTemplates.get(id)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &tpl, nil
+}
+
+var memoryScheduleTemplateSortColumns = map[string]func(a, b *ScheduleTemplate) int{
+	"name": func(a, b *ScheduleTemplate) int { return strings.Compare(a.Name, b.Name) },
+	"total_study_blocks_per_week": func(a, b *ScheduleTemplate) int {
+		return cmp.Compare(a.TotalStudyBlocksPerWeek, b.TotalStudyBlocksPerWeek)
+	},
+	"id": func(a, b *ScheduleTemplate) int { return cmp.Compare(a.ID, b.ID) },
+}
+
+func (m *memoryScheduleTemplateModel) GetAll(ctx context.Context, gradeID, majorID int64, opts QueryOptions) ([]*ScheduleTemplate, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var templates []*ScheduleTemplate
+	for _, tpl := range m.db.scheduleTemplates.all() {
+		if tpl.TargetGradeID == gradeID && tpl.TargetMajorID == majorID {
+			templates = append(templates, &tpl)
+		}
+	}
+	return listRows(templates, opts, memoryScheduleTemplateSortColumns, "name")
+}
+
+type memoryTemplateRuleModel struct {
+	db *MemoryDB
+}
+
+func (m *memoryTemplateRuleModel) Insert(ctx context.Context, tr *TemplateRule) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	err := checkReferences("template_rules",
+		memoryReference{"template_id", m.db.scheduleTemplates.has(tr.TemplateID)},
+		memoryReference{"book_id", m.db.books.has(tr.BookID)},
+	)
+	if err != nil {
+		return err
+	}
+
+	tr.ID = m.db.templateRules.nextID()
+	m.db.templateRules.put(tr.ID, *tr)
+	return nil
+}
+
+func (m *memoryTemplateRuleModel) Get(ctx context.Context, id int64) (*TemplateRule, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	tr, ok := m.db.templateRules.get(id)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &tr, nil
+}
+
+var memoryTemplateRuleSortColumns = map[string]func(a, b *TemplateRule) int{
+	"id":                func(a, b *TemplateRule) int { return cmp.Compare(a.ID, b.ID) },
+	"book_id":           func(a, b *TemplateRule) int { return cmp.Compare(a.BookID, b.BookID) },
+	"default_frequency": func(a, b *TemplateRule) int { return cmp.Compare(a.DefaultFrequency, b.DefaultFrequency) },
+}
+
+func (m *memoryTemplateRuleModel) GetAllForTemplate(ctx context.Context, templateID int64, opts QueryOptions) ([]*TemplateRule, Metadata, error) {
+	if templateID < 1 {
+		return nil, Metadata{}, ErrorNotFound
+	}
+
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var rules []*TemplateRule
+	for _, tr := range m.db.templateRules.all() {
+		if tr.TemplateID == templateID {
+			rules = append(rules, &tr)
+		}
+	}
+	return listRows(rules, opts, memoryTemplateRuleSortColumns, "id")
+}
+
+func (m *memoryTemplateRuleModel) Update(ctx context.Context, tr *TemplateRule) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	existing, ok := m.db.templateRules.get(tr.ID)
+	if !ok || existing.TemplateID != tr.TemplateID {
+		return ErrorNotFound
+	}
+	err := checkReferences("template_rules",
+		memoryReference{"book_id", m.db.books.has(tr.BookID)},
+	)
+	if err != nil {
+		return err
+	}
+
+	m.db.templateRules.put(tr.ID, *tr)
+	return nil
+}
+
+func (m *memoryTemplateRuleModel) Delete(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !m.db.templateRules.delete(id) {
+		return ErrorNotFound
+	}
+	return nil
+}
+
+type memoryTemplateSubjectWeightModel struct {
+	db *MemoryDB
+}
+
+var memoryTemplateSubjectWeightSortColumns = map[string]func(a, b *TemplateSubjectWeight) int{
+	"id":      func(a, b *TemplateSubjectWeight) int { return cmp.Compare(a.ID, b.ID) },
+	"book_id": func(a, b *TemplateSubjectWeight) int { return cmp.Compare(a.BookID, b.BookID) },
+	"weight":  func(a, b *TemplateSubjectWeight) int { return cmp.Compare(a.Weight, b.Weight) },
+}
+
+func (m *memoryTemplateSubjectWeightModel) GetWeightsForTemplate(ctx context.Context, templateID int64, opts QueryOptions) ([]*TemplateSubjectWeight, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var weights []*TemplateSubjectWeight
+	for _, weight := range m.db.templateSubjectWeights.all() {
+		if weight.TemplateID == templateID {
+			weights = append(weights, &weight)
+		}
+	}
+	return listRows(weights, opts, memoryTemplateSubjectWeightSortColumns, "id")
+}
+
+// SetWeight inserts the weight or replaces the one already set for the
+// template and book. Weights are stored as DECIMAL(3,2).
+func (m *memoryTemplateSubjectWeightModel) SetWeight(ctx context.Context, weight *TemplateSubjectWeight) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	rounded := math.Round(weight.Weight*100) / 100
+	if math.Abs(rounded) >= 10 {
+		return invalidValueError("template_subject_weights", "weight", weight.Weight)
+	}
+	err := checkReferences("template_subject_weights",
+		memoryReference{"template_id", m.db.scheduleTemplates.has(weight.TemplateID)},
+		memoryReference{"book_id", m.db.books.has(weight.BookID)},
+	)
+	if err != nil {
+		return err
+	}
+
+	weight.ID = 0
+	for _, existing := range m.db.templateSubjectWeights.all() {
+		if existing.TemplateID == weight.TemplateID && existing.BookID == weight.BookID {
+			weight.ID = existing.ID
+		}
+	}
+	if weight.ID == 0 {
+		weight.ID = m.db.templateSubjectWeights.nextID()
+	}
+
+	row := *weight
+	row.Weight = rounded
+	m.db.templateSubjectWeights.put(row.ID, row)
+	return nil
+}
@@ -0,0 +1,524 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+)
+
+// cascadeExamSchedule deletes what references a deleted exam, as the ON
+// DELETE CASCADE foreign keys do.
+func (db *MemoryDB) cascadeExamSchedule(id int64) {
+	db.examScopeItems.deleteWhere(func(esi ExamScopeItem) bool { return esi.ExamID == id })
+	db.examEnrollments.deleteWhere(func(ee ExamEnrollment) bool { return ee.ExamID == id })
+	db.examResults.deleteWhere(func(er ExamResult) bool { return er.ExamID == id })
+}
+
+// checkExamSchedule enforces the foreign keys of the exam_schedules table.
+func (db *MemoryDB) checkExamSchedule(es *ExamSchedule) error {
+	return checkReferences("exam_schedules",
+		memoryReference{"target_grade_id", db.grades.has(es.TargetGradeID)},
+		memoryReference{"major_id", db.majors.has(es.MajorID)},
+		memoryReference{"series_id", es.SeriesID == nil || db.examSeries.has(*es.SeriesID)},
+	)
+}
+
+// checkExamScopeItem enforces the foreign keys and the target check of the
+// exam_scope_items table. The exam itself is checked by the caller.
+func (db *MemoryDB) checkExamScopeItem(esi *ExamScopeItem) error {
+	err := checkReferences("exam_scope_items",
+		memoryReference{"lesson_id", esi.LessonID == nil || db.lessons.has(*esi.LessonID)},
+		memoryReference{"book_id", esi.BookID == nil || db.books.has(*esi.BookID)},
+	)
+	if err != nil {
+		return err
+	}
+
+	noRange := esi.FromLessonNumber == nil && esi.ToLessonNumber == nil
+	switch {
+	case esi.LessonID != nil && esi.BookID == nil && noRange:
+	case esi.LessonID == nil && esi.BookID != nil && noRange:
+	case esi.LessonID == nil && esi.BookID != nil && esi.FromLessonNumber != nil && esi.ToLessonNumber != nil &&
+		*esi.FromLessonNumber >= 1 && *esi.ToLessonNumber >= *esi.FromLessonNumber:
+	default:
+		return constraintError(ErrorValidation, "exam_scope_items", "exam_scope_items_target_check")
+	}
+	return nil
+}
+
+// examScopeLessons returns the distinct lessons in the scope of an exam, in id
+// order, with book and lesson range items expanded.
+func (db *MemoryDB) examScopeLessons(examID int64) []Lesson {
+	numbers := make(map[int64]int64)
+	counts := make(map[int64]int64)
+	lessons := db.lessons.all()
+	for _, lesson := range lessons {
+		counts[lesson.BookID]++
+		numbers[lesson.ID] = counts[lesson.BookID]
+	}
+
+	items := db.examScopeItems.all()
+	var scope []Lesson
+	for _, lesson := range lessons {
+		for _, esi := range items {
+			if esi.ExamID != examID {
+				continue
+			}
+			if esi.LessonID != nil && *esi.LessonID == lesson.ID ||
+				esi.LessonID == nil && *esi.BookID == lesson.BookID &&
+					(esi.FromLessonNumber == nil ||
+						numbers[lesson.ID] >= *esi.FromLessonNumber && numbers[lesson.ID] <= *esi.ToLessonNumber) {
+				scope = append(scope, lesson)
+				break
+			}
+		}
+	}
+	return scope
+}
+
+type memoryExamScheduleModel struct {
+	db *MemoryDB
+}
+
+func (m *memoryExamScheduleModel) Insert(ctx context.Context, es *ExamSchedule) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if err := m.db.checkExamSchedule(es); err != nil {
+		return err
+	}
+
+	es.ID = m.db.examSchedules.nextID()
+	row := *es
+	row.ExamDate = memoryDate(es.ExamDate)
+	m.db.examSchedules.put(es.ID, row)
+	return nil
+}
+
+func (m *memoryExamScheduleModel) Get(ctx context.Context, id int64) (*ExamSchedule, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	es, ok := m.db.examSchedules.get(id)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &es, nil
+}
+
+var memoryExamScheduleSortColumns = map[string]func(a, b *ExamSchedule) int{
+	"exam_date": func(a, b *ExamSchedule) int { return a.ExamDate.Compare(b.ExamDate) },
+	"title":     func(a, b *ExamSchedule) int { return strings.Compare(a.Title, b.Title) },
+	"id":        func(a, b *ExamSchedule) int { return cmp.Compare(a.ID, b.ID) },
+}
+
+// listExamSchedules lists the exams matching match and dated within the
+// bounds of opts.
+func (db *MemoryDB) listExamSchedules(opts QueryOptions, match func(es *ExamSchedule) bool) ([]*ExamSchedule, Metadata, error) {
+	var exams []*ExamSchedule
+	for _, es := range db.examSchedules.all() {
+		if match(&es) && inDateRange(es.ExamDate, opts.From, opts.To) {
+			exams = append(exams, &es)
+		}
+	}
+	return listRows(exams, opts, memoryExamScheduleSortColumns, "exam_date")
+}
+
+func (m *memoryExamScheduleModel) GetAllForStudentCurriculum(ctx context.Context, gradeID, majorID int64, opts QueryOptions) ([]*ExamSchedule, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	return m.db.listExamSchedules(opts, func(es *ExamSchedule) bool {
+		return es.TargetGradeID == gradeID && es.MajorID == majorID
+	})
+}
+
+func (m *memoryExamScheduleModel) GetAllForStudent(ctx context.Context, studentID int64, opts QueryOptions) ([]*ExamSchedule, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	enrolled := make(map[int64]bool)
+	for _, ee := range m.db.examEnrollments.all() {
+		if ee.StudentID == studentID {
+			enrolled[ee.ExamID] = true
+		}
+	}
+	return m.db.listExamSchedules(opts, func(es *ExamSchedule) bool { return enrolled[es.ID] })
+}
+
+func (m *memoryExamScheduleModel) GetAllForSeries(ctx context.Context, seriesID int64, opts QueryOptions) ([]*ExamSchedule, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	return m.db.listExamSchedules(opts, func(es *ExamSchedule) bool {
+		return es.SeriesID != nil && *es.SeriesID == seriesID
+	})
+}
+
+func (m *memoryExamScheduleModel) GetPrevious(ctx context.Context, es *ExamSchedule) (*ExamSchedule, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	date := memoryDate(es.ExamDate)
+	var previous *ExamSchedule
+	for _, other := range m.db.examSchedules.all() {
+		if c := other.ExamDate.Compare(date); c > 0 || c == 0 && other.ID >= es.ID {
+			continue
+		}
+		if es.SeriesID != nil {
+			if other.SeriesID == nil || *other.SeriesID != *es.SeriesID {
+				continue
+			}
+		} else if other.SeriesID != nil || other.Organisation != es.Organisation ||
+			other.TargetGradeID != es.TargetGradeID || other.MajorID != es.MajorID {
+			continue
+		}
+		// rows come in id order, so a later one on the same date wins
+		if previous == nil || !other.ExamDate.Before(previous.ExamDate) {
+			previous = &other
+		}
+	}
+
+	if previous == nil {
+		return nil, ErrorNotFound
+	}
+	return previous, nil
+}
+
+func (m *memoryExamScheduleModel) Update(ctx context.Context, es *ExamSchedule) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !m.db.examSchedules.has(es.ID) {
+		return ErrorNotFound
+	}
+	if err := m.db.checkExamSchedule(es); err != nil {
+		return err
+	}
+
+	row := *es
+	row.ExamDate = memoryDate(es.ExamDate)
+	m.db.examSchedules.put(es.ID, row)
+	return nil
+}
+
+func (m *memoryExamScheduleModel) Delete(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !m.db.examSchedules.delete(id) {
+		return ErrorNotFound
+	}
+	m.db.cascadeExamSchedule(id)
+	return nil
+}
+
+type memoryExamScopeItemModel struct {
+	db *MemoryDB
+}
+
+func (m *memoryExamScopeItemModel) Insert(ctx context.Context, esi *ExamScopeItem) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	err := checkReferences("exam_scope_items",
+		memoryReference{"exam_id", m.db.examSchedules.has(esi.ExamID)},
+	)
+	if err != nil {
+		return err
+	}
+	if err := m.db.checkExamScopeItem(esi); err != nil {
+		return err
+	}
+
+	esi.ID = m.db.examScopeItems.nextID()
+	m.db.examScopeItems.put(esi.ID, *esi)
+	return nil
+}
+
+var memoryExamScopeItemSortColumns = map[string]func(a, b *ExamScopeItem) int{
+	"id":        func(a, b *ExamScopeItem) int { return cmp.Compare(a.ID, b.ID) },
+	"book_id":   func(a, b *ExamScopeItem) int { return compareOptionalInt64(a.BookID, b.BookID) },
+	"lesson_id": func(a, b *ExamScopeItem) int { return compareOptionalInt64(a.LessonID, b.LessonID) },
+}
+
+func (m *memoryExamScopeItemModel) GetAllForExam(ctx context.Context, examID int64, opts QueryOptions) ([]*ExamScopeItem, Metadata, error) {
+	if examID < 1 {
+		return nil, Metadata{}, ErrorNotFound
+	}
+
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var items []*ExamScopeItem
+	for _, esi := range m.db.examScopeItems.all() {
+		if esi.ExamID == examID {
+			items = append(items, &esi)
+		}
+	}
+	return listRows(items, opts, memoryExamScopeItemSortColumns, "id")
+}
+
+func (m *memoryExamScopeItemModel) Delete(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !m.db.examScopeItems.delete(id) {
+		return ErrorNotFound
+	}
+	return nil
+}
+
+type memoryExamEnrollmentModel struct {
+	db *MemoryDB
+}
+
+func (m *memoryExamEnrollmentModel) Insert(ctx context.Context, ee *ExamEnrollment) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	err := checkReferences("exam_enrollments",
+		memoryReference{"student_id", m.db.students.has(ee.StudentID)},
+		memoryReference{"exam_id", m.db.examSchedules.has(ee.ExamID)},
+	)
+	if err != nil {
+		return err
+	}
+	for _, other := range m.db.examEnrollments.all() {
+		if other.StudentID == ee.StudentID && other.ExamID == ee.ExamID {
+			return ErrorAlreadyEnrolled
+		}
+	}
+
+	ee.ID = m.db.examEnrollments.nextID()
+	ee.EnrolledAt = memoryNow()
+	m.db.examEnrollments.put(ee.ID, *ee)
+	return nil
+}
+
+func (m *memoryExamEnrollmentModel) Get(ctx context.Context, studentID, examID int64) (*ExamEnrollment, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	for _, ee := range m.db.examEnrollments.all() {
+		if ee.StudentID == studentID && ee.ExamID == examID {
+			return &ee, nil
+		}
+	}
+	return nil, ErrorNotFound
+}
+
+func (m *memoryExamEnrollmentModel) Delete(ctx context.Context, studentID, examID int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	deleted := m.db.examEnrollments.deleteWhere(func(ee ExamEnrollment) bool {
+		return ee.StudentID == studentID && ee.ExamID == examID
+	})
+	if len(deleted) == 0 {
+		return ErrorNotFound
+	}
+	return nil
+}
+
+type memoryExamSeriesModel struct {
+	db *MemoryDB
+}
+
+func (m *memoryExamSeriesModel) Get(ctx context.Context, id int64) (*ExamSeries, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	series, ok := m.db.examSeries.get(id)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &series, nil
+}
+
+// InsertSeason checks every row before inserting any, so a failed import
+// leaves nothing behind, like the transaction of its SQL counterpart.
+func (m *memoryExamSeriesModel) InsertSeason(ctx context.Context, series *ExamSeries, exams []*SeasonExam) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	err := checkReferences("exam_series",
+		memoryReference{"target_grade_id", m.db.grades.has(series.TargetGradeID)},
+		memoryReference{"major_id", m.db.majors.has(series.MajorID)},
+	)
+	if err != nil {
+		return err
+	}
+	if series.IntervalDays != nil && *series.IntervalDays <= 0 {
+		return constraintError(ErrorValidation, "exam_series", "exam_series_interval_days_check")
+	}
+	for _, se := range exams {
+		// the series is checked above; its id is not handed out yet
+		exam := *se.Exam
+		exam.SeriesID = nil
+		if err := m.db.checkExamSchedule(&exam); err != nil {
+			return err
+		}
+		for _, esi := range se.ScopeItems {
+			if err := m.db.checkExamScopeItem(esi); err != nil {
+				return err
+			}
+		}
+	}
+
+	series.ID = m.db.examSeries.nextID()
+	m.db.examSeries.put(series.ID, *series)
+	for _, se := range exams {
+		es := se.Exam
+		seriesID := series.ID
+		es.SeriesID = &seriesID
+		es.ID = m.db.examSchedules.nextID()
+		row := *es
+		row.ExamDate = memoryDate(es.ExamDate)
+		m.db.examSchedules.put(es.ID, row)
+
+		for _, esi := range se.ScopeItems {
+			esi.ExamID = es.ID
+			esi.ID = m.db.examScopeItems.nextID()
+			m.db.examScopeItems.put(esi.ID, *esi)
+		}
+	}
+	return nil
+}
+
+type memoryExamResultModel struct {
+	db *MemoryDB
+}
+
+// checkExamResult enforces the foreign keys and checks of the exam_results
+// table.
+func (db *MemoryDB) checkExamResult(er *ExamResult) error {
+	err := checkReferences("exam_results",
+		memoryReference{"student_id", db.students.has(er.StudentID)},
+		memoryReference{"exam_id", db.examSchedules.has(er.ExamID)},
+		memoryReference{"book_id", db.books.has(er.BookID)},
+	)
+	if err != nil {
+		return err
+	}
+
+	checks := []struct {
+		constraint string
+		ok         bool
+	}{
+		{"exam_results_percentage_check", er.Percentage >= -100 && er.Percentage <= 100},
+		{"exam_results_rank_check", er.Rank == nil || *er.Rank > 0},
+		{"exam_results_num_correct_check", er.NumCorrect >= 0},
+		{"exam_results_num_wrong_check", er.NumWrong >= 0},
+		{"exam_results_num_blank_check", er.NumBlank >= 0},
+	}
+	for _, check := range checks {
+		if !check.ok {
+			return constraintError(ErrorValidation, "exam_results", check.constraint)
+		}
+	}
+	return nil
+}
+
+// Upsert checks every result before recording any, like the transaction of
+// its SQL counterpart.
+func (m *memoryExamResultModel) Upsert(ctx context.Context, results []*ExamResult) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, er := range results {
+		if err := m.db.checkExamResult(er); err != nil {
+			return err
+		}
+	}
+
+	for _, er := range results {
+		er.ID = 0
+		for _, other := range m.db.examResults.all() {
+			if other.StudentID == er.StudentID && other.ExamID == er.ExamID && other.BookID == er.BookID {
+				er.ID = other.ID
+				break
+			}
+		}
+		if er.ID == 0 {
+			er.ID = m.db.examResults.nextID()
+		}
+		er.RecordedAt = memoryNow()
+
+		row := *er
+		row.BookTitle = ""
+		m.db.examResults.put(er.ID, row)
+	}
+	return nil
+}
+
+var memoryExamResultSortColumns = map[string]func(a, b *ExamResult) int{
+	"book_title": func(a, b *ExamResult) int { return strings.Compare(a.BookTitle, b.BookTitle) },
+	"percentage": func(a, b *ExamResult) int { return cmp.Compare(a.Percentage, b.Percentage) },
+	"raw_score":  func(a, b *ExamResult) int { return cmp.Compare(a.RawScore, b.RawScore) },
+	"rank":       func(a, b *ExamResult) int { return compareOptionalInt64(a.Rank, b.Rank) },
+	"id":         func(a, b *ExamResult) int { return cmp.Compare(a.ID, b.ID) },
+}
+
+func (m *memoryExamResultModel) GetAllForExam(ctx context.Context, studentID, examID int64, opts QueryOptions) ([]*ExamResult, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var results []*ExamResult
+	for _, er := range m.db.examResults.all() {
+		if er.StudentID == studentID && er.ExamID == examID {
+			book, _ := m.db.books.get(er.BookID)
+			er.BookTitle = book.Title
+			results = append(results, &er)
+		}
+	}
+	return listRows(results, opts, memoryExamResultSortColumns, "book_title")
+}
+
+func (m *memoryExamResultModel) GetHistory(ctx context.Context, studentID int64, filter ExamResultFilter) ([]*ExamResultEntry, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var entries []*ExamResultEntry
+	for _, er := range m.db.examResults.all() {
+		es, _ := m.db.examSchedules.get(er.ExamID)
+		if er.StudentID != studentID ||
+			filter.SeriesID != 0 && (es.SeriesID == nil || *es.SeriesID != filter.SeriesID) ||
+			filter.BookID != 0 && er.BookID != filter.BookID ||
+			!inDateRange(es.ExamDate, filter.From, filter.To) {
+			continue
+		}
+
+		book, _ := m.db.books.get(er.BookID)
+		er.BookTitle = book.Title
+		entries = append(entries, &ExamResultEntry{
+			ExamResult: er,
+			ExamTitle:  es.Title,
+			ExamDate:   es.ExamDate,
+			SeriesID:   es.SeriesID,
+		})
+	}
+
+	slices.SortStableFunc(entries, func(a, b *ExamResultEntry) int {
+		return cmp.Or(
+			a.ExamDate.Compare(b.ExamDate),
+			cmp.Compare(a.ExamID, b.ExamID),
+			strings.Compare(a.BookTitle, b.BookTitle),
+		)
+	})
+	return entries, nil
+}
+
+func (m *memoryExamResultModel) DeleteForExam(ctx context.Context, studentID, examID int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	deleted := m.db.examResults.deleteWhere(func(er ExamResult) bool {
+		return er.StudentID == studentID && er.ExamID == examID
+	})
+	if len(deleted) == 0 {
+		return ErrorNotFound
+	}
+	return nil
+}
@@ -0,0 +1,562 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"math"
+	"strings"
+	"time"
+)
+
+// cascadeWeeklyPlan deletes what references a deleted weekly plan, as the
+// ON DELETE CASCADE foreign keys do.
+func (db *MemoryDB) cascadeWeeklyPlan(id int64) {
+	db.subjectFrequencies.deleteWhere(func(sf SubjectFrequency) bool { return sf.WeeklyPlanID == id })
+	for _, dailyPlanID := range db.dailyPlans.deleteWhere(func(dp DailyPlan) bool { return dp.WeeklyPlanID == id }) {
+		db.cascadeDailyPlan(dailyPlanID)
+	}
+}
+
+func (db *MemoryDB) cascadeDailyPlan(id int64) {
+	for _, sessionID := range db.studySessions.deleteWhere(func(ss StudySession) bool { return ss.DailyPlanID == id }) {
+		db.cascadeStudySession(sessionID)
+	}
+}
+
+func (db *MemoryDB) cascadeStudySession(id int64) {
+	db.sessionReports.deleteWhere(func(sr SessionReport) bool { return sr.StudySessionID == id })
+}
+
+type memoryWeeklyPlanModel struct {
+	db *MemoryDB
+}
+
+// weeklyPlanRow returns wp as the weekly_plans table stores it, or the error
+// for the constraint it violates.
+func (db *MemoryDB) weeklyPlanRow(wp *WeeklyPlan) (WeeklyPlan, error) {
+	row := *wp
+	row.StartDateOfWeek = memoryDate(wp.StartDateOfWeek)
+	row.DayStartTime = memoryNullClock(wp.DayStartTime)
+
+	err := checkReferences("weekly_plans",
+		memoryReference{"student_id", db.students.has(wp.StudentID)},
+	)
+	if err != nil {
+		return WeeklyPlan{}, err
+	}
+	for _, other := range db.weeklyPlans.all() {
+		if other.ID != wp.ID && other.StudentID == row.StudentID && other.StartDateOfWeek.Equal(row.StartDateOfWeek) {
+			return WeeklyPlan{}, constraintError(ErrorConflict, "weekly_plans", "weekly_plans_student_id_start_date_of_week_key")
+		}
+	}
+	return row, nil
+}
+
+func (m *memoryWeeklyPlanModel) Insert(ctx context.Context, wp *WeeklyPlan) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	candidate := *wp
+	candidate.ID = 0
+	candidate.GenerationSeed = sql.NullInt64{}
+	row, err := m.db.weeklyPlanRow(&candidate)
+	if err != nil {
+		return err
+	}
+
+	wp.ID = m.db.weeklyPlans.nextID()
+	row.ID = wp.ID
+	m.db.weeklyPlans.put(wp.ID, row)
+	return nil
+}
+
+func (m *memoryWeeklyPlanModel) Get(ctx context.Context, id int64) (*WeeklyPlan, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	wp, ok := m.db.weeklyPlans.get(id)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &wp, nil
+}
+
+func (m *memoryWeeklyPlanModel) GetByStudentAndStartDate(ctx context.Context, studentID int64, startDateOfWeek time.Time) (*WeeklyPlan, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	startDateOfWeek = memoryDate(startDateOfWeek)
+	for _, wp := range m.db.weeklyPlans.all() {
+		if wp.StudentID == studentID && wp.StartDateOfWeek.Equal(startDateOfWeek) {
+			return &wp, nil
+		}
+	}
+	return nil, ErrorNotFound
+}
+
+var memoryWeeklyPlanSortColumns = map[string]func(a, b *WeeklyPlan) int{
+	"start_date_of_week": func(a, b *WeeklyPlan) int { return a.StartDateOfWeek.Compare(b.StartDateOfWeek) },
+	"id":                 func(a, b *WeeklyPlan) int { return cmp.Compare(a.ID, b.ID) },
+}
+
+func (m *memoryWeeklyPlanModel) GetAllForStudent(ctx context.Context, studentID int64, opts QueryOptions) ([]*WeeklyPlan, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var plans []*WeeklyPlan
+	for _, wp := range m.db.weeklyPlans.all() {
+		if wp.StudentID == studentID && inDateRange(wp.StartDateOfWeek, opts.From, opts.To) {
+			plans = append(plans, &wp)
+		}
+	}
+	return listRows(plans, opts, memoryWeeklyPlanSortColumns, "-start_date_of_week")
+}
+
+func (m *memoryWeeklyPlanModel) Update(ctx context.Context, wp *WeeklyPlan) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	existing, ok := m.db.weeklyPlans.get(wp.ID)
+	if !ok || existing.StudentID != wp.StudentID {
+		return ErrorNotFound
+	}
+	row, err := m.db.weeklyPlanRow(wp)
+	if err != nil {
+		return err
+	}
+
+	row.GenerationSeed = existing.GenerationSeed
+	m.db.weeklyPlans.put(wp.ID, row)
+	return nil
+}
+
+func (m *memoryWeeklyPlanModel) SetGenerationSeed(ctx context.Context, id int64, seed int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	wp, ok := m.db.weeklyPlans.get(id)
+	if !ok {
+		return ErrorNotFound
+	}
+
+	wp.GenerationSeed = sql.NullInt64{Int64: seed, Valid: true}
+	m.db.weeklyPlans.put(id, wp)
+	return nil
+}
+
+func (m *memoryWeeklyPlanModel) Delete(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !m.db.weeklyPlans.delete(id) {
+		return ErrorNotFound
+	}
+	m.db.cascadeWeeklyPlan(id)
+	return nil
+}
+
+type memorySubjectFrequencyModel struct {
+	db *MemoryDB
+}
+
+func (m *memorySubjectFrequencyModel) Insert(ctx context.Context, sf *SubjectFrequency) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	err := checkReferences("subject_frequencies",
+		memoryReference{"weekly_plan_id", m.db.weeklyPlans.has(sf.WeeklyPlanID)},
+		memoryReference{"book_id", m.db.books.has(sf.BookID)},
+	)
+	if err != nil {
+		return err
+	}
+
+	sf.ID = m.db.subjectFrequencies.nextID()
+	m.db.subjectFrequencies.put(sf.ID, *sf)
+	return nil
+}
+
+var memorySubjectFrequencySortColumns = map[string]func(a, b *SubjectFrequency) int{
+	"id":                 func(a, b *SubjectFrequency) int { return cmp.Compare(a.ID, b.ID) },
+	"book_id":            func(a, b *SubjectFrequency) int { return cmp.Compare(a.BookID, b.BookID) },
+	"frequency_per_week": func(a, b *SubjectFrequency) int { return cmp.Compare(a.FrequencyPerWeek, b.FrequencyPerWeek) },
+}
+
+func (m *memorySubjectFrequencyModel) GetAllForWeeklyPlan(ctx context.Context, weeklyPlanID int64, opts QueryOptions) ([]*SubjectFrequency, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var frequencies []*SubjectFrequency
+	for _, sf := range m.db.subjectFrequencies.all() {
+		if sf.WeeklyPlanID == weeklyPlanID {
+			frequencies = append(frequencies, &sf)
+		}
+	}
+	return listRows(frequencies, opts, memorySubjectFrequencySortColumns, "id")
+}
+
+func (m *memorySubjectFrequencyModel) Update(ctx context.Context, sf *SubjectFrequency) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	existing, ok := m.db.subjectFrequencies.get(sf.ID)
+	if !ok || existing.WeeklyPlanID != sf.WeeklyPlanID {
+		return ErrorNotFound
+	}
+	err := checkReferences("subject_frequencies",
+		memoryReference{"book_id", m.db.books.has(sf.BookID)},
+	)
+	if err != nil {
+		return err
+	}
+
+	m.db.subjectFrequencies.put(sf.ID, *sf)
+	return nil
+}
+
+// ReplaceForWeeklyPlan checks every row before changing any, so a failed
+// replace leaves the old frequencies, like the transaction of its SQL
+// counterpart.
+func (m *memorySubjectFrequencyModel) ReplaceForWeeklyPlan(ctx context.Context, weeklyPlanID int64, frequencies []*SubjectFrequency) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, sf := range frequencies {
+		err := checkReferences("subject_frequencies",
+			memoryReference{"weekly_plan_id", m.db.weeklyPlans.has(weeklyPlanID)},
+			memoryReference{"book_id", m.db.books.has(sf.BookID)},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	m.db.subjectFrequencies.deleteWhere(func(sf SubjectFrequency) bool { return sf.WeeklyPlanID == weeklyPlanID })
+	for _, sf := range frequencies {
+		sf.WeeklyPlanID = weeklyPlanID
+		sf.ID = m.db.subjectFrequencies.nextID()
+		m.db.subjectFrequencies.put(sf.ID, *sf)
+	}
+	return nil
+}
+
+func (m *memorySubjectFrequencyModel) Delete(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !m.db.subjectFrequencies.delete(id) {
+		return ErrorNotFound
+	}
+	return nil
+}
+
+type memoryDailyPlanModel struct {
+	db *MemoryDB
+}
+
+func (m *memoryDailyPlanModel) Insert(ctx context.Context, dp *DailyPlan) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	row := *dp
+	row.PlanDate = memoryDate(dp.PlanDate)
+
+	err := checkReferences("daily_plans",
+		memoryReference{"weekly_plan_id", m.db.weeklyPlans.has(dp.WeeklyPlanID)},
+	)
+	if err != nil {
+		return err
+	}
+	for _, other := range m.db.dailyPlans.all() {
+		if other.WeeklyPlanID == row.WeeklyPlanID && other.PlanDate.Equal(row.PlanDate) {
+			return constraintError(ErrorConflict, "daily_plans", "daily_plans_weekly_plan_id_plan_date_key")
+		}
+	}
+
+	dp.ID = m.db.dailyPlans.nextID()
+	row.ID = dp.ID
+	m.db.dailyPlans.put(dp.ID, row)
+	return nil
+}
+
+func (m *memoryDailyPlanModel) Get(ctx context.Context, id int64) (*DailyPlan, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	dp, ok := m.db.dailyPlans.get(id)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &dp, nil
+}
+
+func (m *memoryDailyPlanModel) GetByWeeklyPlanAndDate(ctx context.Context, weeklyPlanID int64, planDate time.Time) (*DailyPlan, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	planDate = memoryDate(planDate)
+	for _, dp := range m.db.dailyPlans.all() {
+		if dp.WeeklyPlanID == weeklyPlanID && dp.PlanDate.Equal(planDate) {
+			return &dp, nil
+		}
+	}
+	return nil, ErrorNotFound
+}
+
+var memoryDailyPlanSortColumns = map[string]func(a, b *DailyPlan) int{
+	"plan_date": func(a, b *DailyPlan) int { return a.PlanDate.Compare(b.PlanDate) },
+	"id":        func(a, b *DailyPlan) int { return cmp.Compare(a.ID, b.ID) },
+}
+
+func (m *memoryDailyPlanModel) GetAllForWeeklyPlan(ctx context.Context, weeklyPlanID int64, opts QueryOptions) ([]*DailyPlan, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var plans []*DailyPlan
+	for _, dp := range m.db.dailyPlans.all() {
+		if dp.WeeklyPlanID == weeklyPlanID && inDateRange(dp.PlanDate, opts.From, opts.To) {
+			plans = append(plans, &dp)
+		}
+	}
+	return listRows(plans, opts, memoryDailyPlanSortColumns, "plan_date")
+}
+
+func (m *memoryDailyPlanModel) Delete(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !m.db.dailyPlans.delete(id) {
+		return ErrorNotFound
+	}
+	m.db.cascadeDailyPlan(id)
+	return nil
+}
+
+type memoryStudySessionModel struct {
+	db *MemoryDB
+}
+
+// studySessionRow returns ss as the study_sessions table stores it, or the
+// error for the constraint it violates.
+func (db *MemoryDB) studySessionRow(ss *StudySession) (StudySession, error) {
+	row := *ss
+	row.CompletionDate = memoryNullDate(ss.CompletionDate)
+
+	var err error
+	if row.StartTime, err = memoryTimeOfDay("study_sessions", "start_time", ss.StartTime); err != nil {
+		return StudySession{}, err
+	}
+	if row.EndTime, err = memoryTimeOfDay("study_sessions", "end_time", ss.EndTime); err != nil {
+		return StudySession{}, err
+	}
+
+	err = checkReferences("study_sessions",
+		memoryReference{"daily_plan_id", db.dailyPlans.has(ss.DailyPlanID)},
+		memoryReference{"book_id", db.books.has(ss.BookID)},
+		optionalReference("lesson_id", ss.LessonID, &db.lessons),
+	)
+	if err != nil {
+		return StudySession{}, err
+	}
+	return row, nil
+}
+
+func (m *memoryStudySessionModel) Insert(ctx context.Context, ss *StudySession) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	row, err := m.db.studySessionRow(ss)
+	if err != nil {
+		return err
+	}
+
+	ss.ID = m.db.studySessions.nextID()
+	ss.CompletionDate = row.CompletionDate
+	row.ID = ss.ID
+	m.db.studySessions.put(ss.ID, row)
+	return nil
+}
+
+func (m *memoryStudySessionModel) ReplaceUnfinishedForWeeklyPlan(ctx context.Context, weeklyPlanID int64, sessions []*StudySession) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	rows := make([]StudySession, len(sessions))
+	for i, ss := range sessions {
+		row, err := m.db.studySessionRow(ss)
+		if err != nil {
+			return err
+		}
+		rows[i] = row
+	}
+
+	unfinished := m.db.studySessions.deleteWhere(func(ss StudySession) bool {
+		dp, _ := m.db.dailyPlans.get(ss.DailyPlanID)
+		return dp.WeeklyPlanID == weeklyPlanID && !ss.IsCompleted
+	})
+	for _, id := range unfinished {
+		m.db.cascadeStudySession(id)
+	}
+
+	for i, ss := range sessions {
+		ss.ID = m.db.studySessions.nextID()
+		ss.CompletionDate = rows[i].CompletionDate
+		rows[i].ID = ss.ID
+		m.db.studySessions.put(ss.ID, rows[i])
+	}
+	return nil
+}
+
+func (m *memoryStudySessionModel) Get(ctx context.Context, id int64) (*StudySession, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	ss, ok := m.db.studySessions.get(id)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &ss, nil
+}
+
+var memoryStudySessionSortColumns = map[string]func(a, b *StudySession) int{
+	"start_time":      func(a, b *StudySession) int { return strings.Compare(a.StartTime, b.StartTime) },
+	"end_time":        func(a, b *StudySession) int { return strings.Compare(a.EndTime, b.EndTime) },
+	"is_completed":    func(a, b *StudySession) int { return compareBool(a.IsCompleted, b.IsCompleted) },
+	"completion_date": func(a, b *StudySession) int { return compareNullTime(a.CompletionDate, b.CompletionDate) },
+	"id":              func(a, b *StudySession) int { return cmp.Compare(a.ID, b.ID) },
+}
+
+// GetAllForDailyPlan filters by opts.Completed and bounds the completion date
+// by opts.From and opts.To, which leaves out sessions never completed.
+func (m *memoryStudySessionModel) GetAllForDailyPlan(ctx context.Context, dailyPlanID int64, opts QueryOptions) ([]*StudySession, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var sessions []*StudySession
+	for _, ss := range m.db.studySessions.all() {
+		if ss.DailyPlanID != dailyPlanID {
+			continue
+		}
+		if opts.Completed != nil && ss.IsCompleted != *opts.Completed {
+			continue
+		}
+		if (!opts.From.IsZero() || !opts.To.IsZero()) &&
+			(!ss.CompletionDate.Valid || !inDateRange(ss.CompletionDate.Time, opts.From, opts.To)) {
+			continue
+		}
+		sessions = append(sessions, &ss)
+	}
+	return listRows(sessions, opts, memoryStudySessionSortColumns, "start_time")
+}
+
+func (m *memoryStudySessionModel) Update(ctx context.Context, ss *StudySession) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !m.db.studySessions.has(ss.ID) {
+		return ErrorNotFound
+	}
+	row, err := m.db.studySessionRow(ss)
+	if err != nil {
+		return err
+	}
+
+	m.db.studySessions.put(ss.ID, row)
+	return nil
+}
+
+func (m *memoryStudySessionModel) Delete(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !m.db.studySessions.delete(id) {
+		return ErrorNotFound
+	}
+	m.db.cascadeStudySession(id)
+	return nil
+}
+
+type memorySessionReportModel struct {
+	db *MemoryDB
+}
+
+// sessionScore returns the score as DECIMAL(5,2) stores it.
+func sessionScore(score float64) (float64, error) {
+	rounded := math.Round(score*100) / 100
+	if math.Abs(rounded) >= 1000 {
+		return 0, invalidValueError("session_reports", "session_score", score)
+	}
+	return rounded, nil
+}
+
+func (m *memorySessionReportModel) Insert(ctx context.Context, sr *SessionReport) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	row := *sr
+	var err error
+	if row.SessionScore, err = sessionScore(sr.SessionScore); err != nil {
+		return err
+	}
+	err = checkReferences("session_reports",
+		memoryReference{"study_session_id", m.db.studySessions.has(sr.StudySessionID)},
+	)
+	if err != nil {
+		return err
+	}
+	for _, other := range m.db.sessionReports.all() {
+		if other.StudySessionID == sr.StudySessionID {
+			return constraintError(ErrorConflict, "session_reports", "session_reports_study_session_id_key")
+		}
+	}
+
+	sr.ID = m.db.sessionReports.nextID()
+	row.ID = sr.ID
+	m.db.sessionReports.put(sr.ID, row)
+	return nil
+}
+
+func (m *memorySessionReportModel) GetForStudySession(ctx context.Context, studySessionID int64) (*SessionReport, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	for _, sr := range m.db.sessionReports.all() {
+		if sr.StudySessionID == studySessionID {
+			return &sr, nil
+		}
+	}
+	return nil, ErrorNotFound
+}
+
+func (m *memorySessionReportModel) Update(ctx context.Context, sr *SessionReport) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	row, ok := m.db.sessionReports.get(sr.ID)
+	if !ok {
+		return ErrorNotFound
+	}
+	score, err := sessionScore(sr.SessionScore)
+	if err != nil {
+		return err
+	}
+
+	row.IsReview = sr.IsReview
+	row.NumTests = sr.NumTests
+	row.NumWrongTests = sr.NumWrongTests
+	row.SessionScore = score
+	row.Notes = sr.Notes
+	m.db.sessionReports.put(sr.ID, row)
+	return nil
+}
+
+func (m *memorySessionReportModel) Delete(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !m.db.sessionReports.delete(id) {
+		return ErrorNotFound
+	}
+	return nil
+}
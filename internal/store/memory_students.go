@@ -0,0 +1,263 @@
+package store
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// memoryStudent is a students row, including the columns Student leaves out.
+type memoryStudent struct {
+	Student
+	calendarTokenHash []byte
+}
+
+type memoryStudentModel struct {
+	db *MemoryDB
+}
+
+// checkStudent enforces the constraints of the students table on student,
+// stored under id, which is 0 for a new row.
+func (db *MemoryDB) checkStudent(id int64, student *Student) error {
+	for _, other := range db.students.all() {
+		if other.ID != id && other.Email == student.Email {
+			return studentError(constraintError(ErrorConflict, "students", "students_email_key"))
+		}
+	}
+	return checkReferences("students",
+		memoryReference{"grade_id", db.grades.has(student.GradeID)},
+		memoryReference{"major_id", db.majors.has(student.MajorID)},
+	)
+}
+
+func (m *memoryStudentModel) Insert(ctx context.Context, student *Student) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if student.Timezone == "" {
+		student.Timezone = DefaultTimezone
+	}
+
+	if err := m.db.checkStudent(0, student); err != nil {
+		return err
+	}
+
+	student.ID = m.db.students.nextID()
+	m.db.students.put(student.ID, memoryStudent{Student: *student})
+	return nil
+}
+
+func (m *memoryStudentModel) Get(ctx context.Context, id int64) (*Student, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	row, ok := m.db.students.get(id)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &row.Student, nil
+}
+
+func (m *memoryStudentModel) Update(ctx context.Context, student *Student) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	row, ok := m.db.students.get(student.ID)
+	if !ok {
+		return ErrorNotFound
+	}
+	if err := m.db.checkStudent(student.ID, student); err != nil {
+		return err
+	}
+
+	row.Student = *student
+	m.db.students.put(student.ID, row)
+	return nil
+}
+
+func (m *memoryStudentModel) SetCalendarTokenHash(ctx context.Context, id int64, hash []byte) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	row, ok := m.db.students.get(id)
+	if !ok {
+		return ErrorNotFound
+	}
+	if hash != nil {
+		for _, other := range m.db.students.all() {
+			if other.ID != id && bytes.Equal(other.calendarTokenHash, hash) {
+				return constraintError(ErrorConflict, "students", "students_calendar_token_hash_key")
+			}
+		}
+	}
+
+	row.calendarTokenHash = bytes.Clone(hash)
+	m.db.students.put(id, row)
+	return nil
+}
+
+func (m *memoryStudentModel) GetByCalendarTokenHash(ctx context.Context, hash []byte) (*Student, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	if hash == nil {
+		return nil, ErrorNotFound
+	}
+	for _, row := range m.db.students.all() {
+		if bytes.Equal(row.calendarTokenHash, hash) {
+			return &row.Student, nil
+		}
+	}
+	return nil, ErrorNotFound
+}
+
+func (m *memoryStudentModel) Delete(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !m.db.students.delete(id) {
+		return ErrorNotFound
+	}
+
+	m.db.unavailableTimes.deleteWhere(func(ut UnavailableTime) bool { return ut.StudentID == id })
+	m.db.examEnrollments.deleteWhere(func(ee ExamEnrollment) bool { return ee.StudentID == id })
+	m.db.examResults.deleteWhere(func(er ExamResult) bool { return er.StudentID == id })
+	for _, planID := range m.db.weeklyPlans.deleteWhere(func(wp WeeklyPlan) bool { return wp.StudentID == id }) {
+		m.db.cascadeWeeklyPlan(planID)
+	}
+	return nil
+}
+
+type memoryUnavailableTimeModel struct {
+	db *MemoryDB
+}
+
+// unavailableTimeRow returns ut as the unavailable_times table stores it.
+func (db *MemoryDB) unavailableTimeRow(ut *UnavailableTime) (UnavailableTime, error) {
+	err := checkReferences("unavailable_times",
+		memoryReference{"student_id", db.students.has(ut.StudentID)},
+	)
+	if err != nil {
+		return UnavailableTime{}, err
+	}
+
+	row := *ut
+	row.StartTime = memoryClock(ut.StartTime)
+	row.EndTime = memoryClock(ut.EndTime)
+	row.SpecificDate = memoryNullDate(ut.SpecificDate)
+	return row, nil
+}
+
+func (m *memoryUnavailableTimeModel) Insert(ctx context.Context, ut *UnavailableTime) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	row, err := m.db.unavailableTimeRow(ut)
+	if err != nil {
+		return err
+	}
+
+	ut.ID = m.db.unavailableTimes.nextID()
+	row.ID = ut.ID
+	m.db.unavailableTimes.put(ut.ID, row)
+	return nil
+}
+
+// InsertBatch inserts all entries or, when one of them fails, none.
+func (m *memoryUnavailableTimeModel) InsertBatch(ctx context.Context, times []*UnavailableTime) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	rows := make([]UnavailableTime, len(times))
+	for i, ut := range times {
+		row, err := m.db.unavailableTimeRow(ut)
+		if err != nil {
+			return fmt.Errorf("failed to insert unavailable time: %w", err)
+		}
+		rows[i] = row
+	}
+
+	for i, ut := range times {
+		ut.ID = m.db.unavailableTimes.nextID()
+		rows[i].ID = ut.ID
+		m.db.unavailableTimes.put(ut.ID, rows[i])
+	}
+	return nil
+}
+
+func (m *memoryUnavailableTimeModel) Get(ctx context.Context, id int64) (*UnavailableTime, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	ut, ok := m.db.unavailableTimes.get(id)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &ut, nil
+}
+
+// Update, like its SQL counterpart, does nothing for an unknown id.
+func (m *memoryUnavailableTimeModel) Update(ctx context.Context, ut *UnavailableTime) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	existing, ok := m.db.unavailableTimes.get(ut.ID)
+	if !ok {
+		return nil
+	}
+
+	updated := *ut
+	updated.StudentID = existing.StudentID
+	row, err := m.db.unavailableTimeRow(&updated)
+	if err != nil {
+		return err
+	}
+	m.db.unavailableTimes.put(ut.ID, row)
+	return nil
+}
+
+func (m *memoryUnavailableTimeModel) Delete(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	m.db.unavailableTimes.delete(id)
+	return nil
+}
+
+var memoryUnavailableTimeSortColumns = map[string]func(a, b *UnavailableTime) int{
+	"day_of_week": func(a, b *UnavailableTime) int {
+		return cmp.Or(cmp.Compare(a.DayOfWeek, b.DayOfWeek), a.StartTime.Compare(b.StartTime))
+	},
+	"specific_date": func(a, b *UnavailableTime) int {
+		return cmp.Or(
+			compareBool(a.SpecificDate.Valid, b.SpecificDate.Valid),
+			compareNullTime(a.SpecificDate, b.SpecificDate),
+			cmp.Compare(a.DayOfWeek, b.DayOfWeek),
+			a.StartTime.Compare(b.StartTime),
+		)
+	},
+	"start_time": func(a, b *UnavailableTime) int { return a.StartTime.Compare(b.StartTime) },
+	"title":      func(a, b *UnavailableTime) int { return strings.Compare(a.Title, b.Title) },
+	"id":         func(a, b *UnavailableTime) int { return cmp.Compare(a.ID, b.ID) },
+}
+
+// GetAllForStudent bounds one-off entries by opts.From and opts.To and always
+// includes recurring ones.
+func (m *memoryUnavailableTimeModel) GetAllForStudent(ctx context.Context, studentID int64, opts QueryOptions) ([]*UnavailableTime, Metadata, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var times []*UnavailableTime
+	for _, ut := range m.db.unavailableTimes.all() {
+		if ut.StudentID != studentID {
+			continue
+		}
+		if ut.SpecificDate.Valid && !inDateRange(ut.SpecificDate.Time, opts.From, opts.To) {
+			continue
+		}
+		times = append(times, &ut)
+	}
+	return listRows(times, opts, memoryUnavailableTimeSortColumns, "specific_date")
+}
@@ -0,0 +1,191 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newTestMemoryStorage returns a memory store with one grade, major, book and
+// student, whose IDs are all 1.
+func newTestMemoryStorage(t *testing.T) (*MemoryDB, *Storage) {
+	t.Helper()
+
+	db := NewMemoryDB()
+	storage := NewMemoryStorage(db)
+	if err := db.InsertGrade(&Grade{Name: "دهم"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.InsertMajor(&Major{Name: "علوم تجربی"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.InsertBook(&Book{Title: "ریاضی (۱)", InherentGradeLevelID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	student := &Student{FirstName: "Sara", LastName: "Mohammadi", Email: "sara@example.com", GradeID: 1, MajorID: 1}
+	if err := storage.Students.Insert(context.Background(), student); err != nil {
+		t.Fatal(err)
+	}
+	return db, storage
+}
+
+func TestMemorySortColumnsMatchSQL(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql, memory []string
+	}{
+		{"books", slices.Sorted(maps.Keys(bookSortColumns)), slices.Sorted(maps.Keys(memoryBookSortColumns))},
+		{"daily plans", slices.Sorted(maps.Keys(dailyPlanSortColumns)), slices.Sorted(maps.Keys(memoryDailyPlanSortColumns))},
+		{"exam results", slices.Sorted(maps.Keys(examResultSortColumns)), slices.Sorted(maps.Keys(memoryExamResultSortColumns))},
+		{"exam schedules", slices.Sorted(maps.Keys(examScheduleSortColumns)), slices.Sorted(maps.Keys(memoryExamScheduleSortColumns))},
+		{"exam scope items", slices.Sorted(maps.Keys(examScopeItemSortColumns)), slices.Sorted(maps.Keys(memoryExamScopeItemSortColumns))},
+		{"grades", slices.Sorted(maps.Keys(gradeSortColumns)), slices.Sorted(maps.Keys(memoryGradeSortColumns))},
+		{"lessons", slices.Sorted(maps.Keys(lessonSortColumns)), slices.Sorted(maps.Keys(memoryLessonSortColumns))},
+		{"majors", slices.Sorted(maps.Keys(majorSortColumns)), slices.Sorted(maps.Keys(memoryMajorSortColumns))},
+		{"schedule templates", slices.Sorted(maps.Keys(scheduleTemplateSortColumns)), slices.Sorted(maps.Keys(memoryScheduleTemplateSortColumns))},
+		{"scope lessons", slices.Sorted(maps.Keys(scopeLessonSortColumns)), slices.Sorted(maps.Keys(memoryScopeLessonSortColumns))},
+		{"study sessions", slices.Sorted(maps.Keys(studySessionSortColumns)), slices.Sorted(maps.Keys(memoryStudySessionSortColumns))},
+		{"subject frequencies", slices.Sorted(maps.Keys(subjectFrequencySortColumns)), slices.Sorted(maps.Keys(memorySubjectFrequencySortColumns))},
+		{"template rules", slices.Sorted(maps.Keys(templateRuleSortColumns)), slices.Sorted(maps.Keys(memoryTemplateRuleSortColumns))},
+		{"template subject weights", slices.Sorted(maps.Keys(templateSubjectWeightSortColumns)), slices.Sorted(maps.Keys(memoryTemplateSubjectWeightSortColumns))},
+		{"unavailable times", slices.Sorted(maps.Keys(unavailableTimeSortColumns)), slices.Sorted(maps.Keys(memoryUnavailableTimeSortColumns))},
+		{"weekly plans", slices.Sorted(maps.Keys(weeklyPlanSortColumns)), slices.Sorted(maps.Keys(memoryWeeklyPlanSortColumns))},
+	}
+
+	for _, tt := range tests {
+		if !slices.Equal(tt.sql, tt.memory) {
+			t.Errorf("%s: memory sorts by %v, SQL by %v", tt.name, tt.memory, tt.sql)
+		}
+	}
+}
+
+func TestMemoryNotFound(t *testing.T) {
+	_, storage := newTestMemoryStorage(t)
+	ctx := context.Background()
+
+	if _, err := storage.Students.Get(ctx, 42); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("Students.Get: got %v, want ErrorNotFound", err)
+	}
+	if err := storage.WeeklyPlans.Delete(ctx, 42); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("WeeklyPlans.Delete: got %v, want ErrorNotFound", err)
+	}
+	if _, err := storage.SessionReports.GetForStudySession(ctx, 42); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("SessionReports.GetForStudySession: got %v, want ErrorNotFound", err)
+	}
+	if err := storage.Students.Update(ctx, &Student{ID: 42, Email: "x@example.com", GradeID: 1, MajorID: 1}); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("Students.Update: got %v, want ErrorNotFound", err)
+	}
+}
+
+func TestMemoryConstraints(t *testing.T) {
+	_, storage := newTestMemoryStorage(t)
+	ctx := context.Background()
+
+	duplicate := &Student{FirstName: "Sara", LastName: "Karimi", Email: "sara@example.com", GradeID: 1, MajorID: 1}
+	if err := storage.Students.Insert(ctx, duplicate); !errors.Is(err, ErrorDuplicateEmail) {
+		t.Errorf("duplicate email: got %v, want ErrorDuplicateEmail", err)
+	}
+
+	orphan := &Student{FirstName: "Reza", LastName: "Karimi", Email: "reza@example.com", GradeID: 9, MajorID: 1}
+	err := storage.Students.Insert(ctx, orphan)
+	var dbErr *DBError
+	if !errors.As(err, &dbErr) || !errors.Is(err, ErrorForeignKey) || dbErr.Field() != "grade_id" {
+		t.Errorf("missing grade: got %v, want a foreign key error on grade_id", err)
+	}
+
+	week := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	if err := storage.WeeklyPlans.Insert(ctx, &WeeklyPlan{StudentID: 1, StartDateOfWeek: week}); err != nil {
+		t.Fatal(err)
+	}
+	err = storage.WeeklyPlans.Insert(ctx, &WeeklyPlan{StudentID: 1, StartDateOfWeek: week.Add(3 * time.Hour)})
+	if !errors.Is(err, ErrorConflict) {
+		t.Errorf("second plan for the week: got %v, want ErrorConflict", err)
+	}
+
+	if err := storage.StudySessions.Insert(ctx, &StudySession{DailyPlanID: 1, BookID: 1, StartTime: "08:00", EndTime: "09:00"}); !errors.Is(err, ErrorForeignKey) {
+		t.Errorf("session without a daily plan: got %v, want ErrorForeignKey", err)
+	}
+}
+
+func TestMemoryStudentDeleteCascades(t *testing.T) {
+	_, storage := newTestMemoryStorage(t)
+	ctx := context.Background()
+
+	wp := &WeeklyPlan{StudentID: 1, StartDateOfWeek: time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)}
+	if err := storage.WeeklyPlans.Insert(ctx, wp); err != nil {
+		t.Fatal(err)
+	}
+	dp := &DailyPlan{WeeklyPlanID: wp.ID, PlanDate: wp.StartDateOfWeek}
+	if err := storage.DailyPlans.Insert(ctx, dp); err != nil {
+		t.Fatal(err)
+	}
+	ss := &StudySession{DailyPlanID: dp.ID, BookID: 1, StartTime: "08:00", EndTime: "09:30"}
+	if err := storage.StudySessions.Insert(ctx, ss); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SessionReports.Insert(ctx, &SessionReport{StudySessionID: ss.ID, NumTests: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := storage.Students.Delete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := storage.WeeklyPlans.Get(ctx, wp.ID); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("weekly plan: got %v, want ErrorNotFound", err)
+	}
+	if _, err := storage.DailyPlans.Get(ctx, dp.ID); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("daily plan: got %v, want ErrorNotFound", err)
+	}
+	if _, err := storage.StudySessions.Get(ctx, ss.ID); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("study session: got %v, want ErrorNotFound", err)
+	}
+	if _, err := storage.SessionReports.GetForStudySession(ctx, ss.ID); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("session report: got %v, want ErrorNotFound", err)
+	}
+}
+
+func TestMemoryListPaging(t *testing.T) {
+	_, storage := newTestMemoryStorage(t)
+	ctx := context.Background()
+
+	start := time.Date(2026, time.September, 5, 0, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		wp := &WeeklyPlan{StudentID: 1, StartDateOfWeek: start.AddDate(0, 0, 7*i)}
+		if err := storage.WeeklyPlans.Insert(ctx, wp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plans, metadata, err := storage.WeeklyPlans.GetAllForStudent(ctx, 1, QueryOptions{Page: 2, PageSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, wp := range plans {
+		ids = append(ids, wp.ID)
+	}
+	// newest first by default
+	if want := []int64{3, 2}; !slices.Equal(ids, want) {
+		t.Errorf("page 2: got plans %v, want %v", ids, want)
+	}
+	if metadata.TotalRecords != 5 || metadata.LastPage != 3 {
+		t.Errorf("metadata %+v, want 5 records on 3 pages", metadata)
+	}
+
+	plans, metadata, err = storage.WeeklyPlans.GetAllForStudent(ctx, 1, QueryOptions{Page: 4, PageSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 0 || metadata.TotalRecords != 5 || metadata.LastPage != 3 {
+		t.Errorf("past the last page: got %d plans and %+v, want none of 5 records on 3 pages", len(plans), metadata)
+	}
+
+	_, _, err = storage.WeeklyPlans.GetAllForStudent(ctx, 1, QueryOptions{Sort: "colour"})
+	if !errors.Is(err, ErrorInvalidSort) {
+		t.Errorf("unknown sort: got %v, want ErrorInvalidSort", err)
+	}
+}
@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrorInvalidSort = errors.New("invalid sort field")
+
+// QueryOptions pages, sorts and filters the results of list methods. The
+// zero value returns every row in the method's default order. Methods ignore
+// filters that do not apply to what they list.
+type QueryOptions struct {
+	Page     int
+	PageSize int
+	// Sort is a field name, prefixed with - for descending order.
+	Sort string
+	// From and To bound the listed rows by their date, inclusive.
+	From time.Time
+	To   time.Time
+	// Completed filters study sessions by completion status.
+	Completed *bool
+}
+
+// Metadata describes the page of results a list method returned.
+type Metadata struct {
+	CurrentPage  int `json:"current_page,omitempty"`
+	PageSize     int `json:"page_size,omitempty"`
+	FirstPage    int `json:"first_page,omitempty"`
+	LastPage     int `json:"last_page,omitempty"`
+	TotalRecords int `json:"total_records"`
+}
+
+// orderBy returns the ORDER BY clause for the requested sort, using
+// defaultSort when none was requested. sortColumns maps the sortable field
+// names to one or more comma-separated columns. Rows are always ordered by
+// idColumn last so that pages are stable.
+func (o QueryOptions) orderBy(sortColumns map[string]string, defaultSort string, idColumn string) (string, error) {
+	sortField, descending := o.sortField(defaultSort)
+
+	column, ok := sortColumns[sortField]
+	if !ok {
+		return "", invalidSortError(sortColumns)
+	}
+
+	direction := "ASC"
+	if descending {
+		direction = "DESC"
+	}
+
+	columns := strings.Split(column, ", ")
+	for i := range columns {
+		columns[i] += " " + direction
+	}
+	if column != idColumn {
+		columns = append(columns, idColumn+" ASC")
+	}
+	return "ORDER BY " + strings.Join(columns, ", "), nil
+}
+
+// sortField returns the requested sort field, or defaultSort when none was
+// requested, without its - prefix.
+func (o QueryOptions) sortField(defaultSort string) (field string, descending bool) {
+	field = o.Sort
+	if field == "" {
+		field = defaultSort
+	}
+	if strings.HasPrefix(field, "-") {
+		return strings.TrimPrefix(field, "-"), true
+	}
+	return field, false
+}
+
+// invalidSortError lists the fields that can be sorted on.
+func invalidSortError[V any](sortColumns map[string]V) error {
+	fields := make([]string, 0, len(sortColumns))
+	for field := range sortColumns {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fmt.Errorf("%w: must be one of %s, optionally prefixed with -", ErrorInvalidSort, strings.Join(fields, ", "))
+}
+
+// limit returns the LIMIT argument, nil meaning no limit.
+func (o QueryOptions) limit() any {
+	if o.PageSize <= 0 {
+		return nil
+	}
+	return o.PageSize
+}
+
+func (o QueryOptions) offset() int {
+	if o.PageSize <= 0 || o.Page <= 1 {
+		return 0
+	}
+	return (o.Page - 1) * o.PageSize
+}
+
+// from and to return the date bounds as query arguments, nil meaning
+// unbounded.
+func (o QueryOptions) from() any {
+	if o.From.IsZero() {
+		return nil
+	}
+	return o.From
+}
+
+func (o QueryOptions) to() any {
+	if o.To.IsZero() {
+		return nil
+	}
+	return o.To
+}
+
+func (o QueryOptions) completed() any {
+	if o.Completed == nil {
+		return nil
+	}
+	return *o.Completed
+}
+
+// pageMetadata returns the metadata of a page of query, a list query whose
+// first column is count(*) OVER() and whose last two arguments are its LIMIT
+// and OFFSET. A page past the last one has no row to report the total on, so
+// the rows of query are then counted without paging.
+func pageMetadata(ctx context.Context, db *sql.DB, query string, args []any, totalRecords int, o QueryOptions) (Metadata, error) {
+	if totalRecords == 0 && o.offset() > 0 {
+		unpaged := append(args[:len(args)-2:len(args)-2], nil, 0)
+		err := db.QueryRowContext(ctx, "SELECT count(*) FROM ("+query+") AS listed", unpaged...).Scan(&totalRecords)
+		if err != nil {
+			return Metadata{}, wrapError(err)
+		}
+	}
+	return calculateMetadata(totalRecords, o), nil
+}
+
+func calculateMetadata(totalRecords int, o QueryOptions) Metadata {
+	if o.PageSize <= 0 {
+		return Metadata{TotalRecords: totalRecords}
+	}
+	if totalRecords == 0 {
+		return Metadata{CurrentPage: max(o.Page, 1), PageSize: o.PageSize}
+	}
+
+	return Metadata{
+		CurrentPage:  max(o.Page, 1),
+		PageSize:     o.PageSize,
+		FirstPage:    1,
+		LastPage:     int(math.Ceil(float64(totalRecords) / float64(o.PageSize))),
+		TotalRecords: totalRecords,
+	}
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOrderBy(t *testing.T) {
+	sortColumns := map[string]string{
+		"id":    "wp.id",
+		"start": "wp.start_date_of_week",
+		"name":  "s.last_name, s.first_name",
+	}
+
+	tests := []struct {
+		sort string
+		want string
+	}{
+		{"", "ORDER BY wp.start_date_of_week DESC, wp.id ASC"},
+		{"start", "ORDER BY wp.start_date_of_week ASC, wp.id ASC"},
+		{"-id", "ORDER BY wp.id DESC"},
+		{"name", "ORDER BY s.last_name ASC, s.first_name ASC, wp.id ASC"},
+		{"-name", "ORDER BY s.last_name DESC, s.first_name DESC, wp.id ASC"},
+	}
+
+	for _, tt := range tests {
+		got, err := QueryOptions{Sort: tt.sort}.orderBy(sortColumns, "-start", "wp.id")
+		if err != nil {
+			t.Errorf("sort %q: %v", tt.sort, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("sort %q: got %q, want %q", tt.sort, got, tt.want)
+		}
+	}
+
+	// Only whitelisted fields are accepted, so the sort never reaches the
+	// query as it was requested
+	for _, sort := range []string{"start_date_of_week", "wp.id", "id; DROP TABLE students", "--id", "+id"} {
+		_, err := QueryOptions{Sort: sort}.orderBy(sortColumns, "-start", "wp.id")
+		if !errors.Is(err, ErrorInvalidSort) {
+			t.Errorf("sort %q: got %v, want ErrorInvalidSort", sort, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), "id, name, start") {
+			t.Errorf("sort %q: error %q does not list the sortable fields", sort, err)
+		}
+	}
+}
+
+func TestPaging(t *testing.T) {
+	tests := []struct {
+		opts   QueryOptions
+		limit  any
+		offset int
+	}{
+		{QueryOptions{}, nil, 0},
+		{QueryOptions{Page: 3}, nil, 0},
+		{QueryOptions{Page: 1, PageSize: 20}, 20, 0},
+		{QueryOptions{Page: 0, PageSize: 20}, 20, 0},
+		{QueryOptions{Page: 3, PageSize: 20}, 20, 40},
+	}
+
+	for _, tt := range tests {
+		if got := tt.opts.limit(); got != tt.limit {
+			t.Errorf("%+v: limit %v, want %v", tt.opts, got, tt.limit)
+		}
+		if got := tt.opts.offset(); got != tt.offset {
+			t.Errorf("%+v: offset %d, want %d", tt.opts, got, tt.offset)
+		}
+	}
+}
+
+func TestCalculateMetadata(t *testing.T) {
+	tests := []struct {
+		name         string
+		totalRecords int
+		opts         QueryOptions
+		want         Metadata
+	}{
+		{"unpaged", 7, QueryOptions{}, Metadata{TotalRecords: 7}},
+		{"first page", 7, QueryOptions{Page: 1, PageSize: 3}, Metadata{CurrentPage: 1, PageSize: 3, FirstPage: 1, LastPage: 3, TotalRecords: 7}},
+		{"page defaults to 1", 6, QueryOptions{PageSize: 3}, Metadata{CurrentPage: 1, PageSize: 3, FirstPage: 1, LastPage: 2, TotalRecords: 6}},
+		{"past the last page", 7, QueryOptions{Page: 5, PageSize: 3}, Metadata{CurrentPage: 5, PageSize: 3, FirstPage: 1, LastPage: 3, TotalRecords: 7}},
+		{"no records", 0, QueryOptions{Page: 2, PageSize: 3}, Metadata{CurrentPage: 2, PageSize: 3}},
+	}
+
+	for _, tt := range tests {
+		if got := calculateMetadata(tt.totalRecords, tt.opts); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -45,32 +46,47 @@ func (m *ScheduleTemplateModel) Get(ctx context.Context, id int64) (*ScheduleTem
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrorNotFound
 		}
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	return &tpl, nil
 }
 
-func (m *ScheduleTemplateModel) GetAll(ctx context.Context, gradeID, majorID int64) ([]*ScheduleTemplate, error) {
-	query := `
-        SELECT id, name, target_grade_id, target_major_id, total_study_blocks_per_week
+var scheduleTemplateSortColumns = map[string]string{
+	"name":                        "name",
+	"total_study_blocks_per_week": "total_study_blocks_per_week",
+	"id":                          "id",
+}
+
+func (m *ScheduleTemplateModel) GetAll(ctx context.Context, gradeID, majorID int64, opts QueryOptions) ([]*ScheduleTemplate, Metadata, error) {
+	orderBy, err := opts.orderBy(scheduleTemplateSortColumns, "name", "id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, name, target_grade_id, target_major_id, total_study_blocks_per_week
         FROM schedule_templates
         WHERE target_grade_id = $1 AND target_major_id = $2
-        ORDER BY name`
+        %s
+        LIMIT $3 OFFSET $4`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, gradeID, majorID)
+	args := []any{gradeID, majorID, opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var templates []*ScheduleTemplate
 	for rows.Next() {
 		var tpl ScheduleTemplate
 		err := rows.Scan(
+			&totalRecords,
 			&tpl.ID,
 			&tpl.Name,
 			&tpl.TargetGradeID,
@@ -78,14 +94,19 @@ func (m *ScheduleTemplateModel) GetAll(ctx context.Context, gradeID, majorID int
 			&tpl.TotalStudyBlocksPerWeek,
 		)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, wrapError(err)
 		}
 		templates = append(templates, &tpl)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
 	}
 
-	return templates, nil
+	return templates, metadata, nil
 }
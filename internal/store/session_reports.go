@@ -33,7 +33,7 @@ func (m *SessionReportModel) Insert(ctx context.Context, sr *SessionReport) erro
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&sr.ID)
+	return wrapError(m.DB.QueryRowContext(ctx, query, args...).Scan(&sr.ID))
 }
 
 func (m *SessionReportModel) GetForStudySession(ctx context.Context, studySessionID int64) (*SessionReport, error) {
@@ -66,7 +66,7 @@ func (m *SessionReportModel) GetForStudySession(ctx context.Context, studySessio
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrorNotFound
 		}
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	return &sr, nil
@@ -85,12 +85,12 @@ func (m *SessionReportModel) Update(ctx context.Context, sr *SessionReport) erro
 
 	result, err := m.DB.ExecContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -112,12 +112,12 @@ func (m *SessionReportModel) Delete(ctx context.Context, id int64) error {
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -3,15 +3,9 @@ package store
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"time"
 )
 
-var (
-	ErrorNotFound       = errors.New("resource not found")
-	ErrorDuplicateEmail = errors.New("duplicate email")
-)
-
 type Storage struct {
 	Students               StudentStore
 	Grades                 GradeStore
@@ -26,9 +20,13 @@ type Storage struct {
 	SessionReports         SessionReportStore
 	ExamSchedules          ExamScheduleStore
 	ExamScopeItems         ExamScopeItemStore
+	ExamEnrollments        ExamEnrollmentStore
+	ExamSeries             ExamSeriesStore
+	ExamResults            ExamResultStore
 	ScheduleTemplates      ScheduleTemplateStore
 	TemplateRules          TemplateRuleStore
 	TemplateSubjectWeights TemplateSubjectWeightStore
+	Analytics              AnalyticsStore
 }
 
 func NewStorage(db *sql.DB) *Storage {
@@ -46,9 +44,13 @@ func NewStorage(db *sql.DB) *Storage {
 		SessionReports:         &SessionReportModel{DB: db},
 		ExamSchedules:          &ExamScheduleModel{DB: db},
 		ExamScopeItems:         &ExamScopeItemModel{DB: db},
+		ExamEnrollments:        &ExamEnrollmentModel{DB: db},
+		ExamSeries:             &ExamSeriesModel{DB: db},
+		ExamResults:            &ExamResultModel{DB: db},
 		ScheduleTemplates:      &ScheduleTemplateModel{DB: db},
 		TemplateRules:          &TemplateRuleModel{DB: db},
 		TemplateSubjectWeights: &TemplateSubjectWeightModel{DB: db},
+		Analytics:              &AnalyticsModel{DB: db},
 	}
 }
 
@@ -57,32 +59,37 @@ type StudentStore interface {
 	Get(ctx context.Context, id int64) (*Student, error)
 	Update(ctx context.Context, student *Student) error
 	Delete(ctx context.Context, id int64) error
+	SetCalendarTokenHash(ctx context.Context, id int64, hash []byte) error
+	GetByCalendarTokenHash(ctx context.Context, hash []byte) (*Student, error)
 }
 
 type GradeStore interface {
 	Get(ctx context.Context, id int64) (*Grade, error)
-	GetAll(ctx context.Context) ([]*Grade, error)
+	GetAll(ctx context.Context, opts QueryOptions) ([]*Grade, Metadata, error)
 }
 
 type MajorStore interface {
 	Get(ctx context.Context, id int64) (*Major, error)
-	GetAll(ctx context.Context) ([]*Major, error)
+	GetAll(ctx context.Context, opts QueryOptions) ([]*Major, Metadata, error)
 }
 
 type BookStore interface {
 	Get(ctx context.Context, id int64) (*Book, error)
-	GetAllForCurriculum(ctx context.Context, gradeID, majorID int64) ([]*Book, error)
+	GetByTitle(ctx context.Context, title string) (*Book, error)
+	GetAllForCurriculum(ctx context.Context, gradeID, majorID int64, opts QueryOptions) ([]*Book, Metadata, error)
 }
 
 type LessonStore interface {
 	Get(ctx context.Context, id int64) (*Lesson, error)
-	GetAllForBook(ctx context.Context, bookID int64) ([]*Lesson, error)
+	GetAllForBook(ctx context.Context, bookID int64, opts QueryOptions) ([]*Lesson, Metadata, error)
+	GetAllForExamScope(ctx context.Context, examID int64, opts QueryOptions) ([]*Lesson, Metadata, error)
 }
 
 type UnavailableTimeStore interface {
 	Insert(ctx context.Context, ut *UnavailableTime) error
+	InsertBatch(ctx context.Context, times []*UnavailableTime) error
 	Get(ctx context.Context, id int64) (*UnavailableTime, error)
-	GetAllForStudent(ctx context.Context, studentID int64) ([]*UnavailableTime, error)
+	GetAllForStudent(ctx context.Context, studentID int64, opts QueryOptions) ([]*UnavailableTime, Metadata, error)
 	Update(ctx context.Context, ut *UnavailableTime) error
 	Delete(ctx context.Context, id int64) error
 }
@@ -90,15 +97,18 @@ type UnavailableTimeStore interface {
 type WeeklyPlanStore interface {
 	Insert(ctx context.Context, wp *WeeklyPlan) error
 	Get(ctx context.Context, id int64) (*WeeklyPlan, error)
-	GetAllForStudent(ctx context.Context, studentID int64) ([]*WeeklyPlan, error)
+	GetByStudentAndStartDate(ctx context.Context, studentID int64, startDateOfWeek time.Time) (*WeeklyPlan, error)
+	GetAllForStudent(ctx context.Context, studentID int64, opts QueryOptions) ([]*WeeklyPlan, Metadata, error)
 	Update(ctx context.Context, wp *WeeklyPlan) error
+	SetGenerationSeed(ctx context.Context, id int64, seed int64) error
 	Delete(ctx context.Context, id int64) error
 }
 
 type SubjectFrequencyStore interface {
 	Insert(ctx context.Context, sf *SubjectFrequency) error
-	GetAllForWeeklyPlan(ctx context.Context, weeklyPlanID int64) ([]*SubjectFrequency, error)
+	GetAllForWeeklyPlan(ctx context.Context, weeklyPlanID int64, opts QueryOptions) ([]*SubjectFrequency, Metadata, error)
 	Update(ctx context.Context, sf *SubjectFrequency) error
+	ReplaceForWeeklyPlan(ctx context.Context, weeklyPlanID int64, frequencies []*SubjectFrequency) error
 	Delete(ctx context.Context, id int64) error
 }
 
@@ -106,14 +116,15 @@ type DailyPlanStore interface {
 	Insert(ctx context.Context, dp *DailyPlan) error
 	Get(ctx context.Context, id int64) (*DailyPlan, error)
 	GetByWeeklyPlanAndDate(ctx context.Context, weeklyPlanID int64, planDate time.Time) (*DailyPlan, error)
-	GetAllForWeeklyPlan(ctx context.Context, weeklyPlanID int64) ([]*DailyPlan, error)
+	GetAllForWeeklyPlan(ctx context.Context, weeklyPlanID int64, opts QueryOptions) ([]*DailyPlan, Metadata, error)
 	Delete(ctx context.Context, id int64) error
 }
 
 type StudySessionStore interface {
 	Insert(ctx context.Context, ss *StudySession) error
+	ReplaceUnfinishedForWeeklyPlan(ctx context.Context, weeklyPlanID int64, sessions []*StudySession) error
 	Get(ctx context.Context, id int64) (*StudySession, error)
-	GetAllForDailyPlan(ctx context.Context, dailyPlanID int64) ([]*StudySession, error)
+	GetAllForDailyPlan(ctx context.Context, dailyPlanID int64, opts QueryOptions) ([]*StudySession, Metadata, error)
 	Update(ctx context.Context, ss *StudySession) error
 	Delete(ctx context.Context, id int64) error
 }
@@ -128,31 +139,61 @@ type SessionReportStore interface {
 type ExamScheduleStore interface {
 	Insert(ctx context.Context, es *ExamSchedule) error
 	Get(ctx context.Context, id int64) (*ExamSchedule, error)
-	GetAllForStudentCurriculum(ctx context.Context, gradeID, majorID int64) ([]*ExamSchedule, error)
+	GetAllForStudentCurriculum(ctx context.Context, gradeID, majorID int64, opts QueryOptions) ([]*ExamSchedule, Metadata, error)
+	GetAllForStudent(ctx context.Context, studentID int64, opts QueryOptions) ([]*ExamSchedule, Metadata, error)
+	GetAllForSeries(ctx context.Context, seriesID int64, opts QueryOptions) ([]*ExamSchedule, Metadata, error)
+	GetPrevious(ctx context.Context, es *ExamSchedule) (*ExamSchedule, error)
 	Update(ctx context.Context, es *ExamSchedule) error
 	Delete(ctx context.Context, id int64) error
 }
 
 type ExamScopeItemStore interface {
 	Insert(ctx context.Context, esi *ExamScopeItem) error
-	GetAllForExam(ctx context.Context, examID int64) ([]*ExamScopeItem, error)
+	GetAllForExam(ctx context.Context, examID int64, opts QueryOptions) ([]*ExamScopeItem, Metadata, error)
 	Delete(ctx context.Context, id int64) error
 }
 
+type ExamEnrollmentStore interface {
+	Insert(ctx context.Context, ee *ExamEnrollment) error
+	Get(ctx context.Context, studentID, examID int64) (*ExamEnrollment, error)
+	Delete(ctx context.Context, studentID, examID int64) error
+}
+
+type ExamSeriesStore interface {
+	Get(ctx context.Context, id int64) (*ExamSeries, error)
+	InsertSeason(ctx context.Context, series *ExamSeries, exams []*SeasonExam) error
+}
+
+type ExamResultStore interface {
+	Upsert(ctx context.Context, results []*ExamResult) error
+	GetAllForExam(ctx context.Context, studentID, examID int64, opts QueryOptions) ([]*ExamResult, Metadata, error)
+	GetHistory(ctx context.Context, studentID int64, filter ExamResultFilter) ([]*ExamResultEntry, error)
+	DeleteForExam(ctx context.Context, studentID, examID int64) error
+}
+
 type ScheduleTemplateStore interface {
 	Get(ctx context.Context, id int64) (*ScheduleTemplate, error)
-	GetAll(ctx context.Context, gradeID, majorID int64) ([]*ScheduleTemplate, error)
+	GetAll(ctx context.Context, gradeID, majorID int64, opts QueryOptions) ([]*ScheduleTemplate, Metadata, error)
 }
 
 type TemplateRuleStore interface {
 	Insert(ctx context.Context, tr *TemplateRule) error
 	Get(ctx context.Context, id int64) (*TemplateRule, error)
-	GetAllForTemplate(ctx context.Context, templateID int64) ([]*TemplateRule, error)
+	GetAllForTemplate(ctx context.Context, templateID int64, opts QueryOptions) ([]*TemplateRule, Metadata, error)
 	Update(ctx context.Context, tr *TemplateRule) error
 	Delete(ctx context.Context, id int64) error
 }
 
 type TemplateSubjectWeightStore interface {
-	GetWeightsForTemplate(ctx context.Context, templateID int64) ([]*TemplateSubjectWeight, error)
+	GetWeightsForTemplate(ctx context.Context, templateID int64, opts QueryOptions) ([]*TemplateSubjectWeight, Metadata, error)
 	SetWeight(ctx context.Context, weight *TemplateSubjectWeight) error
 }
+
+type AnalyticsStore interface {
+	GetBookProgress(ctx context.Context, studentID int64, filter AnalyticsFilter) ([]*BookProgress, error)
+	GetWeeklyProgress(ctx context.Context, studentID int64, filter AnalyticsFilter) ([]*WeekProgress, error)
+	GetStudyDates(ctx context.Context, studentID int64, filter AnalyticsFilter) ([]time.Time, error)
+	GetWeakTopics(ctx context.Context, studentID int64, params WeakTopicParams) ([]*WeakTopic, error)
+	GetScopeLessonProgress(ctx context.Context, studentID, examID int64, from, until time.Time) ([]*ScopeLessonProgress, error)
+	GetUnassignedUpcomingSessions(ctx context.Context, studentID int64, bookIDs []int64, from, until time.Time) (map[int64]int, error)
+}
@@ -15,6 +15,24 @@ type Student struct {
 	PhoneNumber string `json:"phone_number,omitempty"`
 	GradeID     int64  `json:"grade_id"`
 	MajorID     int64  `json:"major_id"`
+	Timezone    string `json:"timezone"`
+}
+
+// DefaultTimezone is the IANA zone of students who have not chosen one.
+const DefaultTimezone = "Asia/Tehran"
+
+// Location returns the student's time zone. Study times, unavailable times and
+// calendar output are all wall-clock times in this location.
+func (s *Student) Location() *time.Location {
+	name := s.Timezone
+	if name == "" {
+		name = DefaultTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
 }
 
 type StudentModel struct {
@@ -23,24 +41,21 @@ type StudentModel struct {
 
 func (m *StudentModel) Insert(ctx context.Context, student *Student) error {
 	query := `
-        INSERT INTO students (first_name, last_name, email, phone_number, grade_id, major_id)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO students (first_name, last_name, email, phone_number, grade_id, major_id, timezone)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
         RETURNING id`
 
-	args := []any{student.FirstName, student.LastName, student.Email, student.PhoneNumber, student.GradeID, student.MajorID}
+	if student.Timezone == "" {
+		student.Timezone = DefaultTimezone
+	}
+
+	args := []any{student.FirstName, student.LastName, student.Email, student.PhoneNumber, student.GradeID, student.MajorID, student.Timezone}
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&student.ID)
-	if err != nil {
-
-		if err.Error() == `pq: duplicate key value violates unique constraint "students_email_key"` {
-			return ErrorDuplicateEmail
-		}
-		return err
-	}
-	return nil
+	return studentError(err)
 }
 
 func (m *StudentModel) Get(ctx context.Context, id int64) (*Student, error) {
@@ -49,7 +64,7 @@ func (m *StudentModel) Get(ctx context.Context, id int64) (*Student, error) {
 	}
 
 	query := `
-        SELECT id, first_name, last_name, email, phone_number, grade_id, major_id
+        SELECT id, first_name, last_name, email, phone_number, grade_id, major_id, timezone
         FROM students
         WHERE id = $1`
 
@@ -65,13 +80,14 @@ func (m *StudentModel) Get(ctx context.Context, id int64) (*Student, error) {
 		&s.PhoneNumber,
 		&s.GradeID,
 		&s.MajorID,
+		&s.Timezone,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrorNotFound
 		}
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	return &s, nil
@@ -80,8 +96,8 @@ func (m *StudentModel) Get(ctx context.Context, id int64) (*Student, error) {
 func (m *StudentModel) Update(ctx context.Context, student *Student) error {
 	query := `
         UPDATE students
-        SET first_name = $1, last_name = $2, email = $3, phone_number = $4, grade_id = $5, major_id = $6
-        WHERE id = $7`
+        SET first_name = $1, last_name = $2, email = $3, phone_number = $4, grade_id = $5, major_id = $6, timezone = $7
+        WHERE id = $8`
 
 	args := []any{
 		student.FirstName,
@@ -90,6 +106,7 @@ func (m *StudentModel) Update(ctx context.Context, student *Student) error {
 		student.PhoneNumber,
 		student.GradeID,
 		student.MajorID,
+		student.Timezone,
 		student.ID,
 	}
 
@@ -98,15 +115,12 @@ func (m *StudentModel) Update(ctx context.Context, student *Student) error {
 
 	result, err := m.DB.ExecContext(ctx, query, args...)
 	if err != nil {
-		if err.Error() == `pq: duplicate key value violates unique constraint "students_email_key"` {
-			return ErrorDuplicateEmail
-		}
-		return err
+		return studentError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -116,6 +130,62 @@ func (m *StudentModel) Update(ctx context.Context, student *Student) error {
 	return nil
 }
 
+// SetCalendarTokenHash stores the hash of the student's calendar feed token,
+// replacing any previous one. A nil hash disables the feed.
+func (m *StudentModel) SetCalendarTokenHash(ctx context.Context, id int64, hash []byte) error {
+	query := `UPDATE students SET calendar_token_hash = $1 WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, hash, id)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorNotFound
+	}
+
+	return nil
+}
+
+func (m *StudentModel) GetByCalendarTokenHash(ctx context.Context, hash []byte) (*Student, error) {
+	query := `
+        SELECT id, first_name, last_name, email, phone_number, grade_id, major_id, timezone
+        FROM students
+        WHERE calendar_token_hash = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var s Student
+	err := m.DB.QueryRowContext(ctx, query, hash).Scan(
+		&s.ID,
+		&s.FirstName,
+		&s.LastName,
+		&s.Email,
+		&s.PhoneNumber,
+		&s.GradeID,
+		&s.MajorID,
+		&s.Timezone,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrorNotFound
+		}
+		return nil, wrapError(err)
+	}
+
+	return &s, nil
+}
+
 func (m *StudentModel) Delete(ctx context.Context, id int64) error {
 	if id < 1 {
 		return ErrorNotFound
@@ -128,12 +198,12 @@ func (m *StudentModel) Delete(ctx context.Context, id int64) error {
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -142,3 +212,15 @@ func (m *StudentModel) Delete(ctx context.Context, id int64) error {
 
 	return nil
 }
+
+// studentError reports a clash with another student's email address as
+// ErrorDuplicateEmail.
+func studentError(err error) error {
+	err = wrapError(err)
+
+	var dbErr *DBError
+	if errors.As(err, &dbErr) && dbErr.Constraint == "students_email_key" {
+		return ErrorDuplicateEmail
+	}
+	return err
+}
@@ -4,17 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 )
 
 type StudySession struct {
-	ID             int64        `json:"id"`
-	DailyPlanID    int64        `json:"daily_plan_id"`
-	BookID         int64        `json:"book_id"`
-	IsCompleted    bool         `json:"is_completed"`
-	CompletionDate sql.NullTime `json:"completion_date,omitempty"`
-	StartTime      string       `json:"start_time"`
-	EndTime        string       `json:"end_time"`
+	ID             int64         `json:"id"`
+	DailyPlanID    int64         `json:"daily_plan_id"`
+	BookID         int64         `json:"book_id"`
+	LessonID       sql.NullInt64 `json:"lesson_id,omitempty"`
+	IsCompleted    bool          `json:"is_completed"`
+	CompletionDate sql.NullTime  `json:"completion_date,omitempty"`
+	StartTime      string        `json:"start_time"`
+	EndTime        string        `json:"end_time"`
 }
 
 type StudySessionModel struct {
@@ -23,15 +25,53 @@ type StudySessionModel struct {
 
 func (m *StudySessionModel) Insert(ctx context.Context, ss *StudySession) error {
 	query := `
-        INSERT INTO study_sessions (daily_plan_id, book_id, start_time, end_time, is_completed, completion_date)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO study_sessions (daily_plan_id, book_id, lesson_id, start_time, end_time, is_completed, completion_date)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
         RETURNING id, is_completed, completion_date`
 
-	args := []any{ss.DailyPlanID, ss.BookID, ss.StartTime, ss.EndTime, ss.IsCompleted, ss.CompletionDate}
+	args := []any{ss.DailyPlanID, ss.BookID, ss.LessonID, ss.StartTime, ss.EndTime, ss.IsCompleted, ss.CompletionDate}
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&ss.ID, &ss.IsCompleted, &ss.CompletionDate)
+	return wrapError(m.DB.QueryRowContext(ctx, query, args...).Scan(&ss.ID, &ss.IsCompleted, &ss.CompletionDate))
+}
+
+// ReplaceUnfinishedForWeeklyPlan deletes the sessions of a weekly plan that
+// are not completed and inserts the given ones in their place in a single
+// transaction. Completed sessions are kept. The IDs of the inserted rows are
+// filled in.
+func (m *StudySessionModel) ReplaceUnfinishedForWeeklyPlan(ctx context.Context, weeklyPlanID int64, sessions []*StudySession) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapError(err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+        DELETE FROM study_sessions ss
+        USING daily_plans dp
+        WHERE ss.daily_plan_id = dp.id AND dp.weekly_plan_id = $1 AND NOT ss.is_completed`, weeklyPlanID)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	query := `
+        INSERT INTO study_sessions (daily_plan_id, book_id, lesson_id, start_time, end_time, is_completed, completion_date)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, is_completed, completion_date`
+
+	for _, ss := range sessions {
+		args := []any{ss.DailyPlanID, ss.BookID, ss.LessonID, ss.StartTime, ss.EndTime, ss.IsCompleted, ss.CompletionDate}
+		err = tx.QueryRowContext(ctx, query, args...).Scan(&ss.ID, &ss.IsCompleted, &ss.CompletionDate)
+		if err != nil {
+			return wrapError(err)
+		}
+	}
+
+	return wrapError(tx.Commit())
 }
 
 func (m *StudySessionModel) Get(ctx context.Context, id int64) (*StudySession, error) {
@@ -39,7 +79,7 @@ func (m *StudySessionModel) Get(ctx context.Context, id int64) (*StudySession, e
 		return nil, ErrorNotFound
 	}
 	query := `
-        SELECT id, daily_plan_id, book_id, is_completed, completion_date, start_time, end_time
+        SELECT id, daily_plan_id, book_id, lesson_id, is_completed, completion_date, start_time, end_time
         FROM study_sessions
         WHERE id = $1`
 
@@ -52,6 +92,7 @@ func (m *StudySessionModel) Get(ctx context.Context, id int64) (*StudySession, e
 		&ss.ID,
 		&ss.DailyPlanID,
 		&ss.BookID,
+		&ss.LessonID,
 		&ss.IsCompleted,
 		&ss.CompletionDate,
 		&dbStartTime,
@@ -61,7 +102,7 @@ func (m *StudySessionModel) Get(ctx context.Context, id int64) (*StudySession, e
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrorNotFound
 		}
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	ss.StartTime = dbStartTime.Format("15:04:05")
@@ -70,37 +111,58 @@ func (m *StudySessionModel) Get(ctx context.Context, id int64) (*StudySession, e
 	return &ss, nil
 }
 
-func (m *StudySessionModel) GetAllForDailyPlan(ctx context.Context, dailyPlanID int64) ([]*StudySession, error) {
-	query := `
-        SELECT id, daily_plan_id, book_id, is_completed, completion_date, start_time, end_time
+var studySessionSortColumns = map[string]string{
+	"start_time":      "start_time",
+	"end_time":        "end_time",
+	"is_completed":    "is_completed",
+	"completion_date": "completion_date",
+	"id":              "id",
+}
+
+func (m *StudySessionModel) GetAllForDailyPlan(ctx context.Context, dailyPlanID int64, opts QueryOptions) ([]*StudySession, Metadata, error) {
+	orderBy, err := opts.orderBy(studySessionSortColumns, "start_time", "id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, daily_plan_id, book_id, lesson_id, is_completed, completion_date, start_time, end_time
         FROM study_sessions
         WHERE daily_plan_id = $1
-        ORDER BY start_time`
+          AND ($2::boolean IS NULL OR is_completed = $2)
+          AND ($3::date IS NULL OR completion_date >= $3)
+          AND ($4::date IS NULL OR completion_date < $4::date + 1)
+        %s
+        LIMIT $5 OFFSET $6`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, dailyPlanID)
+	args := []any{dailyPlanID, opts.completed(), opts.from(), opts.to(), opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var sessions []*StudySession
 	for rows.Next() {
 		var ss StudySession
 		var dbStartTime, dbEndTime time.Time
 		err := rows.Scan(
+			&totalRecords,
 			&ss.ID,
 			&ss.DailyPlanID,
 			&ss.BookID,
+			&ss.LessonID,
 			&ss.IsCompleted,
 			&ss.CompletionDate,
 			&dbStartTime,
 			&dbEndTime,
 		)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, wrapError(err)
 		}
 
 		ss.StartTime = dbStartTime.Format("15:04:05")
@@ -110,29 +172,34 @@ func (m *StudySessionModel) GetAllForDailyPlan(ctx context.Context, dailyPlanID
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
-	return sessions, nil
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return sessions, metadata, nil
 }
 
 func (m *StudySessionModel) Update(ctx context.Context, ss *StudySession) error {
 	query := `
         UPDATE study_sessions
-        SET daily_plan_id = $1, book_id = $2, is_completed = $3, completion_date = $4, start_time = $5, end_time = $6
-        WHERE id = $7`
+        SET daily_plan_id = $1, book_id = $2, lesson_id = $3, is_completed = $4, completion_date = $5, start_time = $6, end_time = $7
+        WHERE id = $8`
 
-	args := []any{ss.DailyPlanID, ss.BookID, ss.IsCompleted, ss.CompletionDate, ss.StartTime, ss.EndTime, ss.ID} // Pass ss.CompletionDate directly
+	args := []any{ss.DailyPlanID, ss.BookID, ss.LessonID, ss.IsCompleted, ss.CompletionDate, ss.StartTime, ss.EndTime, ss.ID} // Pass ss.CompletionDate directly
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	result, err := m.DB.ExecContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -152,12 +219,12 @@ func (m *StudySessionModel) Delete(ctx context.Context, id int64) error {
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 )
 
@@ -28,44 +29,65 @@ func (m *SubjectFrequencyModel) Insert(ctx context.Context, sf *SubjectFrequency
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&sf.ID)
+	return wrapError(m.DB.QueryRowContext(ctx, query, args...).Scan(&sf.ID))
 }
 
-func (m *SubjectFrequencyModel) GetAllForWeeklyPlan(ctx context.Context, weeklyPlanID int64) ([]*SubjectFrequency, error) {
-	query := `
-        SELECT id, weekly_plan_id, book_id, frequency_per_week
+var subjectFrequencySortColumns = map[string]string{
+	"id":                 "id",
+	"book_id":            "book_id",
+	"frequency_per_week": "frequency_per_week",
+}
+
+func (m *SubjectFrequencyModel) GetAllForWeeklyPlan(ctx context.Context, weeklyPlanID int64, opts QueryOptions) ([]*SubjectFrequency, Metadata, error) {
+	orderBy, err := opts.orderBy(subjectFrequencySortColumns, "id", "id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, weekly_plan_id, book_id, frequency_per_week
         FROM subject_frequencies
-        WHERE weekly_plan_id = $1`
+        WHERE weekly_plan_id = $1
+        %s
+        LIMIT $2 OFFSET $3`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, weeklyPlanID)
+	args := []any{weeklyPlanID, opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var frequencies []*SubjectFrequency
 	for rows.Next() {
 		var sf SubjectFrequency
 		err := rows.Scan(
+			&totalRecords,
 			&sf.ID,
 			&sf.WeeklyPlanID,
 			&sf.BookID,
 			&sf.FrequencyPerWeek,
 		)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, wrapError(err)
 		}
 		frequencies = append(frequencies, &sf)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
 	}
 
-	return frequencies, nil
+	return frequencies, metadata, nil
 }
 
 func (m *SubjectFrequencyModel) Update(ctx context.Context, sf *SubjectFrequency) error {
@@ -81,12 +103,12 @@ func (m *SubjectFrequencyModel) Update(ctx context.Context, sf *SubjectFrequency
 
 	result, err := m.DB.ExecContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -96,6 +118,40 @@ func (m *SubjectFrequencyModel) Update(ctx context.Context, sf *SubjectFrequency
 	return nil
 }
 
+// ReplaceForWeeklyPlan deletes the frequencies of a weekly plan and inserts
+// the given ones in their place in a single transaction, so the plan never
+// ends up with only some of them. The IDs of the inserted rows are filled in.
+func (m *SubjectFrequencyModel) ReplaceForWeeklyPlan(ctx context.Context, weeklyPlanID int64, frequencies []*SubjectFrequency) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapError(err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM subject_frequencies WHERE weekly_plan_id = $1`, weeklyPlanID)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	query := `
+        INSERT INTO subject_frequencies (weekly_plan_id, book_id, frequency_per_week)
+        VALUES ($1, $2, $3)
+        RETURNING id`
+
+	for _, sf := range frequencies {
+		sf.WeeklyPlanID = weeklyPlanID
+		err = tx.QueryRowContext(ctx, query, sf.WeeklyPlanID, sf.BookID, sf.FrequencyPerWeek).Scan(&sf.ID)
+		if err != nil {
+			return wrapError(err)
+		}
+	}
+
+	return wrapError(tx.Commit())
+}
+
 func (m *SubjectFrequencyModel) Delete(ctx context.Context, id int64) error {
 	if id < 1 {
 		return ErrorNotFound
@@ -108,12 +164,12 @@ func (m *SubjectFrequencyModel) Delete(ctx context.Context, id int64) error {
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
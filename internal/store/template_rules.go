@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -41,7 +42,7 @@ func (m *TemplateRuleModel) Insert(ctx context.Context, tr *TemplateRule) error
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&tr.ID)
+	return wrapError(m.DB.QueryRowContext(ctx, query, args...).Scan(&tr.ID))
 }
 
 func (m *TemplateRuleModel) Get(ctx context.Context, id int64) (*TemplateRule, error) {
@@ -74,36 +75,52 @@ func (m *TemplateRuleModel) Get(ctx context.Context, id int64) (*TemplateRule, e
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrorNotFound
 		}
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	return &tr, nil
 }
 
-func (m *TemplateRuleModel) GetAllForTemplate(ctx context.Context, templateID int64) ([]*TemplateRule, error) {
+var templateRuleSortColumns = map[string]string{
+	"id":                "id",
+	"book_id":           "book_id",
+	"default_frequency": "default_frequency",
+}
+
+func (m *TemplateRuleModel) GetAllForTemplate(ctx context.Context, templateID int64, opts QueryOptions) ([]*TemplateRule, Metadata, error) {
 	if templateID < 1 {
-		return nil, ErrorNotFound
+		return nil, Metadata{}, ErrorNotFound
 	}
 
-	query := `
-        SELECT id, template_id, book_id, default_frequency, scheduling_hints,
+	orderBy, err := opts.orderBy(templateRuleSortColumns, "id", "id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, template_id, book_id, default_frequency, scheduling_hints,
                consecutive_sessions, time_preference, priority_slot
         FROM template_rules
-        WHERE template_id = $1`
+        WHERE template_id = $1
+        %s
+        LIMIT $2 OFFSET $3`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, templateID)
+	args := []any{templateID, opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var rules []*TemplateRule
 	for rows.Next() {
 		var rule TemplateRule
 		err := rows.Scan(
+			&totalRecords,
 			&rule.ID,
 			&rule.TemplateID,
 			&rule.BookID,
@@ -114,16 +131,21 @@ func (m *TemplateRuleModel) GetAllForTemplate(ctx context.Context, templateID in
 			&rule.PrioritySlot,
 		)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, wrapError(err)
 		}
 		rules = append(rules, &rule)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
 	}
 
-	return rules, nil
+	return rules, metadata, nil
 }
 
 func (m *TemplateRuleModel) Update(ctx context.Context, tr *TemplateRule) error {
@@ -149,12 +171,12 @@ func (m *TemplateRuleModel) Update(ctx context.Context, tr *TemplateRule) error
 
 	result, err := m.DB.ExecContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -175,12 +197,12 @@ func (m *TemplateRuleModel) Delete(ctx context.Context, id int64) error {
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 )
 
@@ -17,41 +18,62 @@ type TemplateSubjectWeightModel struct {
 	DB *sql.DB
 }
 
-func (m *TemplateSubjectWeightModel) GetWeightsForTemplate(ctx context.Context, templateID int64) ([]*TemplateSubjectWeight, error) {
-	query := `
-        SELECT id, template_id, book_id, weight
+var templateSubjectWeightSortColumns = map[string]string{
+	"id":      "id",
+	"book_id": "book_id",
+	"weight":  "weight",
+}
+
+func (m *TemplateSubjectWeightModel) GetWeightsForTemplate(ctx context.Context, templateID int64, opts QueryOptions) ([]*TemplateSubjectWeight, Metadata, error) {
+	orderBy, err := opts.orderBy(templateSubjectWeightSortColumns, "id", "id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, template_id, book_id, weight
         FROM template_subject_weights
-        WHERE template_id = $1`
+        WHERE template_id = $1
+        %s
+        LIMIT $2 OFFSET $3`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, templateID)
+	args := []any{templateID, opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var weights []*TemplateSubjectWeight
 	for rows.Next() {
 		var weight TemplateSubjectWeight
 		err := rows.Scan(
+			&totalRecords,
 			&weight.ID,
 			&weight.TemplateID,
 			&weight.BookID,
 			&weight.Weight,
 		)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, wrapError(err)
 		}
 		weights = append(weights, &weight)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
 	}
 
-	return weights, nil
+	return weights, metadata, nil
 }
 
 func (m *TemplateSubjectWeightModel) SetWeight(ctx context.Context, weight *TemplateSubjectWeight) error {
@@ -67,5 +89,5 @@ func (m *TemplateSubjectWeightModel) SetWeight(ctx context.Context, weight *Temp
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&weight.ID)
+	return wrapError(m.DB.QueryRowContext(ctx, query, args...).Scan(&weight.ID))
 }
@@ -15,6 +15,8 @@ type UnavailableTime struct {
 	StartTime   time.Time
 	EndTime     time.Time
 	IsRecurring bool
+	// SpecificDate, when set, limits a non-recurring entry to that date
+	SpecificDate sql.NullTime
 }
 
 type UnavailableTimeModel struct {
@@ -23,17 +25,44 @@ type UnavailableTimeModel struct {
 
 func (m *UnavailableTimeModel) Insert(ctx context.Context, ut *UnavailableTime) error {
 	query := `
-        INSERT INTO unavailable_times (student_id, title, day_of_week, start_time, end_time, is_recurring)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO unavailable_times (student_id, title, day_of_week, start_time, end_time, is_recurring, specific_date)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
         RETURNING id
     `
-	args := []interface{}{ut.StudentID, ut.Title, ut.DayOfWeek, ut.StartTime, ut.EndTime, ut.IsRecurring}
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&ut.ID)
+	args := []interface{}{ut.StudentID, ut.Title, ut.DayOfWeek, ut.StartTime, ut.EndTime, ut.IsRecurring, ut.SpecificDate}
+	return wrapError(m.DB.QueryRowContext(ctx, query, args...).Scan(&ut.ID))
+}
+
+// InsertBatch inserts all entries in one transaction.
+func (m *UnavailableTimeModel) InsertBatch(ctx context.Context, times []*UnavailableTime) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapError(err)
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO unavailable_times (student_id, title, day_of_week, start_time, end_time, is_recurring, specific_date)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `
+	for _, ut := range times {
+		args := []interface{}{ut.StudentID, ut.Title, ut.DayOfWeek, ut.StartTime, ut.EndTime, ut.IsRecurring, ut.SpecificDate}
+		err = tx.QueryRowContext(ctx, query, args...).Scan(&ut.ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert unavailable time: %w", wrapError(err))
+		}
+	}
+
+	return wrapError(tx.Commit())
 }
 
 func (m *UnavailableTimeModel) Get(ctx context.Context, id int64) (*UnavailableTime, error) {
 	query := `
-        SELECT id, student_id, title, day_of_week, start_time, end_time, is_recurring
+        SELECT id, student_id, title, day_of_week, start_time, end_time, is_recurring, specific_date
         FROM unavailable_times
         WHERE id = $1
     `
@@ -46,12 +75,13 @@ func (m *UnavailableTimeModel) Get(ctx context.Context, id int64) (*UnavailableT
 		&ut.StartTime,
 		&ut.EndTime,
 		&ut.IsRecurring,
+		&ut.SpecificDate,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrorNotFound
 		}
-		return nil, fmt.Errorf("failed to get unavailable time: %w", err)
+		return nil, fmt.Errorf("failed to get unavailable time: %w", wrapError(err))
 	}
 	return &ut, nil
 }
@@ -59,12 +89,12 @@ func (m *UnavailableTimeModel) Get(ctx context.Context, id int64) (*UnavailableT
 func (m *UnavailableTimeModel) Update(ctx context.Context, ut *UnavailableTime) error {
 	query := `
         UPDATE unavailable_times
-        SET title = $1, day_of_week = $2, start_time = $3, end_time = $4, is_recurring = $5
-        WHERE id = $6
+        SET title = $1, day_of_week = $2, start_time = $3, end_time = $4, is_recurring = $5, specific_date = $6
+        WHERE id = $7
     `
-	args := []interface{}{ut.Title, ut.DayOfWeek, ut.StartTime, ut.EndTime, ut.IsRecurring, ut.ID}
+	args := []interface{}{ut.Title, ut.DayOfWeek, ut.StartTime, ut.EndTime, ut.IsRecurring, ut.SpecificDate, ut.ID}
 	_, err := m.DB.ExecContext(ctx, query, args...)
-	return err
+	return wrapError(err)
 }
 
 func (m *UnavailableTimeModel) Delete(ctx context.Context, id int64) error {
@@ -73,26 +103,44 @@ func (m *UnavailableTimeModel) Delete(ctx context.Context, id int64) error {
         WHERE id = $1
     `
 	_, err := m.DB.ExecContext(ctx, query, id)
-	return err
+	return wrapError(err)
 }
 
-func (m *UnavailableTimeModel) GetAllForStudent(ctx context.Context, studentID int64) ([]*UnavailableTime, error) {
-	query := `
-        SELECT id, student_id, title, day_of_week, start_time, end_time, is_recurring
+var unavailableTimeSortColumns = map[string]string{
+	"day_of_week":   "day_of_week, start_time",
+	"specific_date": "specific_date IS NOT NULL, specific_date, day_of_week, start_time",
+	"start_time":    "start_time",
+	"title":         "title",
+	"id":            "id",
+}
+
+func (m *UnavailableTimeModel) GetAllForStudent(ctx context.Context, studentID int64, opts QueryOptions) ([]*UnavailableTime, Metadata, error) {
+	orderBy, err := opts.orderBy(unavailableTimeSortColumns, "specific_date", "id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, student_id, title, day_of_week, start_time, end_time, is_recurring, specific_date
         FROM unavailable_times
         WHERE student_id = $1
-        ORDER BY day_of_week, start_time
-    `
-	rows, err := m.DB.QueryContext(ctx, query, studentID)
+          AND ($2::date IS NULL OR specific_date IS NULL OR specific_date >= $2)
+          AND ($3::date IS NULL OR specific_date IS NULL OR specific_date <= $3)
+        %s
+        LIMIT $4 OFFSET $5`, orderBy)
+	args := []any{studentID, opts.from(), opts.to(), opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all unavailable times for student: %w", err)
+		return nil, Metadata{}, fmt.Errorf("failed to get all unavailable times for student: %w", wrapError(err))
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var times []*UnavailableTime
 	for rows.Next() {
 		var ut UnavailableTime
 		err := rows.Scan(
+			&totalRecords,
 			&ut.ID,
 			&ut.StudentID,
 			&ut.Title,
@@ -100,16 +148,22 @@ func (m *UnavailableTimeModel) GetAllForStudent(ctx context.Context, studentID i
 			&ut.StartTime,
 			&ut.EndTime,
 			&ut.IsRecurring,
+			&ut.SpecificDate,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan unavailable time row: %w", err)
+			return nil, Metadata{}, fmt.Errorf("failed to scan unavailable time row: %w", wrapError(err))
 		}
 		times = append(times, &ut)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %w", err)
+		return nil, Metadata{}, fmt.Errorf("rows error: %w", wrapError(err))
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
 	}
 
-	return times, nil
+	return times, metadata, nil
 }
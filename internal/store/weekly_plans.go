@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -13,6 +14,14 @@ type WeeklyPlan struct {
 	StartDateOfWeek          time.Time    `json:"start_date_of_week"`
 	DayStartTime             sql.NullTime `json:"day_start_time,omitempty"`
 	MaxStudyTimeHoursPerWeek int          `json:"max_study_time_hours_per_week,omitempty"`
+	// GenerationSeed is the seed the plan's sessions were last generated
+	// with. It is set by SetGenerationSeed only.
+	GenerationSeed sql.NullInt64 `json:"generation_seed,omitempty"`
+	// The daily limits bound how the scheduler fills each day; NULL means
+	// no limit.
+	MaxBlocksPerDay            sql.NullInt64 `json:"max_blocks_per_day,omitempty"`
+	MaxBookBlocksPerDay        sql.NullInt64 `json:"max_book_blocks_per_day,omitempty"`
+	MinDaysBetweenBookSessions sql.NullInt64 `json:"min_days_between_book_sessions,omitempty"`
 }
 
 type WeeklyPlanModel struct {
@@ -21,16 +30,18 @@ type WeeklyPlanModel struct {
 
 func (m *WeeklyPlanModel) Insert(ctx context.Context, wp *WeeklyPlan) error {
 	query := `
-        INSERT INTO weekly_plans (student_id, start_date_of_week, day_start_time, max_study_time_hours_per_week)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO weekly_plans (student_id, start_date_of_week, day_start_time, max_study_time_hours_per_week,
+            max_blocks_per_day, max_book_blocks_per_day, min_days_between_book_sessions)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
         RETURNING id`
 
-	args := []any{wp.StudentID, wp.StartDateOfWeek, wp.DayStartTime, wp.MaxStudyTimeHoursPerWeek}
+	args := []any{wp.StudentID, wp.StartDateOfWeek, wp.DayStartTime, wp.MaxStudyTimeHoursPerWeek,
+		wp.MaxBlocksPerDay, wp.MaxBookBlocksPerDay, wp.MinDaysBetweenBookSessions}
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&wp.ID)
+	return wrapError(m.DB.QueryRowContext(ctx, query, args...).Scan(&wp.ID))
 }
 
 func (m *WeeklyPlanModel) Get(ctx context.Context, id int64) (*WeeklyPlan, error) {
@@ -39,7 +50,8 @@ func (m *WeeklyPlanModel) Get(ctx context.Context, id int64) (*WeeklyPlan, error
 	}
 
 	query := `
-        SELECT id, student_id, start_date_of_week, day_start_time, max_study_time_hours_per_week
+        SELECT id, student_id, start_date_of_week, day_start_time, max_study_time_hours_per_week, generation_seed,
+            max_blocks_per_day, max_book_blocks_per_day, min_days_between_book_sessions
         FROM weekly_plans
         WHERE id = $1`
 
@@ -53,76 +65,165 @@ func (m *WeeklyPlanModel) Get(ctx context.Context, id int64) (*WeeklyPlan, error
 		&wp.StartDateOfWeek,
 		&wp.DayStartTime,
 		&wp.MaxStudyTimeHoursPerWeek,
+		&wp.GenerationSeed,
+		&wp.MaxBlocksPerDay,
+		&wp.MaxBookBlocksPerDay,
+		&wp.MinDaysBetweenBookSessions,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrorNotFound
 		}
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	return &wp, nil
 }
 
-func (m *WeeklyPlanModel) GetAllForStudent(ctx context.Context, studentID int64) ([]*WeeklyPlan, error) {
+func (m *WeeklyPlanModel) GetByStudentAndStartDate(ctx context.Context, studentID int64, startDateOfWeek time.Time) (*WeeklyPlan, error) {
 	query := `
-        SELECT id, student_id, start_date_of_week, day_start_time, max_study_time_hours_per_week
+        SELECT id, student_id, start_date_of_week, day_start_time, max_study_time_hours_per_week, generation_seed,
+            max_blocks_per_day, max_book_blocks_per_day, min_days_between_book_sessions
+        FROM weekly_plans
+        WHERE student_id = $1 AND start_date_of_week = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var wp WeeklyPlan
+	err := m.DB.QueryRowContext(ctx, query, studentID, startDateOfWeek).Scan(
+		&wp.ID,
+		&wp.StudentID,
+		&wp.StartDateOfWeek,
+		&wp.DayStartTime,
+		&wp.MaxStudyTimeHoursPerWeek,
+		&wp.GenerationSeed,
+		&wp.MaxBlocksPerDay,
+		&wp.MaxBookBlocksPerDay,
+		&wp.MinDaysBetweenBookSessions,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrorNotFound
+		}
+		return nil, wrapError(err)
+	}
+
+	return &wp, nil
+}
+
+var weeklyPlanSortColumns = map[string]string{
+	"start_date_of_week": "start_date_of_week",
+	"id":                 "id",
+}
+
+func (m *WeeklyPlanModel) GetAllForStudent(ctx context.Context, studentID int64, opts QueryOptions) ([]*WeeklyPlan, Metadata, error) {
+	orderBy, err := opts.orderBy(weeklyPlanSortColumns, "-start_date_of_week", "id")
+	if err != nil {
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, student_id, start_date_of_week, day_start_time, max_study_time_hours_per_week, generation_seed,
+            max_blocks_per_day, max_book_blocks_per_day, min_days_between_book_sessions
         FROM weekly_plans
         WHERE student_id = $1
-        ORDER BY start_date_of_week DESC`
+          AND ($2::date IS NULL OR start_date_of_week >= $2)
+          AND ($3::date IS NULL OR start_date_of_week <= $3)
+        %s
+        LIMIT $4 OFFSET $5`, orderBy)
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, studentID)
+	args := []any{studentID, opts.from(), opts.to(), opts.limit(), opts.offset()}
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	var plans []*WeeklyPlan
 	for rows.Next() {
 		var wp WeeklyPlan
 		err := rows.Scan(
+			&totalRecords,
 			&wp.ID,
 			&wp.StudentID,
 			&wp.StartDateOfWeek,
 			&wp.DayStartTime,
 			&wp.MaxStudyTimeHoursPerWeek,
+			&wp.GenerationSeed,
+			&wp.MaxBlocksPerDay,
+			&wp.MaxBookBlocksPerDay,
+			&wp.MinDaysBetweenBookSessions,
 		)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, wrapError(err)
 		}
 		plans = append(plans, &wp)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, wrapError(err)
+	}
+
+	metadata, err := pageMetadata(ctx, m.DB, query, args, totalRecords, opts)
+	if err != nil {
+		return nil, Metadata{}, err
 	}
 
-	return plans, nil
+	return plans, metadata, nil
 }
 
 func (m *WeeklyPlanModel) Update(ctx context.Context, wp *WeeklyPlan) error {
 	query := `
         UPDATE weekly_plans
-        SET start_date_of_week = $1, day_start_time = $2, max_study_time_hours_per_week = $3
-        WHERE id = $4 AND student_id = $5`
+        SET start_date_of_week = $1, day_start_time = $2, max_study_time_hours_per_week = $3,
+            max_blocks_per_day = $4, max_book_blocks_per_day = $5, min_days_between_book_sessions = $6
+        WHERE id = $7 AND student_id = $8`
 
-	args := []any{wp.StartDateOfWeek, wp.DayStartTime, wp.MaxStudyTimeHoursPerWeek, wp.ID, wp.StudentID}
+	args := []any{wp.StartDateOfWeek, wp.DayStartTime, wp.MaxStudyTimeHoursPerWeek,
+		wp.MaxBlocksPerDay, wp.MaxBookBlocksPerDay, wp.MinDaysBetweenBookSessions, wp.ID, wp.StudentID}
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	result, err := m.DB.ExecContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return wrapError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrorNotFound
+	}
+
+	return nil
+}
+
+// SetGenerationSeed records the seed the plan's sessions were generated with.
+func (m *WeeklyPlanModel) SetGenerationSeed(ctx context.Context, id int64, seed int64) error {
+	query := `UPDATE weekly_plans SET generation_seed = $1 WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, seed, id)
+	if err != nil {
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -144,12 +245,12 @@ func (m *WeeklyPlanModel) Delete(ctx context.Context, id int64) error {
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return wrapError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -9,6 +9,8 @@ DELETE FROM daily_plans;
 DELETE FROM subject_frequencies;
 DELETE FROM weekly_plans;
 DELETE FROM unavailable_times;
+DELETE FROM exam_results;
+DELETE FROM exam_enrollments;
 DELETE FROM exam_scope_items;
 DELETE FROM exam_schedules;
 DELETE FROM template_rules;
@@ -929,3 +931,11 @@ INSERT INTO template_rules (template_id, book_id, default_frequency, scheduling_
 ((SELECT id FROM schedule_templates WHERE name = 'دهم انسانی - ۲۴ بلوک'), (SELECT id FROM books WHERE title = 'جامعه شناسی (۱)'), 2, 'contiguous_pair', TRUE, NULL, NULL),
 ((SELECT id FROM schedule_templates WHERE name = 'دهم انسانی - ۲۴ بلوک'), (SELECT id FROM books WHERE title = 'تاریخ (۱)'), 2, NULL, FALSE, NULL, NULL);
 
+
+-- Step 10: Book difficulties; books not listed keep the default of medium
+UPDATE books SET difficulty = 3
+WHERE title LIKE 'ریاضی%' OR title LIKE 'حسابان%' OR title LIKE 'هندسه%' OR title LIKE 'فیزیک%'
+   OR title LIKE 'شیمی%' OR title = 'آمار و احتمال';
+UPDATE books SET difficulty = 1
+WHERE title LIKE 'دین و زندگی%' OR title LIKE 'نگارش%'
+   OR title IN ('آمادگی دفاعی', 'سلامت و بهداشت', 'مدیریت خانواده و سبک زندگی');